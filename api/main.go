@@ -17,6 +17,7 @@ import (
 	"kd-api/src/config"
 	"kd-api/src/middlewares"
 	"kd-api/src/routes"
+	"kd-api/src/services"
 )
 
 func main() {
@@ -26,6 +27,12 @@ func main() {
 	// Connect DB
 	config.ConnectDatabase()
 
+	// Periodic inventory ledger integrity check (disabled unless LEDGER_CHECK_INTERVAL is set)
+	services.StartLedgerCheckJob(config.LedgerCheckInterval())
+
+	// Idempotency keys are kept for IDEMPOTENCY_TTL, expired ones are cleared hourly
+	services.StartIdempotencyCleanupJob()
+
 	r := gin.Default()
 	r.MaxMultipartMemory = 8 << 20 // 8 MB max memory for multipart uploads
 
@@ -45,7 +52,8 @@ func main() {
 			return false
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Idempotent-Replayed"},
 		AllowCredentials: true,
 	}))
 
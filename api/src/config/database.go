@@ -50,22 +50,100 @@ func ConnectDatabase() {
 	sqlDB.SetConnMaxIdleTime(5 * time.Minute)
 
 	err = db.AutoMigrate(
+		&models.Customer{},
+		&models.CustomerAddress{},
 		&models.Item{},
 		&models.Transaction{},
 		&models.TransactionItem{},
+		&models.TransactionPayment{},
+		&models.Refund{},
+		&models.RefundLine{},
+		&models.Exchange{},
+		&models.CustomerPayment{},
+		&models.Promotion{},
+		&models.TransactionPromotion{},
+		&models.CustomerPaymentAllocation{},
 		&models.User{},
 		&models.Attendance{},
 		&models.CashSession{},
 		&models.InventoryLog{},
 		&models.POBill{},
 		&models.Image{},
+		&models.StockReservation{},
+		&models.ItemCostHistory{},
+		&models.ItemPriceHistory{},
+		&models.StockWriteOff{},
+		&models.Backorder{},
+		&models.StockShortage{},
+		&models.Supplier{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderLine{},
+		&models.DocumentSequence{},
+		&models.DeliveryOrder{},
+		&models.DeliveryOrderLine{},
+		&models.Vehicle{},
+		&models.Quotation{},
+		&models.QuotationLine{},
+		&models.PaymentScheduleEntry{},
+		&models.IdempotentRequest{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database: ", err)
 	}
 
 	// Forcibly update users role ENUM to include 'dev' because GORM AutoMigrate doesn't modify existing ENUMs
-	db.Exec("ALTER TABLE users MODIFY COLUMN role ENUM('admin','cashier','owner','dev') DEFAULT 'cashier';")
+	db.Exec("ALTER TABLE users MODIFY COLUMN role ENUM('admin','cashier','owner','dev','driver') DEFAULT 'cashier';")
+	db.Exec("ALTER TABLE inventory_logs MODIFY COLUMN type ENUM('sale','refund','adjustment','restock','audit','delete','write_off','void') NOT NULL;")
+	db.Exec("ALTER TABLE transactions MODIFY COLUMN status ENUM('draft','partially_paid','completed','partially_refunded','refunded','voided') DEFAULT 'draft';")
+	db.Exec("ALTER TABLE stock_reservations MODIFY COLUMN type ENUM('draft','delivery','layaway') NOT NULL;")
+	db.Exec("ALTER TABLE transactions MODIFY COLUMN payment_type ENUM('cash','qris','debit','credit','split','exchange');")
+	db.Exec("ALTER TABLE transaction_payments MODIFY COLUMN method ENUM('cash','qris','debit','credit','exchange') NOT NULL;")
+	db.Exec("ALTER TABLE refunds MODIFY COLUMN method ENUM('cash','qris','debit','credit','exchange') NOT NULL;")
+
+	// Backfill one tender per sale paid before split payments existed
+	db.Exec("INSERT INTO transaction_payments (transaction_id, method, amount, created_at) " +
+		"SELECT t.id, COALESCE(t.payment_type, 'cash'), t.payment, t.created_at FROM transactions t " +
+		"WHERE t.payment IS NOT NULL AND NOT EXISTS (SELECT 1 FROM transaction_payments p WHERE p.transaction_id = t.id);")
+
+	// Whole-sale refunds from before line refunds returned every line
+	db.Exec("UPDATE transaction_items ti JOIN transactions t ON t.id = ti.transaction_id " +
+		"SET ti.refunded_quantity = ti.quantity WHERE t.status = 'refunded' AND ti.refunded_quantity = 0;")
+
+	// Lines sold before list prices were kept were sold at list price unless a custom price was typed in
+	db.Exec("UPDATE transaction_items SET list_price = price WHERE list_price = 0;")
+
+	// Sales from before invoice numbering keep the id-based number they were printed with
+	db.Exec("UPDATE transactions SET number = CONCAT('TX-', id) WHERE number IS NULL AND status <> 'draft';")
+
+	// Items from before cost and price history start them at their prices on the day of the backfill,
+	// earlier prices were never kept so dates before it still fall back to the current price
+	if err := db.Exec("INSERT INTO item_cost_histories (item_id, buy_price, effective_at) " +
+		"SELECT i.id, i.buy_price, NOW() FROM items i " +
+		"WHERE NOT EXISTS (SELECT 1 FROM item_cost_histories h WHERE h.item_id = i.id);").Error; err != nil {
+		log.Println("Failed to backfill item cost history: ", err)
+	}
+	if err := db.Exec("INSERT INTO item_price_histories (item_id, price, effective_at) " +
+		"SELECT i.id, i.price, NOW() FROM items i " +
+		"WHERE NOT EXISTS (SELECT 1 FROM item_price_histories h WHERE h.item_id = i.id);").Error; err != nil {
+		log.Println("Failed to backfill item price history: ", err)
+	}
+
+	// Link sale ledger rows to their transaction, they were only found by number before
+	db.Exec("UPDATE inventory_logs l JOIN transactions t ON l.reference_id IN (t.number, CONCAT(t.number, ' (BACKORDER)')) " +
+		"SET l.transaction_id = t.id WHERE l.transaction_id IS NULL AND l.type = 'sale';")
+	db.Exec("UPDATE inventory_logs l JOIN transactions t ON l.reference_id IN (CONCAT('TX-', t.id), CONCAT('TX-', t.id, ' (BACKORDER)')) " +
+		"SET l.transaction_id = t.id WHERE l.transaction_id IS NULL AND l.type = 'sale';")
+
+	// Deliver sales completed before stock holds existed had their stock taken at completion,
+	// they count as delivered so it is neither taken again nor left out of a refund
+	db.Exec("UPDATE transactions t SET t.delivered_at = t.created_at " +
+		"WHERE t.transaction_type = 'deliver' AND t.delivered_at IS NULL AND t.status IN ('completed','partially_refunded','refunded') " +
+		"AND NOT EXISTS (SELECT 1 FROM stock_reservations r WHERE r.transaction_id = t.id AND r.type = 'delivery') " +
+		"AND EXISTS (SELECT 1 FROM inventory_logs l WHERE l.type = 'sale' AND l.reference_id = CONCAT('TX-', t.id));")
+
+	// Deliveries made before delivery orders went out in one go
+	db.Exec("UPDATE transaction_items ti JOIN transactions t ON t.id = ti.transaction_id " +
+		"SET ti.delivered_quantity = ti.quantity - ti.refunded_quantity WHERE t.delivered_at IS NOT NULL AND ti.delivered_quantity = 0;")
 
 	// CI-only: seed test user (only when SEED_TEST_USER=true)
 	SeedTestUser(db)
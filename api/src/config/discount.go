@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MaxLineDiscountPercent returns the largest discount a role may give on a sale line,
+// as a percent of the line at list price. Read from MAX_LINE_DISCOUNT_<ROLE>
+// (e.g. MAX_LINE_DISCOUNT_CASHIER=10). Without it owners and devs are unlimited,
+// admins get 25% and cashiers 10%.
+func MaxLineDiscountPercent(role string) float64 {
+	if role != "" {
+		if limit, err := strconv.ParseFloat(os.Getenv("MAX_LINE_DISCOUNT_"+strings.ToUpper(role)), 64); err == nil && limit >= 0 {
+			return limit
+		}
+	}
+
+	switch role {
+	case "owner", "dev":
+		return 100
+	case "admin":
+		return 25
+	default:
+		return 10
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// IdempotencyTTL is how long an Idempotency-Key and its stored response are kept,
+// read from IDEMPOTENCY_TTL (e.g. "24h") and 24 hours by default.
+func IdempotencyTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL"))
+	if err != nil || ttl <= 0 {
+		return 24 * time.Hour
+	}
+	return ttl
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReserveDraftStock reports whether draft transactions should hold stock.
+// Controlled by RESERVE_DRAFT_STOCK, disabled unless set to a true value.
+func ReserveDraftStock() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("RESERVE_DRAFT_STOCK"))
+	return enabled
+}
+
+// DraftReservationTTL returns how long a draft keeps its stock on hold.
+// Read from DRAFT_RESERVATION_TTL as a Go duration (e.g. "30m", "2h"), defaults to 2 hours.
+func DraftReservationTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("DRAFT_RESERVATION_TTL"))
+	if err != nil || ttl <= 0 {
+		return 2 * time.Hour
+	}
+	return ttl
+}
+
+// LedgerCheckInterval returns how often the inventory ledger integrity check runs.
+// Read from LEDGER_CHECK_INTERVAL as a Go duration, empty or invalid disables the job.
+func LedgerCheckInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("LEDGER_CHECK_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 0
+	}
+	return interval
+}
+
+// NegativeStockPolicy returns how sales beyond the stock on hand are handled for a role:
+// "reject", "allow" (stock goes negative) or "backorder".
+// NEGATIVE_STOCK_POLICY_<ROLE> (e.g. NEGATIVE_STOCK_POLICY_OWNER) overrides NEGATIVE_STOCK_POLICY,
+// which defaults to "allow". The bool is true when the policy came from a role override.
+func NegativeStockPolicy(role string) (string, bool) {
+	if role != "" {
+		if policy := os.Getenv("NEGATIVE_STOCK_POLICY_" + strings.ToUpper(role)); IsValidStockPolicy(policy) {
+			return policy, true
+		}
+	}
+
+	if policy := os.Getenv("NEGATIVE_STOCK_POLICY"); IsValidStockPolicy(policy) {
+		return policy, false
+	}
+	return "allow", false
+}
+
+func IsValidStockPolicy(policy string) bool {
+	return policy == "reject" || policy == "allow" || policy == "backorder"
+}
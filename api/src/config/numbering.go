@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Document types with their own number sequence
+const (
+	DocInvoice         = "invoice"
+	DocRefund          = "refund"
+	DocExchange        = "exchange"
+	DocPurchaseOrder   = "purchase_order"
+	DocPOBill          = "po_bill"
+	DocCustomerPayment = "customer_payment"
+	DocDeliveryOrder   = "delivery_order"
+	DocQuotation       = "quotation"
+)
+
+var defaultNumberFormats = map[string]string{
+	DocInvoice:         "INV/{YYYY}/{MM}/{SEQ:5}",
+	DocRefund:          "RET/{YYYY}/{MM}/{SEQ:5}",
+	DocExchange:        "EXC/{YYYY}/{MM}/{SEQ:5}",
+	DocPurchaseOrder:   "PO/{YYYY}/{MM}/{SEQ:5}",
+	DocPOBill:          "BILL/{YYYY}/{MM}/{SEQ:5}",
+	DocCustomerPayment: "PAY/{YYYY}/{MM}/{SEQ:5}",
+	DocDeliveryOrder:   "DO/{YYYY}/{MM}/{SEQ:5}",
+	DocQuotation:       "QUO/{YYYY}/{MM}/{SEQ:5}",
+}
+
+// DocumentNumberFormat returns the number template for a document type, read from
+// NUMBER_FORMAT_<TYPE> (e.g. NUMBER_FORMAT_INVOICE). Templates use {YYYY}, {YY}, {MM}
+// and {SEQ} or {SEQ:n} for a zero-padded counter; one without {SEQ} is ignored.
+// The counter restarts every month when {MM} is used, every year with only {YYYY}/{YY}.
+func DocumentNumberFormat(docType string) string {
+	format := strings.TrimSpace(os.Getenv("NUMBER_FORMAT_" + strings.ToUpper(docType)))
+	if !strings.Contains(format, "{SEQ") {
+		return defaultNumberFormats[docType]
+	}
+	return format
+}
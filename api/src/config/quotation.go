@@ -0,0 +1,15 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// QuotationValidDays is how long a quotation holds its prices when no valid_until is given,
+// read from QUOTATION_VALID_DAYS and 14 days by default.
+func QuotationValidDays() int {
+	if days, err := strconv.Atoi(os.Getenv("QUOTATION_VALID_DAYS")); err == nil && days > 0 {
+		return days
+	}
+	return 14
+}
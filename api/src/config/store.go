@@ -0,0 +1,30 @@
+package config
+
+import "os"
+
+// StoreProfile is the shop identity printed on receipts and delivery notes
+type StoreProfile struct {
+	Name    string
+	Address string
+	Phone   string
+	NPWP    string
+	Footer  string
+}
+
+// Store reads the store profile from STORE_NAME, STORE_ADDRESS, STORE_PHONE, STORE_NPWP and STORE_RECEIPT_FOOTER
+func Store() StoreProfile {
+	profile := StoreProfile{
+		Name:    os.Getenv("STORE_NAME"),
+		Address: os.Getenv("STORE_ADDRESS"),
+		Phone:   os.Getenv("STORE_PHONE"),
+		NPWP:    os.Getenv("STORE_NPWP"),
+		Footer:  os.Getenv("STORE_RECEIPT_FOOTER"),
+	}
+	if profile.Name == "" {
+		profile.Name = "Klampis Depo"
+	}
+	if profile.Footer == "" {
+		profile.Footer = "Thank you for shopping with us"
+	}
+	return profile
+}
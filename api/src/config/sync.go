@@ -0,0 +1,16 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// SyncMaxOfflineAge is how far back the mobile POS may date a sale made offline,
+// read from SYNC_MAX_OFFLINE_AGE (e.g. "72h") and 72 hours by default.
+func SyncMaxOfflineAge() time.Duration {
+	age, err := time.ParseDuration(os.Getenv("SYNC_MAX_OFFLINE_AGE"))
+	if err != nil || age <= 0 {
+		return 72 * time.Hour
+	}
+	return age
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// TaxRate returns the standard PPN rate in percent, read from PPN_RATE and defaulting to 11.
+// Items can override it with their own rate.
+func TaxRate() float64 {
+	rate, err := strconv.ParseFloat(os.Getenv("PPN_RATE"), 64)
+	if err != nil || rate < 0 {
+		return 11
+	}
+	return rate
+}
+
+// PricesIncludeTax reports whether item prices already contain PPN (PRICES_INCLUDE_TAX,
+// default true). When false, PPN is added on top of the sale total.
+func PricesIncludeTax() bool {
+	include, err := strconv.ParseBool(os.Getenv("PRICES_INCLUDE_TAX"))
+	if err != nil {
+		return true
+	}
+	return include
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+	"kd-api/src/utils/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBackorders handles GET /inventory/backorders
+func GetBackorders(c *gin.Context) {
+	var filter dtos.BackorderFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewBackorderService()
+	response, err := service.GetBackorders(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// FulfillBackorder handles POST /inventory/backorders/:id/fulfill
+func FulfillBackorder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid backorder id"})
+		return
+	}
+
+	service := services.NewBackorderService()
+	backorder, err := service.FulfillBackorder(uint(id), common.GetUserID(c))
+	if err != nil {
+		if err.Error() == "backorder not found" || err.Error() == "item not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "only open backorders can be fulfilled" || strings.HasPrefix(err.Error(), "insufficient stock") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, backorder)
+}
+
+// CancelBackorder handles POST /inventory/backorders/:id/cancel
+func CancelBackorder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid backorder id"})
+		return
+	}
+
+	service := services.NewBackorderService()
+	backorder, err := service.CancelBackorder(uint(id))
+	if err != nil {
+		if err.Error() == "backorder not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "only open backorders can be cancelled" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, backorder)
+}
+
+// GetStockShortages handles GET /inventory/shortages
+func GetStockShortages(c *gin.Context) {
+	var filter dtos.StockShortageFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewBackorderService()
+	response, err := service.GetStockShortages(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCustomers handles GET /customers?q=&group=
+func GetCustomers(c *gin.Context) {
+	var filter dtos.CustomerFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewCustomerService()
+	response, err := service.GetCustomers(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCustomerByID handles GET /customers/:id
+func GetCustomerByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	service := services.NewCustomerService()
+	customer, err := service.GetCustomerByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// CreateCustomer handles POST /customers
+func CreateCustomer(c *gin.Context) {
+	var input dtos.CreateCustomerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewCustomerService()
+	customer, err := service.CreateCustomer(input)
+	if err != nil {
+		handleCustomerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, customer)
+}
+
+// UpdateCustomer handles PUT /customers/:id
+func UpdateCustomer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	var input dtos.UpdateCustomerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewCustomerService()
+	customer, err := service.UpdateCustomer(uint(id), input)
+	if err != nil {
+		handleCustomerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// DeleteCustomer handles DELETE /customers/:id
+func DeleteCustomer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	service := services.NewCustomerService()
+	if err := service.DeleteCustomer(uint(id)); err != nil {
+		handleCustomerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Customer deleted"})
+}
+
+// AddCustomerAddress handles POST /customers/:id/addresses
+func AddCustomerAddress(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	var input dtos.CustomerAddressInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewCustomerService()
+	address, err := service.AddAddress(uint(id), input)
+	if err != nil {
+		handleCustomerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+// UpdateCustomerAddress handles PUT /customers/:id/addresses/:addressId
+func UpdateCustomerAddress(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+	addressID, err := strconv.ParseUint(c.Param("addressId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address id"})
+		return
+	}
+
+	var input dtos.CustomerAddressInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewCustomerService()
+	address, err := service.UpdateAddress(uint(id), uint(addressID), input)
+	if err != nil {
+		handleCustomerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// DeleteCustomerAddress handles DELETE /customers/:id/addresses/:addressId
+func DeleteCustomerAddress(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+	addressID, err := strconv.ParseUint(c.Param("addressId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address id"})
+		return
+	}
+
+	service := services.NewCustomerService()
+	if err := service.DeleteAddress(uint(id), uint(addressID)); err != nil {
+		handleCustomerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Address deleted"})
+}
+
+// GetCustomerHistory handles GET /customers/:id/history
+func GetCustomerHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	var filter dtos.CustomerHistoryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewCustomerService()
+	response, err := service.GetHistory(uint(id), filter)
+	if err != nil {
+		handleCustomerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func handleCustomerError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "customer not found", "address not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "customer with this phone already exists":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+	"kd-api/src/utils/common"
+	"kd-api/src/utils/receipt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleDeliveryOrderError maps delivery order errors to status codes
+func handleDeliveryOrderError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "delivery order not found" || message == "transaction not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case message == "delivery order is assigned to another driver":
+		c.JSON(http.StatusForbidden, gin.H{"error": message})
+	case strings.HasPrefix(message, "invalid ") ||
+		strings.HasPrefix(message, "only ") ||
+		strings.HasPrefix(message, "cannot ") ||
+		strings.HasPrefix(message, "delivery ") ||
+		strings.HasPrefix(message, "delivered ") ||
+		strings.HasPrefix(message, "insufficient stock") ||
+		strings.HasSuffix(message, " is required") ||
+		strings.HasSuffix(message, " not found") ||
+		strings.HasPrefix(message, "vehicle ") ||
+		message == "nothing left to deliver" ||
+		message == "transaction already delivered":
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// CreateDeliveryOrder handles POST /delivery-orders
+func CreateDeliveryOrder(c *gin.Context) {
+	var input dtos.CreateDeliveryOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDeliveryOrderService()
+	order, warnings, err := service.CreateDeliveryOrder(input, common.GetUserID(c))
+	if err != nil {
+		handleDeliveryOrderError(c, err)
+		return
+	}
+
+	response := gin.H{"delivery_order": order}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetDeliveryOrders handles GET /delivery-orders?status=&date=&driver_id=&transaction_id=
+func GetDeliveryOrders(c *gin.Context) {
+	var filter dtos.DeliveryOrderFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDeliveryOrderService()
+	response, err := service.GetDeliveryOrders(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDeliveryOrderByID handles GET /delivery-orders/:id
+func GetDeliveryOrderByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery order id"})
+		return
+	}
+
+	service := services.NewDeliveryOrderService()
+	order, err := service.GetDeliveryOrderByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// UpdateDeliveryOrder handles PUT /delivery-orders/:id
+func UpdateDeliveryOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery order id"})
+		return
+	}
+
+	var input dtos.UpdateDeliveryOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDeliveryOrderService()
+	order, warnings, err := service.UpdateDeliveryOrder(uint(id), input)
+	if err != nil {
+		handleDeliveryOrderError(c, err)
+		return
+	}
+
+	response := gin.H{"delivery_order": order}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateDeliveryOrderStatus handles PATCH /delivery-orders/:id/status
+func UpdateDeliveryOrderStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery order id"})
+		return
+	}
+
+	var input dtos.DeliveryStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDeliveryOrderService()
+	order, warnings, err := service.UpdateDeliveryStatus(uint(id), input, common.GetUserID(c), common.GetUserRole(c), c.ClientIP())
+	if err != nil {
+		handleDeliveryOrderError(c, err)
+		return
+	}
+
+	response := gin.H{"delivery_order": order}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDriverDeliveries handles GET /delivery-orders/my-day?date=
+// Drivers always get their own list, staff pick a driver with driver_id.
+func GetDriverDeliveries(c *gin.Context) {
+	var filter dtos.DriverDayFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	driverID := common.GetUintValue(common.GetUserID(c))
+	if common.GetUserRole(c) != "driver" {
+		if filter.DriverID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "driver_id is required"})
+			return
+		}
+		driverID = *filter.DriverID
+	}
+
+	service := services.NewDeliveryOrderService()
+	orders, err := service.GetDriverDay(driverID, filter.Date)
+	if err != nil {
+		handleDeliveryOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetDeliverySchedule handles GET /delivery-orders/schedule?date=
+func GetDeliverySchedule(c *gin.Context) {
+	var filter dtos.DeliveryScheduleFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDeliveryOrderService()
+	schedule, err := service.GetSchedule(filter.Date)
+	if err != nil {
+		handleDeliveryOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// AssignDeliveryVehicle handles POST /delivery-orders/schedule/assign
+func AssignDeliveryVehicle(c *gin.Context) {
+	var input dtos.AssignVehicleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDeliveryOrderService()
+	run, err := service.AssignVehicle(input)
+	if err != nil {
+		handleDeliveryOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// GetDeliveryOrderNote handles GET /delivery-orders/:id/delivery-note.pdf
+func GetDeliveryOrderNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery order id"})
+		return
+	}
+
+	service := services.NewDeliveryOrderService()
+	order, err := service.GetDeliveryOrderByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := receipt.BuildDeliveryOrderNote(order, config.Store())
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"delivery-order-%d.pdf\"", order.ID))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
@@ -24,3 +24,57 @@ func GetInventoryHistory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+func GetStockReservations(c *gin.Context) {
+	var filter dtos.ReservationFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewReservationService()
+	response, err := service.GetReservations(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func GetInventoryValuation(c *gin.Context) {
+	service := services.NewInventoryService()
+	response, err := service.GetValuation(c.Query("as_of"))
+	if err != nil {
+		if err.Error() == "invalid as_of, use YYYY-MM-DD or RFC3339" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func ExportInventoryValuation(c *gin.Context) {
+	service := services.NewInventoryService()
+	valuation, err := service.GetValuation(c.Query("as_of"))
+	if err != nil {
+		if err.Error() == "invalid as_of, use YYYY-MM-DD or RFC3339" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Disposition", "attachment; filename=\"inventory-valuation.csv\"")
+	c.Header("Content-Type", "text/csv")
+
+	if err := service.ExportValuation(c.Writer, valuation); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+	"kd-api/src/utils/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLedgerIntegrity replays the inventory ledger and reports chain breaks and stock mismatches
+func GetLedgerIntegrity(c *gin.Context) {
+	itemID, _ := strconv.ParseUint(c.DefaultQuery("item_id", "0"), 10, 64)
+
+	service := services.NewLedgerService()
+	report, err := service.CheckIntegrity(uint(itemID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RepairLedger previews or (with confirm=true) posts corrective adjustment entries
+func RepairLedger(c *gin.Context) {
+	var input dtos.LedgerRepairInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewLedgerService()
+	response, err := service.Repair(input, common.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
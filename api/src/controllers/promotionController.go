@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPromotions handles GET /promotions?q=&type=&active_only=
+func GetPromotions(c *gin.Context) {
+	var filter dtos.PromotionFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewPromotionService()
+	response, err := service.GetPromotions(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPromotionByID handles GET /promotions/:id
+func GetPromotionByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid promotion id"})
+		return
+	}
+
+	service := services.NewPromotionService()
+	promotion, err := service.GetPromotionByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// CreatePromotion handles POST /promotions
+func CreatePromotion(c *gin.Context) {
+	var input dtos.CreatePromotionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewPromotionService()
+	promotion, err := service.CreatePromotion(input)
+	if err != nil {
+		handlePromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// UpdatePromotion handles PUT /promotions/:id
+func UpdatePromotion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid promotion id"})
+		return
+	}
+
+	var input dtos.UpdatePromotionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewPromotionService()
+	promotion, err := service.UpdatePromotion(uint(id), input)
+	if err != nil {
+		handlePromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// DeletePromotion handles DELETE /promotions/:id
+func DeletePromotion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid promotion id"})
+		return
+	}
+
+	service := services.NewPromotionService()
+	if err := service.DeletePromotion(uint(id)); err != nil {
+		handlePromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted"})
+}
+
+// GetPromotionCost handles GET /reports/promotions?start_date=&end_date=
+func GetPromotionCost(c *gin.Context) {
+	var filter dtos.PromotionCostFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewPromotionService()
+	response, err := service.GetPromotionCost(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func handlePromotionError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "promotion not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "invalid promotion"),
+		err.Error() == "coupon code already exists",
+		err.Error() == "item not found":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+	"kd-api/src/utils/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReorderSuggestions handles GET /purchase-orders/suggestions
+func GetReorderSuggestions(c *gin.Context) {
+	var filter dtos.ReorderFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewReorderService()
+	response, err := service.GetSuggestions(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreatePurchaseOrder handles POST /purchase-orders
+func CreatePurchaseOrder(c *gin.Context) {
+	var input dtos.CreatePurchaseOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewPurchaseOrderService()
+	order, err := service.CreatePurchaseOrder(input, common.GetUserID(c))
+	if err != nil {
+		handlePurchaseOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// CreatePurchaseOrderFromSuggestions handles POST /purchase-orders/from-suggestions
+func CreatePurchaseOrderFromSuggestions(c *gin.Context) {
+	var input dtos.CreatePurchaseOrderFromSuggestionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewPurchaseOrderService()
+	order, err := service.CreateFromSuggestions(input, common.GetUserID(c))
+	if err != nil {
+		handlePurchaseOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// GetPurchaseOrders handles GET /purchase-orders
+func GetPurchaseOrders(c *gin.Context) {
+	var filter dtos.PurchaseOrderFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewPurchaseOrderService()
+	response, err := service.GetPurchaseOrders(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPurchaseOrderByID handles GET /purchase-orders/:id
+func GetPurchaseOrderByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order id"})
+		return
+	}
+
+	service := services.NewPurchaseOrderService()
+	order, err := service.GetPurchaseOrderByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// UpdatePurchaseOrderStatus handles PUT /purchase-orders/:id/status
+func UpdatePurchaseOrderStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order id"})
+		return
+	}
+
+	var input dtos.UpdatePurchaseOrderStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewPurchaseOrderService()
+	order, err := service.UpdateStatus(uint(id), input, common.GetUserID(c), c.ClientIP())
+	if err != nil {
+		handlePurchaseOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func handlePurchaseOrderError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "purchase order not found" || msg == "supplier not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "item ") && strings.HasSuffix(msg, " not found"),
+		strings.HasPrefix(msg, "cannot change purchase order"),
+		msg == "no reorder suggestions for this supplier":
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+	}
+}
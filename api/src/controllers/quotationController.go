@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+	"kd-api/src/utils/common"
+	"kd-api/src/utils/receipt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleQuotationError maps quotation errors to status codes
+func handleQuotationError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "quotation not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case strings.HasPrefix(message, "invalid ") ||
+		strings.HasPrefix(message, "only ") ||
+		strings.HasPrefix(message, "quotation ") ||
+		strings.HasPrefix(message, "discount") ||
+		strings.HasPrefix(message, "item ") ||
+		strings.HasPrefix(message, "valid_until ") ||
+		strings.HasPrefix(message, "customer") ||
+		strings.HasPrefix(message, "address ") ||
+		strings.HasPrefix(message, "coupon ") ||
+		strings.HasPrefix(message, "insufficient stock") ||
+		strings.HasPrefix(message, "change ") ||
+		strings.HasPrefix(message, "credit ") ||
+		strings.HasPrefix(message, "payment"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func parseQuotationID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quotation id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// CreateQuotation handles POST /quotations
+func CreateQuotation(c *gin.Context) {
+	var input dtos.CreateQuotationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewQuotationService()
+	quotation, err := service.CreateQuotation(input, common.GetUserID(c), common.GetUserRole(c))
+	if err != nil {
+		handleQuotationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, quotation)
+}
+
+// GetQuotations handles GET /quotations?status=&customer_id=&number=
+func GetQuotations(c *gin.Context) {
+	var filter dtos.QuotationFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewQuotationService()
+	response, err := service.GetQuotations(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetQuotationStats handles GET /quotations/stats?start_date=&end_date=
+func GetQuotationStats(c *gin.Context) {
+	var filter dtos.QuotationStatsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewQuotationService()
+	stats, err := service.GetQuotationStats(filter)
+	if err != nil {
+		handleQuotationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetQuotationByID handles GET /quotations/:id
+func GetQuotationByID(c *gin.Context) {
+	id, ok := parseQuotationID(c)
+	if !ok {
+		return
+	}
+
+	service := services.NewQuotationService()
+	quotation, err := service.GetQuotationByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quotation)
+}
+
+// UpdateQuotation handles PUT /quotations/:id
+func UpdateQuotation(c *gin.Context) {
+	id, ok := parseQuotationID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.UpdateQuotationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewQuotationService()
+	quotation, err := service.UpdateQuotation(id, input, common.GetUserRole(c))
+	if err != nil {
+		handleQuotationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quotation)
+}
+
+// CancelQuotation handles POST /quotations/:id/cancel
+func CancelQuotation(c *gin.Context) {
+	id, ok := parseQuotationID(c)
+	if !ok {
+		return
+	}
+
+	service := services.NewQuotationService()
+	quotation, err := service.CancelQuotation(id)
+	if err != nil {
+		handleQuotationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quotation)
+}
+
+// ConvertQuotation handles POST /quotations/:id/convert
+func ConvertQuotation(c *gin.Context) {
+	id, ok := parseQuotationID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.ConvertQuotationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewQuotationService()
+	transaction, warnings, err := service.ConvertQuotation(id, input, common.GetUserID(c), common.GetUserRole(c), c.ClientIP())
+	if err != nil {
+		handleQuotationError(c, err)
+		return
+	}
+
+	response := gin.H{"transaction": transaction}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetQuotationPDF handles GET /quotations/:id/quotation.pdf
+func GetQuotationPDF(c *gin.Context) {
+	id, ok := parseQuotationID(c)
+	if !ok {
+		return
+	}
+
+	service := services.NewQuotationService()
+	quotation, err := service.GetQuotationByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := receipt.BuildQuotationPDF(quotation, config.Store())
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"quotation-%d.pdf\"", quotation.ID))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"kd-api/src/config"
+	"kd-api/src/services"
+	"kd-api/src/utils/receipt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTransactionReceiptEscPos handles GET /transactions/:id/receipt/escpos?width=58|80&drawer=true
+func GetTransactionReceiptEscPos(c *gin.Context) {
+	options := receipt.ReceiptOptions{PaperWidth: 58}
+	switch c.DefaultQuery("width", "58") {
+	case "58":
+	case "80":
+		options.PaperWidth = 80
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "width must be 58 or 80"})
+		return
+	}
+	options.KickDrawer = c.Query("drawer") == "true" || c.Query("drawer") == "1"
+
+	service := services.NewTransactionService()
+	transaction, err := service.GetTransactionByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := receipt.BuildReceipt(transaction, config.Store(), options)
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"receipt-%d.bin\"", transaction.ID))
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
+
+// GetTransactionDeliveryNote handles GET /transactions/:id/delivery-note.pdf
+func GetTransactionDeliveryNote(c *gin.Context) {
+	service := services.NewTransactionService()
+	transaction, err := service.GetTransactionByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := receipt.BuildDeliveryNote(transaction, config.Store())
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"delivery-note-%d.pdf\"", transaction.ID))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
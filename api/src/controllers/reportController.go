@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"net/http"
+
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStockAnalysis handles GET /reports/stock-analysis
+func GetStockAnalysis(c *gin.Context) {
+	var filter dtos.StockAnalysisFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewReportService()
+	report, err := service.GetStockAnalysis(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportStockAnalysis handles GET /reports/stock-analysis/export/csv
+func ExportStockAnalysis(c *gin.Context) {
+	var filter dtos.StockAnalysisFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewReportService()
+	report, err := service.GetStockAnalysis(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Disposition", "attachment; filename=\"stock-analysis.csv\"")
+	c.Header("Content-Type", "text/csv")
+
+	if err := service.ExportStockAnalysis(c.Writer, report); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// GetPaymentSummary handles GET /reports/payments
+func GetPaymentSummary(c *gin.Context) {
+	var filter dtos.PaymentSummaryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewReportService()
+	response, err := service.GetPaymentSummary(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDiscountReport handles GET /reports/discounts?start_date=&end_date=
+func GetDiscountReport(c *gin.Context) {
+	var filter dtos.DiscountReportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewReportService()
+	response, err := service.GetDiscountReport(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTaxReport handles GET /reports/tax?start_date=&end_date=
+func GetTaxReport(c *gin.Context) {
+	var filter dtos.TaxReportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewReportService()
+	response, err := service.GetTaxReport(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ExportEFaktur handles GET /reports/tax/efaktur/csv?start_date=&end_date=&customer_id=
+func ExportEFaktur(c *gin.Context) {
+	var filter dtos.EFakturFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Disposition", "attachment; filename=\"efaktur.csv\"")
+	c.Header("Content-Type", "text/csv")
+
+	service := services.NewReportService()
+	if err := service.ExportEFaktur(c.Writer, filter); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+}
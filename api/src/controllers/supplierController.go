@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSuppliers handles GET /suppliers
+func GetSuppliers(c *gin.Context) {
+	service := services.NewSupplierService()
+	suppliers, err := service.GetSuppliers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, suppliers)
+}
+
+// GetSupplierByID handles GET /suppliers/:id
+func GetSupplierByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid supplier id"})
+		return
+	}
+
+	service := services.NewSupplierService()
+	supplier, err := service.GetSupplierByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, supplier)
+}
+
+// CreateSupplier handles POST /suppliers
+func CreateSupplier(c *gin.Context) {
+	var input dtos.CreateSupplierInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewSupplierService()
+	supplier, err := service.CreateSupplier(input)
+	if err != nil {
+		if err.Error() == "supplier with this name already exists" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, supplier)
+}
+
+// UpdateSupplier handles PUT /suppliers/:id
+func UpdateSupplier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid supplier id"})
+		return
+	}
+
+	var input dtos.UpdateSupplierInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewSupplierService()
+	supplier, err := service.UpdateSupplier(uint(id), input)
+	if err != nil {
+		if err.Error() == "supplier not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "supplier with this name already exists" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, supplier)
+}
+
+// DeleteSupplier handles DELETE /suppliers/:id
+func DeleteSupplier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid supplier id"})
+		return
+	}
+
+	service := services.NewSupplierService()
+	if err := service.DeleteSupplier(uint(id)); err != nil {
+		if err.Error() == "supplier not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Supplier deleted successfully"})
+}
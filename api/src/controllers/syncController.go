@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+	"kd-api/src/utils/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSyncItems handles GET /sync/items, the item changes since a cursor for the mobile POS
+func GetSyncItems(c *gin.Context) {
+	var filter dtos.SyncItemsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewSyncService()
+	response, err := service.GetItemChanges(filter)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UploadSyncTransactions handles POST /sync/transactions, a batch of sales made offline.
+// Each sale succeeds or fails on its own, the results tell the app which ones to retry.
+func UploadSyncTransactions(c *gin.Context) {
+	var input dtos.SyncTransactionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewSyncService()
+	response, err := service.UploadTransactions(input, common.GetUserID(c), common.GetUserRole(c), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"kd-api/src/dtos"
 	"kd-api/src/services"
@@ -21,7 +22,7 @@ func CreateTransaction(c *gin.Context) {
 	}
 
 	service := services.NewTransactionService()
-	transaction, warnings, err := service.CreateTransaction(input, common.GetUserID(c), c.ClientIP())
+	transaction, warnings, err := service.CreateTransaction(input, common.GetUserID(c), common.GetUserRole(c), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -45,13 +46,26 @@ func UpdateTransactionStatus(c *gin.Context) {
 	}
 
 	service := services.NewTransactionService()
-	transaction, err := service.UpdateTransactionStatus(id, input, common.GetUserID(c), c.ClientIP())
+	transaction, err := service.UpdateTransactionStatus(id, input, common.GetUserID(c), common.GetUserRole(c), c.ClientIP())
 	if err != nil {
 		// Distinguish between not found and other errors if needed, but for now generic 500 or 400
 		if err.Error() == "transaction not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if strings.HasPrefix(err.Error(), "insufficient stock") ||
+			strings.HasPrefix(err.Error(), "coupon ") ||
+			err.Error() == "customer not found" ||
+			err.Error() == "address not found for this customer" ||
+			err.Error() == "customer_address_id requires customer_id" ||
+			err.Error() == "cannot change the customer of a transaction with an outstanding balance" ||
+			err.Error() == "cannot change the discount of a partially paid transaction" ||
+			err.Error() == "a partially paid transaction completes when its balance is paid" ||
+			err.Error() == "a voided transaction cannot be changed" ||
+			err.Error() == "transaction type can only be changed on a draft" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -125,12 +139,17 @@ func GetTransactions(c *gin.Context) {
     page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
     limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
     filterDate := c.Query("date")
+    cashierID, _ := strconv.ParseUint(c.Query("cashier_id"), 10, 32)
+    sessionID, _ := strconv.ParseUint(c.Query("cash_session_id"), 10, 32)
 
     service := services.NewTransactionService()
     response, err := service.GetTransactions(dtos.TransactionFilter{
-        Page:  page,
-        Limit: limit,
-        Date:  filterDate,
+        Page:      page,
+        Limit:     limit,
+        Date:      filterDate,
+        Number:    c.Query("number"),
+        CashierID: uint(cashierID),
+        SessionID: uint(sessionID),
     })
 
     if err != nil {
@@ -141,17 +160,21 @@ func GetTransactions(c *gin.Context) {
     c.JSON(http.StatusOK, response)
 }
 
-// Get only completed + refunded transactions (history)
+// Get only completed + (partially) refunded transactions (history)
 func GetTransactionHistory(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	filterDate := c.Query("date")
+	cashierID, _ := strconv.ParseUint(c.Query("cashier_id"), 10, 32)
+	sessionID, _ := strconv.ParseUint(c.Query("cash_session_id"), 10, 32)
 
 	service := services.NewTransactionService()
 	response, err := service.GetTransactionHistory(dtos.TransactionFilter{
-		Page:  page,
-		Limit: limit,
-		Date:  filterDate,
+		Page:      page,
+		Limit:     limit,
+		Date:      filterDate,
+		CashierID: uint(cashierID),
+		SessionID: uint(sessionID),
 	})
 
 	if err != nil {
@@ -162,18 +185,133 @@ func GetTransactionHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// Refund transaction (completed -> refunded, restore stock)
+// Refund some or all lines of a transaction (restock refunded quantities)
 func RefundTransaction(c *gin.Context) {
 	id := c.Param("id")
+
+	// An empty body refunds everything that has not been refunded yet
+	var input dtos.RefundInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	service := services.NewTransactionService()
-	
-	transaction, err := service.RefundTransaction(id, common.GetUserID(c), c.ClientIP())
+	transaction, err := service.RefundTransaction(id, input, common.GetUserID(c), c.ClientIP())
+	if err != nil {
+		if err.Error() == "transaction not found" || strings.HasPrefix(err.Error(), "transaction item ") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "only completed transactions can be refunded" ||
+			err.Error() == "nothing left to refund" ||
+			err.Error() == "credit refund exceeds the outstanding balance of this transaction" ||
+			strings.HasPrefix(err.Error(), "refund quantity") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// Mark a completed deliver transaction as delivered (deduct held stock)
+func MarkTransactionDelivered(c *gin.Context) {
+	id := c.Param("id")
+	service := services.NewTransactionService()
+
+	transaction, warnings, err := service.MarkDelivered(id, common.GetUserID(c), common.GetUserRole(c), c.ClientIP())
 	if err != nil {
 		if err.Error() == "transaction not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		if err.Error() == "only completed transactions can be refunded" {
+		if err.Error() == "only completed deliver transactions can be delivered" ||
+			err.Error() == "transaction already delivered" ||
+			err.Error() == "transaction has open delivery orders" ||
+			strings.HasPrefix(err.Error(), "insufficient stock") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"transaction": transaction}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Take a further payment on a partially paid transaction, paying off the balance completes it
+func AddTransactionPayment(c *gin.Context) {
+	id := c.Param("id")
+
+	var input dtos.AddPaymentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewTransactionService()
+	transaction, warnings, err := service.AddPayment(id, input, common.GetUserID(c), common.GetUserRole(c), c.ClientIP())
+	if err != nil {
+		if err.Error() == "transaction not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "only partially paid transactions take further payments" ||
+			strings.HasPrefix(err.Error(), "change ") ||
+			strings.HasPrefix(err.Error(), "credit ") ||
+			strings.HasPrefix(err.Error(), "customer ") ||
+			strings.HasPrefix(err.Error(), "insufficient stock") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"transaction": transaction}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Void a sale rung up by mistake, approved with a supervisor PIN while the drawer is still open
+func VoidTransaction(c *gin.Context) {
+	id := c.Param("id")
+
+	var input dtos.VoidTransactionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewTransactionService()
+	transaction, err := service.VoidTransaction(id, input, common.GetUserID(c), c.ClientIP())
+	if err != nil {
+		if err.Error() == "invalid supervisor PIN" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "transaction not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "only completed transactions can be voided" ||
+			err.Error() == "an exchange sale cannot be voided" ||
+			err.Error() == "transactions can only be voided while the cash session is open" ||
+			err.Error() == "transaction has customer payments, refund it instead" ||
+			err.Error() == "transaction has open delivery orders" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -183,3 +321,54 @@ func RefundTransaction(c *gin.Context) {
 
 	c.JSON(http.StatusOK, transaction)
 }
+
+// Swap returned lines of a sale for other goods, settling the price difference
+func CreateExchange(c *gin.Context) {
+	id := c.Param("id")
+
+	var input dtos.ExchangeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewExchangeService()
+	exchange, warnings, err := service.CreateExchange(id, input, common.GetUserID(c), common.GetUserRole(c), c.ClientIP())
+	if err != nil {
+		if err.Error() == "transaction not found" || strings.HasPrefix(err.Error(), "transaction item ") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "only completed transactions can be refunded" ||
+			err.Error() == "nothing left to refund" ||
+			strings.HasPrefix(err.Error(), "refund quantity") ||
+			strings.HasPrefix(err.Error(), "invalid quantity") ||
+			strings.HasPrefix(err.Error(), "item ") ||
+			strings.HasPrefix(err.Error(), "discount") ||
+			strings.HasPrefix(err.Error(), "insufficient stock") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"exchange": exchange}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// List the exchanges made against a sale
+func GetTransactionExchanges(c *gin.Context) {
+	service := services.NewExchangeService()
+	exchanges, err := service.GetExchanges(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, exchanges)
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetVehicles handles GET /vehicles
+func GetVehicles(c *gin.Context) {
+	service := services.NewVehicleService()
+	vehicles, err := service.GetVehicles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicles)
+}
+
+// GetVehicleByID handles GET /vehicles/:id
+func GetVehicleByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid vehicle id"})
+		return
+	}
+
+	service := services.NewVehicleService()
+	vehicle, err := service.GetVehicleByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicle)
+}
+
+// CreateVehicle handles POST /vehicles
+func CreateVehicle(c *gin.Context) {
+	var input dtos.CreateVehicleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewVehicleService()
+	vehicle, err := service.CreateVehicle(input)
+	if err != nil {
+		if err.Error() == "vehicle with this plate number already exists" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, vehicle)
+}
+
+// UpdateVehicle handles PUT /vehicles/:id
+func UpdateVehicle(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid vehicle id"})
+		return
+	}
+
+	var input dtos.UpdateVehicleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewVehicleService()
+	vehicle, err := service.UpdateVehicle(uint(id), input)
+	if err != nil {
+		if err.Error() == "vehicle not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "vehicle with this plate number already exists" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicle)
+}
+
+// DeleteVehicle handles DELETE /vehicles/:id
+func DeleteVehicle(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid vehicle id"})
+		return
+	}
+
+	service := services.NewVehicleService()
+	if err := service.DeleteVehicle(uint(id)); err != nil {
+		if err.Error() == "vehicle not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vehicle deleted successfully"})
+}
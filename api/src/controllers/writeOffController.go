@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"kd-api/src/dtos"
+	"kd-api/src/services"
+	"kd-api/src/utils/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWriteOff handles POST /inventory/write-offs
+func CreateWriteOff(c *gin.Context) {
+	var input dtos.CreateWriteOffInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewWriteOffService()
+	writeOff, err := service.CreateWriteOff(input, common.GetUserID(c))
+	if err != nil {
+		if err.Error() == "item not found" || err.Error() == "image not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "item stock is not managed" ||
+			err.Error() == "image_url must be an uploaded /images/ URL" ||
+			strings.HasPrefix(err.Error(), "write-off quantity exceeds stock") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, writeOff)
+}
+
+// GetWriteOffs handles GET /inventory/write-offs
+func GetWriteOffs(c *gin.Context) {
+	var filter dtos.WriteOffFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewWriteOffService()
+	response, err := service.GetWriteOffs(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetShrinkageReport handles GET /inventory/shrinkage
+func GetShrinkageReport(c *gin.Context) {
+	var filter dtos.ShrinkageFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewWriteOffService()
+	report, err := service.GetShrinkageReport(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,76 @@
+package dtos
+
+import (
+	"kd-api/src/models"
+	"time"
+)
+
+type CustomerAddressInput struct {
+	Label     *string `json:"label"`
+	Address   string  `json:"address" binding:"required"`
+	IsDefault bool    `json:"is_default"`
+}
+
+type CreateCustomerInput struct {
+	Name            string                 `json:"name" binding:"required"`
+	Phone           *string                `json:"phone"`
+	NPWP            *string                `json:"npwp"`
+	Group           *string                `json:"group" binding:"omitempty,oneof=retail contractor wholesale"`
+	Note            *string                `json:"note"`
+	CreditLimit     *float64               `json:"credit_limit" binding:"omitempty,gte=0"`
+	PaymentTermDays *int                   `json:"payment_term_days" binding:"omitempty,gte=0"`
+	Addresses       []CustomerAddressInput `json:"addresses" binding:"omitempty,dive"`
+}
+
+type UpdateCustomerInput struct {
+	Name            *string  `json:"name"`
+	Phone           *string  `json:"phone"`
+	NPWP            *string  `json:"npwp"`
+	Group           *string  `json:"group" binding:"omitempty,oneof=retail contractor wholesale"`
+	Note            *string  `json:"note"`
+	CreditLimit     *float64 `json:"credit_limit" binding:"omitempty,gte=0"`
+	PaymentTermDays *int     `json:"payment_term_days" binding:"omitempty,gte=0"`
+}
+
+type CustomerFilter struct {
+	Search string `form:"q"` // Matches name or phone
+	Group  string `form:"group"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
+
+type CustomerListResponse struct {
+	Data       []models.Customer `json:"data"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+	Total      int64             `json:"total"`
+	TotalPages int               `json:"total_pages"`
+}
+
+type CustomerHistoryFilter struct {
+	Status string `form:"status"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
+
+// CustomerStats sums a customer's paid sales, LifetimeValue is net of refunds
+type CustomerStats struct {
+	TransactionCount  int64      `json:"transaction_count"`
+	TotalSpent        float64    `json:"total_spent"`
+	RefundedAmount    float64    `json:"refunded_amount"`
+	LifetimeValue     float64    `json:"lifetime_value"`
+	AverageOrderValue float64    `json:"average_order_value"`
+	Outstanding       float64    `json:"outstanding"` // Unpaid credit sales
+	FirstPurchaseAt   *time.Time `json:"first_purchase_at"`
+	LastPurchaseAt    *time.Time `json:"last_purchase_at"`
+}
+
+type CustomerHistoryResponse struct {
+	Customer   models.Customer      `json:"customer"`
+	Stats      CustomerStats        `json:"stats"`
+	Data       []models.Transaction `json:"data"`
+	Page       int                  `json:"page"`
+	Limit      int                  `json:"limit"`
+	Total      int64                `json:"total"`
+	TotalPages int                  `json:"total_pages"`
+}
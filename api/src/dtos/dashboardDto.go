@@ -14,4 +14,10 @@ type DashboardStats struct {
 	TodayTransactions int64     `json:"today_transactions"`
 	LowStock          int64     `json:"low_stock"`
 	TopSellingItems   []TopItem `json:"top_selling_items"`
+
+	// Outstanding balances: sales paid with a down payment and sales on customer credit
+	OpenLayaways        int64   `json:"open_layaways"`
+	LayawayBalance      float64 `json:"layaway_balance"`
+	OverdueInstallments float64 `json:"overdue_installments"` // Scheduled installments past their due date
+	Receivables         float64 `json:"receivables"`
 }
@@ -0,0 +1,71 @@
+package dtos
+
+import "kd-api/src/models"
+
+type DeliveryOrderLineInput struct {
+	TransactionItemID uint `json:"transaction_item_id" binding:"required"`
+	Quantity          int  `json:"quantity" binding:"required,gt=0"`
+}
+
+// CreateDeliveryOrderInput schedules a trip for a deliver sale. Contact and address default
+// to the sale's customer and delivery address, Lines to everything not delivered or scheduled yet.
+type CreateDeliveryOrderInput struct {
+	TransactionID uint                     `json:"transaction_id" binding:"required"`
+	ScheduledDate string                   `json:"scheduled_date" binding:"required"` // YYYY-MM-DD
+	RecipientName *string                  `json:"recipient_name"`
+	Phone         *string                  `json:"phone"`
+	Address       *string                  `json:"address"`
+	VehicleID     *uint                    `json:"vehicle_id"`
+	DriverID      *uint                    `json:"driver_id"`
+	DeliveryFee   float64                  `json:"delivery_fee" binding:"gte=0"`
+	Note          *string                  `json:"note"`
+	Lines         []DeliveryOrderLineInput `json:"lines" binding:"omitempty,dive"`
+}
+
+type UpdateDeliveryOrderInput struct {
+	ScheduledDate *string  `json:"scheduled_date"`
+	RecipientName *string  `json:"recipient_name"`
+	Phone         *string  `json:"phone"`
+	Address       *string  `json:"address"`
+	VehicleID     *uint    `json:"vehicle_id"` // 0 takes the order off its vehicle
+	DriverID      *uint    `json:"driver_id"`  // 0 unassigns the driver
+	DeliveryFee   *float64 `json:"delivery_fee" binding:"omitempty,gte=0"`
+	Note          *string  `json:"note"`
+}
+
+type DeliveredLineInput struct {
+	LineID   uint `json:"line_id" binding:"required"`
+	Quantity int  `json:"quantity" binding:"gte=0"`
+}
+
+// DeliveryStatusInput moves an order along. Delivered needs the recipient's name and the
+// proof photo uploaded through /upload/image, failed needs a reason.
+type DeliveryStatusInput struct {
+	Status        string               `json:"status" binding:"required,oneof=loaded on_the_way delivered failed"`
+	ReceivedBy    *string              `json:"received_by"`
+	ProofImageURL *string              `json:"proof_image_url"`
+	FailureReason *string              `json:"failure_reason"`
+	Lines         []DeliveredLineInput `json:"lines" binding:"omitempty,dive"` // Only when the customer took less than was loaded
+}
+
+type DeliveryOrderFilter struct {
+	Status        string `form:"status"`
+	Date          string `form:"date"` // Scheduled date, YYYY-MM-DD
+	DriverID      *uint  `form:"driver_id"`
+	TransactionID *uint  `form:"transaction_id"`
+	Page          int    `form:"page"`
+	Limit         int    `form:"limit"`
+}
+
+type DeliveryOrderListResponse struct {
+	Data       []models.DeliveryOrder `json:"data"`
+	Page       int                    `json:"page"`
+	Limit      int                    `json:"limit"`
+	Total      int64                  `json:"total"`
+	TotalPages int                    `json:"total_pages"`
+}
+
+type DriverDayFilter struct {
+	Date     string `form:"date"`      // Defaults to today
+	DriverID *uint  `form:"driver_id"` // Staff looking at a driver's list, drivers always get their own
+}
@@ -2,6 +2,7 @@ package dtos
 
 import (
 	"kd-api/src/models"
+	"time"
 )
 
 type InventoryFilter struct {
@@ -20,3 +21,122 @@ type InventoryListResponse struct {
 	Total      int64                 `json:"total"`
 	TotalPages int                   `json:"total_pages"`
 }
+
+type ReservationFilter struct {
+	ItemID        uint   `form:"item_id"`
+	TransactionID uint   `form:"transaction_id"`
+	Type          string `form:"type"`   // draft, delivery, layaway
+	Status        string `form:"status"` // defaults to active
+	Page          int    `form:"page"`
+	Limit         int    `form:"limit"`
+}
+
+type ReservationListResponse struct {
+	Data       []models.StockReservation `json:"data"`
+	Page       int                       `json:"page"`
+	Limit      int                       `json:"limit"`
+	Total      int64                     `json:"total"`
+	TotalPages int                       `json:"total_pages"`
+}
+
+type InventoryValuationLine struct {
+	ItemID   uint    `json:"item_id"`
+	Name     string  `json:"name"`
+	Category string  `json:"category"`
+	Quantity int     `json:"quantity"`
+	UnitCost float64 `json:"unit_cost"`
+	Value    float64 `json:"value"`
+}
+
+type CategoryValuation struct {
+	Category  string  `json:"category"`
+	ItemCount int     `json:"item_count"`
+	Quantity  int     `json:"quantity"`
+	Value     float64 `json:"value"`
+}
+
+type InventoryValuationResponse struct {
+	AsOf          time.Time                `json:"as_of"`
+	Items         []InventoryValuationLine `json:"items"`
+	Categories    []CategoryValuation      `json:"categories"`
+	TotalQuantity int                      `json:"total_quantity"`
+	TotalValue    float64                  `json:"total_value"`
+}
+
+type LedgerBreak struct {
+	LogID         uint      `json:"log_id"`
+	Type          string    `json:"type"`
+	ReferenceID   string    `json:"reference_id"`
+	PreviousStock int       `json:"previous_stock"`
+	Change        int       `json:"change"`
+	ExpectedStock int       `json:"expected_stock"`
+	FinalStock    int       `json:"final_stock"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type LedgerItemReport struct {
+	ItemID      uint          `json:"item_id"`
+	Name        string        `json:"name"`
+	Stock       int           `json:"stock"`        // items.stock
+	LedgerStock int           `json:"ledger_stock"` // FinalStock of the last log
+	Difference  int           `json:"difference"`   // stock - ledger_stock
+	Breaks      []LedgerBreak `json:"breaks"`
+}
+
+type LedgerIntegrityReport struct {
+	CheckedAt       time.Time          `json:"checked_at"`
+	ItemsChecked    int                `json:"items_checked"`
+	ItemsWithIssues int                `json:"items_with_issues"`
+	Items           []LedgerItemReport `json:"items"`
+}
+
+type LedgerRepairInput struct {
+	ItemIDs []uint `json:"item_ids"` // empty means every mismatched item
+	Confirm bool   `json:"confirm"`  // false only previews the corrections
+}
+
+type LedgerAdjustment struct {
+	ItemID      uint   `json:"item_id"`
+	Name        string `json:"name"`
+	LedgerStock int    `json:"ledger_stock"`
+	Stock       int    `json:"stock"`
+	Change      int    `json:"change"`
+}
+
+type LedgerRepairResponse struct {
+	Confirmed   bool               `json:"confirmed"`
+	Adjustments []LedgerAdjustment `json:"adjustments"`
+}
+
+type BackorderFilter struct {
+	ItemID        uint   `form:"item_id"`
+	TransactionID uint   `form:"transaction_id"`
+	Status        string `form:"status"` // open, fulfilled, cancelled
+	Page          int    `form:"page"`
+	Limit         int    `form:"limit"`
+}
+
+type BackorderListResponse struct {
+	Data       []models.Backorder `json:"data"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+	Total      int64              `json:"total"`
+	TotalPages int                `json:"total_pages"`
+}
+
+type StockShortageFilter struct {
+	ItemID    uint   `form:"item_id"`
+	Policy    string `form:"policy"` // reject, allow, backorder
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+	Page      int    `form:"page"`
+	Limit     int    `form:"limit"`
+}
+
+type StockShortageListResponse struct {
+	Data       []models.StockShortage `json:"data"`
+	Page       int                    `json:"page"`
+	Limit      int                    `json:"limit"`
+	Total      int64                  `json:"total"`
+	TotalPages int                    `json:"total_pages"`
+}
@@ -16,9 +16,16 @@ type CreateItemInput struct {
 	Description    *string `json:"description"`
 	Stock          int     `json:"stock"`
 	IsStockManaged *bool   `json:"is_stock_managed"`
+	NegativeStockPolicy *string `json:"negative_stock_policy" binding:"omitempty,oneof=reject allow backorder"`
 	BuyPrice       float64 `json:"buy_price"`
 	Price       float64 `json:"price" binding:"required"`
 	ImageURL    *string `json:"image_url"`
+	Category    *string `json:"category"`
+	SupplierID  *uint   `json:"supplier_id"`
+	IsTaxable   *bool    `json:"is_taxable"`
+	TaxRate     *float64 `json:"tax_rate" binding:"omitempty,gte=0,lte=100"` // Empty uses the standard PPN rate
+	WeightKg    *float64 `json:"weight_kg" binding:"omitempty,gte=0"`
+	VolumeM3    *float64 `json:"volume_m3" binding:"omitempty,gte=0"`
 }
 
 type UpdateItemInput struct {
@@ -26,9 +33,16 @@ type UpdateItemInput struct {
 	Description    *string `json:"description"`
 	Stock          int     `json:"stock"`
 	IsStockManaged *bool   `json:"is_stock_managed"`
+	NegativeStockPolicy *string `json:"negative_stock_policy" binding:"omitempty,oneof=reject allow backorder"`
 	BuyPrice       float64 `json:"buy_price"`
 	Price       float64 `json:"price"`
 	ImageURL    *string `json:"image_url"`
+	Category    *string `json:"category"`
+	SupplierID  *uint   `json:"supplier_id"`
+	IsTaxable   *bool    `json:"is_taxable"`
+	TaxRate     *float64 `json:"tax_rate" binding:"omitempty,gte=0,lte=100"` // Empty uses the standard PPN rate
+	WeightKg    *float64 `json:"weight_kg" binding:"omitempty,gte=0"`
+	VolumeM3    *float64 `json:"volume_m3" binding:"omitempty,gte=0"`
 }
 
 type ItemFilter struct {
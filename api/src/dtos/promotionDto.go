@@ -0,0 +1,76 @@
+package dtos
+
+import (
+	"kd-api/src/models"
+	"time"
+)
+
+type CreatePromotionInput struct {
+	Name         string     `json:"name" binding:"required"`
+	Type         string     `json:"type" binding:"required,oneof=item_discount buy_x_get_y spend_threshold"`
+	DiscountType *string    `json:"discount_type" binding:"omitempty,oneof=percent fixed"`
+	Value        float64    `json:"value" binding:"gte=0"`
+	ItemID       *uint      `json:"item_id"`
+	Category     *string    `json:"category"`
+	BuyQuantity  int        `json:"buy_quantity" binding:"gte=0"`
+	FreeQuantity int        `json:"free_quantity" binding:"gte=0"`
+	MinSpend     float64    `json:"min_spend" binding:"gte=0"`
+	MaxDiscount  *float64   `json:"max_discount" binding:"omitempty,gt=0"`
+	CouponCode   *string    `json:"coupon_code"`
+	UsageLimit   *int       `json:"usage_limit" binding:"omitempty,gt=0"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	IsActive     *bool      `json:"is_active"`
+}
+
+// UpdatePromotionInput changes only the fields sent. The rule type cannot change.
+type UpdatePromotionInput struct {
+	Name         *string    `json:"name"`
+	DiscountType *string    `json:"discount_type" binding:"omitempty,oneof=percent fixed"`
+	Value        *float64   `json:"value" binding:"omitempty,gte=0"`
+	ItemID       *uint      `json:"item_id"`  // 0 clears it
+	Category     *string    `json:"category"` // "" clears it
+	BuyQuantity  *int       `json:"buy_quantity" binding:"omitempty,gte=0"`
+	FreeQuantity *int       `json:"free_quantity" binding:"omitempty,gte=0"`
+	MinSpend     *float64   `json:"min_spend" binding:"omitempty,gte=0"`
+	MaxDiscount  *float64   `json:"max_discount" binding:"omitempty,gte=0"` // 0 removes the cap
+	CouponCode   *string    `json:"coupon_code"`                            // "" makes it an automatic promotion
+	UsageLimit   *int       `json:"usage_limit" binding:"omitempty,gte=0"`  // 0 removes the limit
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	IsActive     *bool      `json:"is_active"`
+}
+
+type PromotionFilter struct {
+	Search     string `form:"q"`
+	Type       string `form:"type"`
+	ActiveOnly bool   `form:"active_only"` // Active and inside the validity window right now
+	Page       int    `form:"page"`
+	Limit      int    `form:"limit"`
+}
+
+type PromotionListResponse struct {
+	Data       []models.Promotion `json:"data"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+	Total      int64              `json:"total"`
+	TotalPages int                `json:"total_pages"`
+}
+
+type PromotionCostFilter struct {
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+}
+
+type PromotionCostLine struct {
+	PromotionID  uint    `json:"promotion_id"`
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	Transactions int64   `json:"transactions"`
+	Cost         float64 `json:"cost"`
+}
+
+type PromotionCostResponse struct {
+	Promotions []PromotionCostLine `json:"promotions"`
+	TotalCost  float64             `json:"total_cost"`
+}
@@ -0,0 +1,82 @@
+package dtos
+
+import (
+	"kd-api/src/models"
+)
+
+type PurchaseOrderLineInput struct {
+	ItemID   uint     `json:"item_id" binding:"required"`
+	Quantity int      `json:"quantity" binding:"required,gt=0"`
+	UnitCost *float64 `json:"unit_cost"` // Defaults to the item's buy price
+}
+
+type CreatePurchaseOrderInput struct {
+	SupplierID uint                     `json:"supplier_id" binding:"required"`
+	Note       *string                  `json:"note"`
+	Lines      []PurchaseOrderLineInput `json:"lines" binding:"required,min=1,dive"`
+}
+
+// CreatePurchaseOrderFromSuggestionsInput turns a supplier's reorder suggestions
+// into a draft PO. Lines, when given, replace the computed quantities.
+type CreatePurchaseOrderFromSuggestionsInput struct {
+	SupplierID uint                     `json:"supplier_id" binding:"required"`
+	WindowDays int                      `json:"window_days"`
+	CoverDays  int                      `json:"cover_days"`
+	Note       *string                  `json:"note"`
+	Lines      []PurchaseOrderLineInput `json:"lines" binding:"omitempty,dive"`
+}
+
+type UpdatePurchaseOrderStatusInput struct {
+	Status string `json:"status" binding:"required,oneof=ordered received cancelled"`
+}
+
+type PurchaseOrderFilter struct {
+	SupplierID uint   `form:"supplier_id"`
+	Status     string `form:"status"`
+	Page       int    `form:"page"`
+	Limit      int    `form:"limit"`
+}
+
+type PurchaseOrderListResponse struct {
+	Data       []models.PurchaseOrder `json:"data"`
+	Page       int                    `json:"page"`
+	Limit      int                    `json:"limit"`
+	Total      int64                  `json:"total"`
+	TotalPages int                    `json:"total_pages"`
+}
+
+type ReorderFilter struct {
+	WindowDays int  `form:"window_days"` // Rolling sales window, default 30
+	CoverDays  int  `form:"cover_days"`  // Days of stock to hold beyond the lead time, default 14
+	SupplierID uint `form:"supplier_id"`
+}
+
+type ReorderSuggestion struct {
+	ItemID             uint     `json:"item_id"`
+	Name               string   `json:"name"`
+	Stock              int      `json:"stock"`
+	Reserved           int      `json:"reserved"`
+	Available          int      `json:"available"`
+	OnOrder            int      `json:"on_order"`
+	SoldInWindow       int      `json:"sold_in_window"`
+	AverageDailyDemand float64  `json:"average_daily_demand"`
+	DaysOfCover        *float64 `json:"days_of_cover"` // Null when the item did not sell in the window
+	ReorderPoint       float64  `json:"reorder_point"`
+	SuggestedQuantity  int      `json:"suggested_quantity"`
+	UnitCost           float64  `json:"unit_cost"`
+	EstimatedCost      float64  `json:"estimated_cost"`
+}
+
+type SupplierReorderSuggestion struct {
+	SupplierID    *uint               `json:"supplier_id"`
+	SupplierName  string              `json:"supplier_name"`
+	LeadTimeDays  int                 `json:"lead_time_days"`
+	Items         []ReorderSuggestion `json:"items"`
+	EstimatedCost float64             `json:"estimated_cost"`
+}
+
+type ReorderSuggestionResponse struct {
+	WindowDays int                         `json:"window_days"`
+	CoverDays  int                         `json:"cover_days"`
+	Suppliers  []SupplierReorderSuggestion `json:"suppliers"`
+}
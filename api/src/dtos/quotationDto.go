@@ -0,0 +1,86 @@
+package dtos
+
+import "kd-api/src/models"
+
+// CreateQuotationInput prices the lines like a sale would, line discounts need a reason and
+// stay within the role's limit. ValidUntil defaults to QUOTATION_VALID_DAYS from today.
+type CreateQuotationInput struct {
+	CustomerID        uint                   `json:"customer_id" binding:"required"`
+	CustomerAddressID *uint                  `json:"customer_address_id"`
+	TransactionType   *string                `json:"transaction_type" binding:"omitempty,oneof=onsite deliver"`
+	ValidUntil        *string                `json:"valid_until"` // YYYY-MM-DD
+	Discount          *float64               `json:"discount" binding:"omitempty,gte=0"`
+	Note              *string                `json:"note"`
+	Items             []TransactionItemInput `json:"items" binding:"required,min=1,dive"`
+}
+
+// UpdateQuotationInput changes an open quotation, Items replaces all lines and reprices them
+type UpdateQuotationInput struct {
+	CustomerAddressID *uint                  `json:"customer_address_id"` // 0 removes the address
+	TransactionType   *string                `json:"transaction_type" binding:"omitempty,oneof=onsite deliver"`
+	ValidUntil        *string                `json:"valid_until"`
+	Discount          *float64               `json:"discount" binding:"omitempty,gte=0"`
+	Note              *string                `json:"note"`
+	Items             []TransactionItemInput `json:"items" binding:"omitempty,min=1,dive"`
+}
+
+// ConvertQuotationInput turns a quotation into a draft, a partially paid or a completed sale.
+// Payment fields work as on POST /transactions and are not used for a draft.
+type ConvertQuotationInput struct {
+	Status            string                 `json:"status" binding:"required,oneof=draft partially_paid completed"`
+	PaymentAmount     *float64               `json:"paymentAmount,omitempty"`
+	PaymentType       *string                `json:"paymentType,omitempty"`
+	Payments          []PaymentInput         `json:"payments,omitempty" binding:"omitempty,dive"`
+	CustomerAddressID *uint                  `json:"customer_address_id,omitempty"` // Defaults to the quotation's
+	CouponCodes       []string               `json:"coupon_codes,omitempty"`
+	PaymentSchedule   []PaymentScheduleInput `json:"payment_schedule,omitempty" binding:"omitempty,dive"`
+	Note              *string                `json:"note,omitempty"`
+}
+
+// QuotationFilter lists quotations, status "expired" means open and past its validity
+type QuotationFilter struct {
+	Page       int    `form:"page"`
+	Limit      int    `form:"limit"`
+	Status     string `form:"status" binding:"omitempty,oneof=open expired converted cancelled"`
+	CustomerID *uint  `form:"customer_id"`
+	Number     string `form:"number"`
+}
+
+type QuotationListResponse struct {
+	Data       []models.Quotation `json:"data"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+	Total      int64              `json:"total"`
+	TotalPages int                `json:"totalPages"`
+}
+
+// QuotationStatsFilter takes quotations created between the dates, both YYYY-MM-DD and inclusive
+type QuotationStatsFilter struct {
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+}
+
+type QuotationCreatorStats struct {
+	CreatedByID    *uint   `json:"created_by_id"`
+	Username       string  `json:"username"`
+	Quotations     int64   `json:"quotations"`
+	Converted      int64   `json:"converted"`
+	ConversionRate float64 `json:"conversion_rate"`
+}
+
+// QuotationStatsResponse shows how many quotes turn into sales. ConversionRate is converted
+// over all quotes that are decided, i.e. converted, cancelled or expired.
+type QuotationStatsResponse struct {
+	StartDate        string                  `json:"start_date"`
+	EndDate          string                  `json:"end_date"`
+	Quotations       int64                   `json:"quotations"`
+	Open             int64                   `json:"open"`
+	Expired          int64                   `json:"expired"`
+	Converted        int64                   `json:"converted"`
+	Cancelled        int64                   `json:"cancelled"`
+	ConversionRate   float64                 `json:"conversion_rate"`
+	QuotedValue      float64                 `json:"quoted_value"`
+	ConvertedValue   float64                 `json:"converted_value"`
+	AvgDaysToConvert float64                 `json:"avg_days_to_convert"`
+	ByCreator        []QuotationCreatorStats `json:"by_creator"`
+}
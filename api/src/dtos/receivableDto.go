@@ -0,0 +1,93 @@
+package dtos
+
+import (
+	"kd-api/src/models"
+	"time"
+)
+
+type PaymentAllocationInput struct {
+	TransactionID uint    `json:"transaction_id" binding:"required"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// CustomerPaymentInput settles the given invoices, or the oldest due first when Allocations is empty
+type CustomerPaymentInput struct {
+	Amount      float64                  `json:"amount" binding:"required,gt=0"`
+	Method      string                   `json:"method" binding:"required,oneof=cash qris debit"`
+	Reference   *string                  `json:"reference,omitempty"`
+	Note        *string                  `json:"note,omitempty"`
+	Allocations []PaymentAllocationInput `json:"allocations,omitempty" binding:"omitempty,dive"`
+}
+
+type CustomerPaymentFilter struct {
+	Page  int `form:"page"`
+	Limit int `form:"limit"`
+}
+
+type CustomerPaymentListResponse struct {
+	Data       []models.CustomerPayment `json:"data"`
+	Page       int                      `json:"page"`
+	Limit      int                      `json:"limit"`
+	Total      int64                    `json:"total"`
+	TotalPages int                      `json:"total_pages"`
+}
+
+type StatementFilter struct {
+	StartDate string `form:"start_date"` // YYYY-MM-DD, defaults to the first of this month
+	EndDate   string `form:"end_date"`   // YYYY-MM-DD, defaults to today
+}
+
+// StatementLine is one movement on a customer's account. Debit raises what they owe.
+type StatementLine struct {
+	Date        time.Time `json:"date"`
+	Type        string    `json:"type"` // invoice, payment, refund
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+	Debit       float64   `json:"debit"`
+	Credit      float64   `json:"credit"`
+	Balance     float64   `json:"balance"`
+}
+
+type OpenInvoice struct {
+	TransactionID uint       `json:"transaction_id"`
+	Reference     string     `json:"reference"`
+	Date          time.Time  `json:"date"`
+	DueDate       *time.Time `json:"due_date"`
+	Total         float64    `json:"total"`
+	AmountDue     float64    `json:"amount_due"`
+	DaysOverdue   int        `json:"days_overdue"`
+}
+
+type CustomerStatementResponse struct {
+	Customer       models.Customer `json:"customer"`
+	StartDate      string          `json:"start_date"`
+	EndDate        string          `json:"end_date"`
+	OpeningBalance float64         `json:"opening_balance"`
+	Lines          []StatementLine `json:"lines"`
+	ClosingBalance float64         `json:"closing_balance"`
+	OpenInvoices   []OpenInvoice   `json:"open_invoices"`
+}
+
+// AgingBuckets splits outstanding credit by how many days past the due date it is
+type AgingBuckets struct {
+	Current    float64 `json:"current"` // Not yet due
+	Days1To30  float64 `json:"days_1_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Over90     float64 `json:"over_90"`
+	Total      float64 `json:"total"`
+}
+
+type CustomerAging struct {
+	CustomerID  uint    `json:"customer_id"`
+	Name        string  `json:"name"`
+	Phone       *string `json:"phone,omitempty"`
+	CreditLimit float64 `json:"credit_limit"`
+	AgingBuckets
+}
+
+type AgingReportResponse struct {
+	AsOf      time.Time       `json:"as_of"`
+	Customers []CustomerAging `json:"customers"`
+	Totals    AgingBuckets    `json:"totals"`
+}
@@ -0,0 +1,145 @@
+package dtos
+
+import "time"
+
+type StockAnalysisFilter struct {
+	Days     int    `form:"days"`      // Sales window for ABC and sell-through, default 90
+	DeadDays int    `form:"dead_days"` // No sale for this many days flags dead stock, default 90
+	Metric   string `form:"metric" binding:"omitempty,oneof=revenue profit"`
+	Class    string `form:"class" binding:"omitempty,oneof=A B C"`
+	DeadOnly bool   `form:"dead_only"`
+}
+
+type StockAnalysisLine struct {
+	ItemID            uint       `json:"item_id"`
+	Name              string     `json:"name"`
+	Category          string     `json:"category"`
+	Stock             int        `json:"stock"`
+	UnitCost          float64    `json:"unit_cost"`
+	StockValue        float64    `json:"stock_value"` // Capital tied up at cost
+	QuantitySold      int        `json:"quantity_sold"`
+	Revenue           float64    `json:"revenue"`
+	Profit            float64    `json:"profit"`
+	Contribution      float64    `json:"contribution"`     // Percent of the metric total
+	CumulativeShare   float64    `json:"cumulative_share"` // Percent, items ranked by the metric
+	Class             string     `json:"class"`
+	LastSoldAt        *time.Time `json:"last_sold_at"`
+	DaysSinceLastSale *int       `json:"days_since_last_sale"`
+	SellThroughRate   float64    `json:"sell_through_rate"` // Percent of opening + received units sold in the window
+	IsDeadStock       bool       `json:"is_dead_stock"`
+}
+
+type StockClassSummary struct {
+	Class      string  `json:"class"`
+	ItemCount  int     `json:"item_count"`
+	Revenue    float64 `json:"revenue"`
+	Profit     float64 `json:"profit"`
+	StockValue float64 `json:"stock_value"`
+}
+
+type StockAnalysisResponse struct {
+	Metric         string              `json:"metric"`
+	Days           int                 `json:"days"`
+	DeadDays       int                 `json:"dead_days"`
+	From           time.Time           `json:"from"`
+	To             time.Time           `json:"to"`
+	Items          []StockAnalysisLine `json:"items"`
+	Classes        []StockClassSummary `json:"classes"`
+	TotalRevenue   float64             `json:"total_revenue"`
+	TotalProfit    float64             `json:"total_profit"`
+	DeadStockCount int                 `json:"dead_stock_count"`
+	DeadStockValue float64             `json:"dead_stock_value"`
+}
+
+type PaymentSummaryFilter struct {
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+}
+
+type PaymentMethodTotal struct {
+	Method string  `json:"method"`
+	Count  int64   `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+type PaymentSummaryResponse struct {
+	Methods        []PaymentMethodTotal `json:"methods"`
+	Collections    []PaymentMethodTotal `json:"collections"` // Customer payments on credit sales
+	TotalChange    float64              `json:"total_change"`
+	NetCash        float64              `json:"net_cash"`        // Cash tendered minus change given, plus cash collections
+	ExchangeCredit float64              `json:"exchange_credit"` // Returned goods put towards a sale, not money
+	Total          float64              `json:"total"`           // All tenders but exchange credit minus change, equals sales collected
+}
+
+type DiscountReportFilter struct {
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+}
+
+// DiscountTotals splits everything given off list price on sales
+type DiscountTotals struct {
+	LineDiscounts      float64 `json:"line_discounts"`      // Cashier discounts on lines
+	BelowListPrice     float64 `json:"below_list_price"`    // Custom prices under the list price
+	PromotionDiscounts float64 `json:"promotion_discounts"` // Line and sale promotions
+	SaleDiscounts      float64 `json:"sale_discounts"`      // Whole-sale discount typed in at the till
+	Total              float64 `json:"total"`
+}
+
+type DiscountByReason struct {
+	Reason string  `json:"reason"`
+	Lines  int64   `json:"lines"`
+	Amount float64 `json:"amount"`
+}
+
+type DiscountByItem struct {
+	ItemID         uint    `json:"item_id"`
+	Name           string  `json:"name"`
+	Lines          int64   `json:"lines"`
+	LineDiscounts  float64 `json:"line_discounts"`
+	BelowListPrice float64 `json:"below_list_price"`
+}
+
+type DiscountReportResponse struct {
+	Totals   DiscountTotals     `json:"totals"`
+	ByReason []DiscountByReason `json:"by_reason"`
+	ByItem   []DiscountByItem   `json:"by_item"`
+}
+
+type TaxReportFilter struct {
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+}
+
+// TaxTotals is the output tax of the period, net of refunded quantities
+type TaxTotals struct {
+	TaxBase     float64 `json:"tax_base"`     // DPP of taxable sales
+	TaxAmount   float64 `json:"tax_amount"`   // PPN collected
+	ExemptSales float64 `json:"exempt_sales"` // Sales of items not subject to PPN
+}
+
+type TaxByRate struct {
+	Rate      float64 `json:"rate"`
+	Lines     int64   `json:"lines"`
+	TaxBase   float64 `json:"tax_base"`
+	TaxAmount float64 `json:"tax_amount"`
+}
+
+// TaxByPeriod is one tax month (masa pajak), e.g. "2026-10"
+type TaxByPeriod struct {
+	Period      string  `json:"period"`
+	TaxBase     float64 `json:"tax_base"`
+	TaxAmount   float64 `json:"tax_amount"`
+	ExemptSales float64 `json:"exempt_sales"`
+}
+
+type TaxReportResponse struct {
+	Totals   TaxTotals     `json:"totals"`
+	ByRate   []TaxByRate   `json:"by_rate"`
+	ByPeriod []TaxByPeriod `json:"by_period"`
+}
+
+type EFakturFilter struct {
+	StartDate  string `form:"start_date"`
+	EndDate    string `form:"end_date"`
+	CustomerID *uint  `form:"customer_id"`
+}
@@ -0,0 +1,17 @@
+package dtos
+
+type CreateSupplierInput struct {
+	Name         string  `json:"name" binding:"required"`
+	Phone        *string `json:"phone"`
+	Address      *string `json:"address"`
+	LeadTimeDays *int    `json:"lead_time_days" binding:"omitempty,gte=0"`
+	Note         *string `json:"note"`
+}
+
+type UpdateSupplierInput struct {
+	Name         *string `json:"name"`
+	Phone        *string `json:"phone"`
+	Address      *string `json:"address"`
+	LeadTimeDays *int    `json:"lead_time_days" binding:"omitempty,gte=0"`
+	Note         *string `json:"note"`
+}
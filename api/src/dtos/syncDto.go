@@ -0,0 +1,69 @@
+package dtos
+
+import (
+	"time"
+
+	"kd-api/src/models"
+)
+
+// SyncItemsFilter reads the item feed from a cursor, an empty cursor starts from the beginning
+type SyncItemsFilter struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+// SyncItemsResponse is one page of item changes. Deleted holds the IDs of items removed
+// since the cursor. Keep calling with NextCursor while HasMore is true.
+type SyncItemsResponse struct {
+	Items      []models.Item `json:"items"`
+	Deleted    []uint        `json:"deleted"`
+	NextCursor string        `json:"next_cursor"`
+	HasMore    bool          `json:"has_more"`
+	ServerTime time.Time     `json:"server_time"`
+}
+
+// OfflineLineInput is a line as the app sold it, Price is the unit price it charged
+type OfflineLineInput struct {
+	ItemID         uint     `json:"item_id" binding:"required"`
+	Quantity       int      `json:"quantity" binding:"required"`
+	Price          float64  `json:"price" binding:"gte=0"`
+	DiscountAmount *float64 `json:"discount_amount,omitempty" binding:"omitempty,gte=0"`
+	DiscountReason *string  `json:"discount_reason,omitempty"`
+}
+
+// OfflineTransactionInput is a completed sale made while the app had no connection.
+// ClientUUID is generated by the app and makes re-uploading the same sale harmless.
+type OfflineTransactionInput struct {
+	ClientUUID        string             `json:"client_uuid" binding:"required,uuid"`
+	SoldAt            *time.Time         `json:"sold_at"` // When the sale was made, defaults to now
+	TransactionType   *string            `json:"transaction_type,omitempty"`
+	Discount          *float64           `json:"discount,omitempty"`
+	CustomerID        *uint              `json:"customer_id,omitempty"`
+	CustomerAddressID *uint              `json:"customer_address_id,omitempty"`
+	Note              *string            `json:"note,omitempty"`
+	Payments          []PaymentInput     `json:"payments" binding:"required,min=1,dive"`
+	Items             []OfflineLineInput `json:"items" binding:"required,min=1,dive"`
+}
+
+type SyncTransactionsInput struct {
+	Transactions []OfflineTransactionInput `json:"transactions" binding:"required,min=1,max=100,dive"`
+}
+
+// SyncTransactionResult is the outcome of one uploaded sale: "created", "duplicate" when it
+// was uploaded before (the existing sale is returned) or "failed" with the reason.
+type SyncTransactionResult struct {
+	ClientUUID    string   `json:"client_uuid"`
+	Status        string   `json:"status"`
+	TransactionID *uint    `json:"transaction_id,omitempty"`
+	Number        *string  `json:"number,omitempty"`
+	Total         *float64 `json:"total,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+	Error         string   `json:"error,omitempty"`
+}
+
+type SyncTransactionsResponse struct {
+	Results    []SyncTransactionResult `json:"results"`
+	Created    int                     `json:"created"`
+	Duplicates int                     `json:"duplicates"`
+	Failed     int                     `json:"failed"`
+}
@@ -1,29 +1,93 @@
 package dtos
 
-import "kd-api/src/models"
+import (
+	"time"
 
+	"kd-api/src/models"
+)
+
+// TransactionItemInput is one sale line. A line discount is given either as an amount
+// off the whole line or as a percent of it, and needs a reason.
 type TransactionItemInput struct {
-	ItemID      uint     `json:"item_id"`
-	Quantity    int      `json:"quantity"`
-	CustomPrice *float64 `json:"customPrice,omitempty"`
+	ItemID          uint     `json:"item_id"`
+	Quantity        int      `json:"quantity"`
+	CustomPrice     *float64 `json:"customPrice,omitempty"`
+	DiscountAmount  *float64 `json:"discount_amount,omitempty" binding:"omitempty,gte=0"`
+	DiscountPercent *float64 `json:"discount_percent,omitempty" binding:"omitempty,gte=0,lte=100"`
+	DiscountReason  *string  `json:"discount_reason,omitempty"`
+	ListPrice       *float64 `json:"-"` // Set by the server for offline sales: the price on the shelf when sold, discounts are measured against it
+}
+
+// PaymentInput is one tender of a split payment, only cash tenders can give change.
+// A "credit" tender puts that part on the customer's account.
+type PaymentInput struct {
+	Method    string  `json:"method" binding:"required,oneof=cash qris debit credit"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
+	Reference *string `json:"reference,omitempty"`
+}
+
+// PaymentScheduleInput plans one installment of the balance left after a down payment
+type PaymentScheduleInput struct {
+	DueDate string  `json:"due_date" binding:"required"` // YYYY-MM-DD
+	Amount  float64 `json:"amount" binding:"required,gt=0"`
+	Note    *string `json:"note,omitempty"`
 }
 
+// CreateTransactionInput with status "partially_paid" takes the payments as a down payment,
+// the rest is the balance due and may be planned with PaymentSchedule.
 type CreateTransactionInput struct {
-	ID              *uint                  `json:"id,omitempty"`
-	Status          string                 `json:"status"`
-	PaymentAmount   *float64               `json:"paymentAmount,omitempty"`
-	PaymentType     *string                `json:"paymentType,omitempty"`
-	Note            *string                `json:"note,omitempty"`
-	TransactionType *string                `json:"transaction_type,omitempty"`
-	Discount        *float64               `json:"discount,omitempty"`
-	Items           []TransactionItemInput `json:"items"`
+	ID                *uint                  `json:"id,omitempty"`
+	Status            string                 `json:"status"`
+	PaymentAmount     *float64               `json:"paymentAmount,omitempty"`
+	PaymentType       *string                `json:"paymentType,omitempty"`
+	Payments          []PaymentInput         `json:"payments,omitempty" binding:"omitempty,dive"` // Takes precedence over paymentAmount/paymentType
+	Note              *string                `json:"note,omitempty"`
+	TransactionType   *string                `json:"transaction_type,omitempty"`
+	Discount          *float64               `json:"discount,omitempty"`
+	CustomerID        *uint                  `json:"customer_id,omitempty"`
+	CustomerAddressID *uint                  `json:"customer_address_id,omitempty"`
+	CouponCodes       []string               `json:"coupon_codes,omitempty"`
+	QuotationID       *uint                  `json:"quotation_id,omitempty"` // Lines on the quotation keep their quoted price
+	PaymentSchedule   []PaymentScheduleInput `json:"payment_schedule,omitempty" binding:"omitempty,dive"`
+	Items             []TransactionItemInput `json:"items" binding:"dive"`
+
+	// Only set by the offline sync, not accepted from clients directly
+	ClientUUID *string    `json:"-"`
+	SoldAt     *time.Time `json:"-"`
 }
 
 type UpdateTransactionInput struct {
-	Status          string   `json:"status"`
-	Note            *string  `json:"note,omitempty"`
-	TransactionType *string  `json:"transaction_type,omitempty"`
-	Discount        *float64 `json:"discount,omitempty"`
+	Status            string   `json:"status"`
+	Note              *string  `json:"note,omitempty"`
+	TransactionType   *string  `json:"transaction_type,omitempty"`
+	Discount          *float64 `json:"discount,omitempty"`
+	CustomerID        *uint    `json:"customer_id,omitempty"` // 0 removes the customer
+	CustomerAddressID *uint    `json:"customer_address_id,omitempty"`
+}
+
+// AddPaymentInput pays (part of) the balance of a partially paid sale. Paying it off completes
+// the sale, only cash beyond the balance is given back as change.
+type AddPaymentInput struct {
+	Payments []PaymentInput `json:"payments" binding:"required,min=1,dive"`
+}
+
+type RefundLineInput struct {
+	TransactionItemID uint `json:"transaction_item_id" binding:"required"`
+	Quantity          int  `json:"quantity" binding:"required,gt=0"`
+}
+
+// RefundInput refunds the given lines, or everything not yet refunded when Lines is empty
+type RefundInput struct {
+	Lines  []RefundLineInput `json:"lines,omitempty" binding:"omitempty,dive"`
+	Reason *string           `json:"reason,omitempty"`
+	Method *string           `json:"method,omitempty" binding:"omitempty,oneof=cash qris debit credit"` // Defaults to how the sale was paid, "credit" takes it off the amount still owed
+}
+
+// VoidTransactionInput cancels a mistaken sale, approved on the spot by an owner or admin
+type VoidTransactionInput struct {
+	Reason             string `json:"reason" binding:"required"`
+	SupervisorUsername string `json:"supervisor_username" binding:"required"`
+	SupervisorPIN      string `json:"supervisor_pin" binding:"required"`
 }
 
 type TransactionFilter struct {
@@ -32,10 +96,11 @@ type TransactionFilter struct {
 	StartDate string
 	Date      string
 	Status    string
+	Number    string // Invoice number, exact match
+	CashierID uint   // Who completed the sale, or created it while it is a draft
+	SessionID uint   // Cash session the sale was completed on
 }
 
-
-
 type TransactionListResponse struct {
 	Data       []models.Transaction `json:"data"`
 	Page       int                  `json:"page"`
@@ -43,3 +108,11 @@ type TransactionListResponse struct {
 	Total      int64                `json:"total"`
 	TotalPages int                  `json:"totalPages"`
 }
+
+// ExchangeInput takes ReturnLines back from the original sale and sells NewLines in their place
+type ExchangeInput struct {
+	ReturnLines []RefundLineInput      `json:"return_lines" binding:"required,min=1,dive"`
+	NewLines    []TransactionItemInput `json:"new_lines" binding:"required,min=1,dive"`
+	Method      *string                `json:"method,omitempty" binding:"omitempty,oneof=cash qris debit"` // Settles the difference, defaults to cash
+	Note        *string                `json:"note,omitempty"`
+}
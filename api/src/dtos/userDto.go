@@ -4,10 +4,12 @@ type CreateUserInput struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 	Role     string `json:"role" binding:"required"`
+	PIN      string `json:"pin" binding:"omitempty,numeric,min=4,max=8"` // Supervisor PIN for owners and admins
 }
 
 type UpdateUserInput struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 	Role     string `json:"role"`
+	PIN      string `json:"pin" binding:"omitempty,numeric,min=4,max=8"`
 }
@@ -0,0 +1,50 @@
+package dtos
+
+import "kd-api/src/models"
+
+type CreateVehicleInput struct {
+	PlateNumber string  `json:"plate_number" binding:"required"`
+	Name        string  `json:"name" binding:"required"`
+	MaxWeightKg float64 `json:"max_weight_kg" binding:"gte=0"`
+	MaxVolumeM3 float64 `json:"max_volume_m3" binding:"gte=0"`
+	Note        *string `json:"note"`
+}
+
+type UpdateVehicleInput struct {
+	PlateNumber *string  `json:"plate_number"`
+	Name        *string  `json:"name"`
+	MaxWeightKg *float64 `json:"max_weight_kg" binding:"omitempty,gte=0"`
+	MaxVolumeM3 *float64 `json:"max_volume_m3" binding:"omitempty,gte=0"`
+	IsActive    *bool    `json:"is_active"`
+	Note        *string  `json:"note"`
+}
+
+// VehicleRun is what one vehicle carries on one day, open delivery orders only
+type VehicleRun struct {
+	Vehicle       models.Vehicle         `json:"vehicle"`
+	Orders        []models.DeliveryOrder `json:"orders"`
+	TotalWeightKg float64                `json:"total_weight_kg"`
+	TotalVolumeM3 float64                `json:"total_volume_m3"`
+	WeightLoadPct *float64               `json:"weight_load_pct,omitempty"` // Empty when the vehicle has no weight limit
+	VolumeLoadPct *float64               `json:"volume_load_pct,omitempty"`
+	Overloaded    bool                   `json:"overloaded"`
+	Warnings      []string               `json:"warnings,omitempty"`
+}
+
+type DeliveryScheduleFilter struct {
+	Date string `form:"date"` // Defaults to today
+}
+
+type DeliveryScheduleResponse struct {
+	Date       string                 `json:"date"`
+	Runs       []VehicleRun           `json:"runs"`
+	Unassigned []models.DeliveryOrder `json:"unassigned"` // Open orders for the day without a vehicle
+}
+
+// AssignVehicleInput puts delivery orders on a vehicle's run for the day
+type AssignVehicleInput struct {
+	VehicleID uint   `json:"vehicle_id" binding:"required"`
+	Date      string `json:"date" binding:"required"` // YYYY-MM-DD, orders are rescheduled to it
+	DriverID  *uint  `json:"driver_id"`
+	OrderIDs  []uint `json:"order_ids" binding:"required,min=1"`
+}
@@ -0,0 +1,58 @@
+package dtos
+
+import (
+	"kd-api/src/models"
+)
+
+type CreateWriteOffInput struct {
+	ItemID   uint    `json:"item_id" binding:"required"`
+	Quantity int     `json:"quantity" binding:"required,gt=0"`
+	Reason   string  `json:"reason" binding:"required,oneof=damaged expired lost internal_use sample"`
+	ImageURL *string `json:"image_url"`
+	Note     *string `json:"note"`
+}
+
+type WriteOffFilter struct {
+	ItemID    uint   `form:"item_id"`
+	Reason    string `form:"reason"`
+	StartDate string `form:"start_date"` // YYYY-MM-DD
+	EndDate   string `form:"end_date"`   // YYYY-MM-DD
+	Page      int    `form:"page"`
+	Limit     int    `form:"limit"`
+}
+
+type WriteOffListResponse struct {
+	Data       []models.StockWriteOff `json:"data"`
+	Page       int                    `json:"page"`
+	Limit      int                    `json:"limit"`
+	Total      int64                  `json:"total"`
+	TotalPages int                    `json:"total_pages"`
+}
+
+type ShrinkageFilter struct {
+	StartDate string `form:"start_date"` // YYYY-MM-DD
+	EndDate   string `form:"end_date"`   // YYYY-MM-DD
+	Period    string `form:"period"`     // day or month (default month)
+}
+
+type ShrinkageRow struct {
+	Period   string  `json:"period"`
+	Reason   string  `json:"reason"`
+	Count    int64   `json:"count"`
+	Quantity int64   `json:"quantity"`
+	Value    float64 `json:"value"`
+}
+
+type ShrinkageReasonTotal struct {
+	Reason   string  `json:"reason"`
+	Count    int64   `json:"count"`
+	Quantity int64   `json:"quantity"`
+	Value    float64 `json:"value"`
+}
+
+type ShrinkageReport struct {
+	Period     string                 `json:"period"`
+	Rows       []ShrinkageRow         `json:"rows"`
+	ByReason   []ShrinkageReasonTotal `json:"by_reason"`
+	TotalValue float64                `json:"total_value"`
+}
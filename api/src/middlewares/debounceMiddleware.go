@@ -33,6 +33,12 @@ func DebounceMiddleware(window time.Duration) gin.HandlerFunc {
 			return
 		}
 
+		// Retries with an Idempotency-Key are answered by IdempotencyMiddleware instead
+		if c.GetHeader("Idempotency-Key") != "" {
+			c.Next()
+			return
+		}
+
 		// Read the request body
 		var bodyBytes []byte
 		if c.Request.Body != nil {
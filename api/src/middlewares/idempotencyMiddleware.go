@@ -0,0 +1,120 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"kd-api/src/services"
+	"kd-api/src/utils/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxIdempotencyKeyLength matches the stored column
+const maxIdempotencyKeyLength = 255
+
+// responseRecorder copies what the handler writes so it can be stored for replays
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes POST, PUT, PATCH and DELETE requests carrying an Idempotency-Key
+// header safe to retry. The first request runs and its response is kept (see IDEMPOTENCY_TTL);
+// a retry with the same key and body gets that response again with Idempotent-Replayed: true,
+// the same key with another body gets 422. Server errors are not kept, those may be retried.
+// Keys are per user, so it must run after AuthMiddleware.
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || (method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch && method != http.MethodDelete) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		userID := common.GetUserID(c)
+		if userID == nil {
+			c.Next()
+			return
+		}
+
+		var bodyBytes []byte
+		if c.Request.Body != nil {
+			var err error
+			bodyBytes, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+		}
+
+		hasher := sha256.New()
+		hasher.Write([]byte(method + " " + c.Request.URL.Path + "\n"))
+		hasher.Write(bodyBytes)
+		requestHash := hex.EncodeToString(hasher.Sum(nil))
+
+		service := services.NewIdempotencyService()
+		request, owned, err := service.Begin(*userID, key, method, c.Request.URL.Path, requestHash)
+		if err != nil {
+			switch err.Error() {
+			case "idempotency key was already used for a different request":
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			case "a request with this idempotency key is still in progress":
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			c.Abort()
+			return
+		}
+
+		if !owned {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(request.StatusCode, request.ContentType, request.Response)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// Free the key if the handler panics, the retry should run again
+		stored := false
+		defer func() {
+			if !stored {
+				_ = service.Release(request.ID)
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		if err := service.Complete(request.ID, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err == nil {
+			stored = true
+		}
+	}
+}
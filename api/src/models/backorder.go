@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// Backorder is the part of a sold quantity that could not be taken from
+// stock at sale time. It is deducted (and logged as a sale) when fulfilled.
+type Backorder struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	TransactionID uint       `gorm:"not null;index" json:"transaction_id"`
+	ItemID        uint       `gorm:"not null;index" json:"item_id"`
+	Quantity      int        `gorm:"not null" json:"quantity"`
+	Status        string     `gorm:"type:enum('open','fulfilled','cancelled');default:'open';index" json:"status"`
+	FulfilledAt   *time.Time `json:"fulfilled_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Item Item `gorm:"foreignKey:ItemID" json:"item"`
+}
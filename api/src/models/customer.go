@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Customer struct {
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	Name            string            `gorm:"type:varchar(150);not null;index" json:"name"`
+	Phone           *string           `gorm:"type:varchar(30);uniqueIndex" json:"phone,omitempty"` // Stored normalised, e.g. 08123456789
+	NPWP            *string           `gorm:"column:npwp;type:varchar(30)" json:"npwp,omitempty"`
+	Group           string            `gorm:"column:customer_group;type:enum('retail','contractor','wholesale');default:'retail'" json:"group"`
+	Note            *string           `gorm:"type:text" json:"note,omitempty"`
+	CreditLimit     float64           `gorm:"not null;default:0" json:"credit_limit"` // 0 means the customer cannot buy on credit
+	PaymentTermDays int               `gorm:"not null;default:30" json:"payment_term_days"`
+	Addresses       []CustomerAddress `json:"addresses,omitempty"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type CustomerAddress struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CustomerID uint      `gorm:"not null;index" json:"customer_id"`
+	Label      *string   `gorm:"type:varchar(50)" json:"label,omitempty"` // e.g. "Site Rungkut", "Warehouse"
+	Address    string    `gorm:"type:text;not null" json:"address"`
+	IsDefault  bool      `gorm:"not null;default:false" json:"is_default"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// CustomerPayment is money received against a customer's credit sales.
+// One payment can settle several invoices, each share is an allocation.
+type CustomerPayment struct {
+	ID            uint                        `gorm:"primaryKey" json:"id"`
+	Number        string                      `gorm:"type:varchar(50);index" json:"number"`
+	CustomerID    uint                        `gorm:"not null;index" json:"customer_id"`
+	Amount        float64                     `gorm:"not null" json:"amount"`
+	Method        string                      `gorm:"type:enum('cash','qris','debit');not null" json:"method"`
+	Reference     *string                     `gorm:"type:varchar(100)" json:"reference,omitempty"` // e.g. transfer slip number
+	Note          *string                     `gorm:"type:text" json:"note,omitempty"`
+	UserID        *uint                       `gorm:"index" json:"user_id,omitempty"`
+	CashSessionID *uint                       `gorm:"index" json:"cash_session_id,omitempty"` // Drawer cash payments went into
+	Allocations   []CustomerPaymentAllocation `json:"allocations"`
+	CreatedAt     time.Time                   `gorm:"autoCreateTime;index" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+type CustomerPaymentAllocation struct {
+	ID                uint    `gorm:"primaryKey" json:"id"`
+	CustomerPaymentID uint    `gorm:"not null;index" json:"customer_payment_id"`
+	TransactionID     uint    `gorm:"not null;index" json:"transaction_id"`
+	Amount            float64 `gorm:"not null" json:"amount"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// DeliveryOrder is one trip taking goods of a deliver sale to the customer. A sale can be
+// split over several orders; stock leaves the books only when an order is delivered.
+type DeliveryOrder struct {
+	ID            uint                `gorm:"primaryKey" json:"id"`
+	Number        string              `gorm:"type:varchar(50);uniqueIndex" json:"number"`
+	TransactionID uint                `gorm:"not null;index" json:"transaction_id"`
+	Status        string              `gorm:"type:enum('scheduled','loaded','on_the_way','delivered','failed');default:'scheduled';index" json:"status"`
+	ScheduledDate time.Time           `gorm:"type:date;not null;index" json:"scheduled_date"`
+	RecipientName string              `gorm:"type:varchar(150);not null" json:"recipient_name"`
+	Phone         *string             `gorm:"type:varchar(30)" json:"phone,omitempty"`
+	Address       string              `gorm:"type:text;not null" json:"address"`
+	VehicleID     *uint               `gorm:"index" json:"vehicle_id,omitempty"`
+	DriverID      *uint               `gorm:"index" json:"driver_id,omitempty"`
+	TotalWeightKg float64             `gorm:"not null;default:0" json:"total_weight_kg"` // Loaded lines, items without a weight count as 0
+	TotalVolumeM3 float64             `gorm:"not null;default:0" json:"total_volume_m3"`
+	DeliveryFee   float64             `gorm:"not null;default:0" json:"delivery_fee"`
+	Note          *string             `gorm:"type:text" json:"note,omitempty"`
+	ReceivedBy    *string             `gorm:"type:varchar(150)" json:"received_by,omitempty"` // Name of who signed for the goods
+	ProofImageID  *uint               `json:"proof_image_id,omitempty"`
+	FailureReason *string             `gorm:"type:text" json:"failure_reason,omitempty"`
+	DeliveredAt   *time.Time          `json:"delivered_at,omitempty"`
+	CreatedByID   *uint               `json:"created_by_id,omitempty"`
+	Lines         []DeliveryOrderLine `json:"lines"`
+	CreatedAt     time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Driver      *User        `gorm:"foreignKey:DriverID" json:"driver,omitempty"`
+	Vehicle     *Vehicle     `gorm:"foreignKey:VehicleID" json:"vehicle,omitempty"`
+	ProofImage  *Image       `gorm:"foreignKey:ProofImageID" json:"proof_image,omitempty"`
+	Transaction *Transaction `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+}
+
+type DeliveryOrderLine struct {
+	ID                uint `gorm:"primaryKey" json:"id"`
+	DeliveryOrderID   uint `gorm:"not null;index" json:"delivery_order_id"`
+	TransactionItemID uint `gorm:"not null;index" json:"transaction_item_id"`
+	ItemID            uint `gorm:"not null" json:"item_id"`
+	Quantity          int  `gorm:"not null" json:"quantity"`                     // Loaded for this trip
+	DeliveredQuantity int  `gorm:"not null;default:0" json:"delivered_quantity"` // What the customer took, the rest comes back
+
+	Item Item `gorm:"foreignKey:ItemID" json:"item"`
+}
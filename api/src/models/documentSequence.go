@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// DocumentSequence holds the last number handed out for a document type in a period.
+// Period is empty for sequences that never reset, "2026" or "2026/10" otherwise.
+type DocumentSequence struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	DocType    string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_document_sequence" json:"doc_type"`
+	Period     string    `gorm:"type:varchar(10);not null;default:'';uniqueIndex:idx_document_sequence" json:"period"`
+	LastNumber uint      `gorm:"not null;default:0" json:"last_number"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Exchange swaps returned lines of a sale for new goods. It links the original sale,
+// the refund that took the goods back and the new sale that handed the others out.
+type Exchange struct {
+	ID                    uint      `gorm:"primaryKey" json:"id"`
+	Number                string    `gorm:"type:varchar(50);index" json:"number"`
+	OriginalTransactionID uint      `gorm:"not null;index" json:"original_transaction_id"`
+	RefundID              uint      `gorm:"not null" json:"refund_id"`
+	NewTransactionID      uint      `gorm:"not null" json:"new_transaction_id"`
+	ReturnedAmount        float64   `gorm:"not null" json:"returned_amount"`
+	NewAmount             float64   `gorm:"not null" json:"new_amount"`
+	Difference            float64   `gorm:"not null" json:"difference"`                                        // Positive is collected from the customer, negative is paid out
+	Method                *string   `gorm:"type:enum('cash','qris','debit','credit')" json:"method,omitempty"` // How the difference was settled
+	CashSessionID         *uint     `gorm:"index" json:"cash_session_id,omitempty"`                            // Drawer a cash payout came from
+	Note                  *string   `gorm:"type:text" json:"note,omitempty"`
+	UserID                *uint     `gorm:"index" json:"user_id,omitempty"`
+	CreatedAt             time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Refund         *Refund      `gorm:"foreignKey:RefundID" json:"refund,omitempty"`
+	NewTransaction *Transaction `gorm:"foreignKey:NewTransactionID" json:"new_transaction,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotentRequest remembers a mutating request sent with an Idempotency-Key header and the
+// response it got, so a client retry replays that response instead of running twice.
+type IdempotentRequest struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;uniqueIndex:idx_idempotent_request" json:"user_id"`
+	Key         string     `gorm:"column:request_key;type:varchar(255);not null;uniqueIndex:idx_idempotent_request" json:"key"`
+	Method      string     `gorm:"type:varchar(10);not null" json:"method"`
+	Path        string     `gorm:"type:varchar(255);not null" json:"path"`
+	RequestHash string     `gorm:"type:char(64);not null" json:"request_hash"` // SHA-256 of method, path and body
+	StatusCode  int        `gorm:"not null;default:0" json:"status_code"`
+	ContentType string     `gorm:"type:varchar(100)" json:"content_type"`
+	Response    []byte     `gorm:"type:mediumblob" json:"-"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // Nil while the first request is still running
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -12,9 +12,21 @@ type Item struct {
 	Description *string        `gorm:"type:text" json:"description,omitempty"`
 	Stock          int            `gorm:"not null;default:0" json:"stock"`
 	IsStockManaged *bool          `gorm:"not null;default:true" json:"is_stock_managed"`
+	NegativeStockPolicy *string   `gorm:"type:enum('reject','allow','backorder')" json:"negative_stock_policy,omitempty"` // Overrides NEGATIVE_STOCK_POLICY for this item
 	BuyPrice       float64        `gorm:"not null" json:"buy_price"`
 	Price       float64        `gorm:"not null" json:"price"`
 	ImageURL    *string        `gorm:"type:varchar(255)" json:"image_url,omitempty" nullable:"true"`
+	Category    *string        `gorm:"type:varchar(100);index" json:"category,omitempty"`
+	SupplierID  *uint          `gorm:"index" json:"supplier_id,omitempty"`
+	IsTaxable   *bool          `gorm:"not null;default:true" json:"is_taxable"`
+	TaxRate     *float64       `json:"tax_rate,omitempty"` // Percent, overrides PPN_RATE for this item
+	WeightKg    *float64       `json:"weight_kg,omitempty"` // Per unit, used for delivery load planning
+	VolumeM3    *float64       `json:"volume_m3,omitempty"` // Per unit
+
+	// Computed from active stock reservations, not stored
+	ReservedStock  int `gorm:"-" json:"reserved_stock"`
+	AvailableStock int `gorm:"-" json:"available_stock"`
+
     CreatedAt   time.Time         `gorm:"autoCreateTime" json:"created_at"`
     UpdatedAt   time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
     DeletedAt   gorm.DeletedAt    `gorm:"index" json:"-"`
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// ItemCostHistory keeps every buy price an item has had, so stock can be
+// valued at the cost that was effective on a past date.
+type ItemCostHistory struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ItemID      uint      `gorm:"not null;index:idx_item_cost_effective" json:"item_id"`
+	BuyPrice    float64   `gorm:"not null" json:"buy_price"`
+	EffectiveAt time.Time `gorm:"not null;index:idx_item_cost_effective" json:"effective_at"`
+	UserID      *uint     `json:"user_id,omitempty"`
+}
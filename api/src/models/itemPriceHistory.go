@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// ItemPriceHistory keeps every selling price an item has had, so a sale made
+// offline can be checked against the price that was on the shelf at the time.
+type ItemPriceHistory struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ItemID      uint      `gorm:"not null;index:idx_item_price_effective" json:"item_id"`
+	Price       float64   `gorm:"not null" json:"price"`
+	EffectiveAt time.Time `gorm:"not null;index:idx_item_price_effective" json:"effective_at"`
+	UserID      *uint     `json:"user_id,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PaymentScheduleEntry is one planned installment of a sale paid with a down payment.
+// Payments after the down payment fill the entries in due date order.
+type PaymentScheduleEntry struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	TransactionID uint      `gorm:"not null;index" json:"transaction_id"`
+	DueDate       time.Time `gorm:"type:date;not null;index" json:"due_date"`
+	Amount        float64   `gorm:"not null" json:"amount"`
+	PaidAmount    float64   `gorm:"not null;default:0" json:"paid_amount"`
+	Note          *string   `gorm:"type:varchar(255)" json:"note,omitempty"` // e.g. "on delivery"
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
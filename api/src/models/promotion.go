@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Promotion is a discount rule evaluated when a sale is rung up.
+//
+//   - item_discount: DiscountType/Value off every unit of the matching lines
+//   - buy_x_get_y: of every BuyQuantity+FreeQuantity units on a line, FreeQuantity are free
+//   - spend_threshold: DiscountType/Value off the sale once it reaches MinSpend
+//
+// Item rules match ItemID, else Category, else every item. A rule with a CouponCode
+// only applies when the code is entered at the till.
+type Promotion struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Name         string     `gorm:"type:varchar(150);not null" json:"name"`
+	Type         string     `gorm:"type:enum('item_discount','buy_x_get_y','spend_threshold');not null" json:"type"`
+	DiscountType string     `gorm:"type:enum('percent','fixed');default:'percent'" json:"discount_type"`
+	Value        float64    `gorm:"not null;default:0" json:"value"` // Percent (0-100) or amount, per unit for item_discount
+	ItemID       *uint      `gorm:"index" json:"item_id,omitempty"`
+	Category     *string    `gorm:"type:varchar(100)" json:"category,omitempty"`
+	BuyQuantity  int        `gorm:"not null;default:0" json:"buy_quantity"`
+	FreeQuantity int        `gorm:"not null;default:0" json:"free_quantity"`
+	MinSpend     float64    `gorm:"not null;default:0" json:"min_spend"`
+	MaxDiscount  *float64   `json:"max_discount,omitempty"` // Caps a percent spend_threshold discount
+	CouponCode   *string    `gorm:"type:varchar(50);index" json:"coupon_code,omitempty"`
+	UsageLimit   *int       `json:"usage_limit,omitempty"` // Completed sales the coupon may be used on
+	UsageCount   int        `gorm:"not null;default:0" json:"usage_count"`
+	StartsAt     *time.Time `json:"starts_at,omitempty"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+	IsActive     bool       `gorm:"not null;default:true" json:"is_active"`
+
+	Item *Item `gorm:"foreignKey:ItemID" json:"item,omitempty"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TransactionPromotion records a promotion applied to a sale, on one line
+// or, when TransactionItemID is nil, on the whole sale. Amount is what it cost.
+type TransactionPromotion struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	TransactionID     uint      `gorm:"not null;index" json:"transaction_id"`
+	TransactionItemID *uint     `gorm:"index" json:"transaction_item_id,omitempty"`
+	PromotionID       uint      `gorm:"not null;index" json:"promotion_id"`
+	Name              string    `gorm:"type:varchar(150);not null" json:"name"` // Copied so renaming the rule keeps old receipts right
+	CouponCode        *string   `gorm:"type:varchar(50)" json:"coupon_code,omitempty"`
+	Amount            float64   `gorm:"not null" json:"amount"`
+	CreatedAt         time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
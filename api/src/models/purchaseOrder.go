@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+type PurchaseOrder struct {
+	ID          uint                `gorm:"primaryKey" json:"id"`
+	Number      string              `gorm:"type:varchar(50);index" json:"number"`
+	SupplierID  uint                `gorm:"not null;index" json:"supplier_id"`
+	Status      string              `gorm:"type:enum('draft','ordered','received','cancelled');default:'draft';index" json:"status"`
+	Total       float64             `gorm:"not null;default:0" json:"total"`
+	Note        *string             `gorm:"type:text" json:"note,omitempty"`
+	CreatedByID *uint               `json:"created_by_id,omitempty"`
+	OrderedAt   *time.Time          `json:"ordered_at,omitempty"`
+	ReceivedAt  *time.Time          `json:"received_at,omitempty"`
+	Lines       []PurchaseOrderLine `json:"lines"`
+	CreatedAt   time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Supplier Supplier `gorm:"foreignKey:SupplierID" json:"supplier"`
+}
+
+type PurchaseOrderLine struct {
+	ID              uint    `gorm:"primaryKey" json:"id"`
+	PurchaseOrderID uint    `gorm:"not null;index" json:"purchase_order_id"`
+	ItemID          uint    `gorm:"not null;index" json:"item_id"`
+	Quantity        int     `gorm:"not null" json:"quantity"`
+	UnitCost        float64 `gorm:"not null;default:0" json:"unit_cost"`
+	Subtotal        float64 `gorm:"not null;default:0" json:"subtotal"`
+
+	// Relations
+	Item Item `gorm:"foreignKey:ItemID" json:"item"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Quotation is a price offer (penawaran harga) for a customer. It reserves nothing and
+// becomes a sale only when converted, at the prices it was quoted with.
+type Quotation struct {
+	ID                uint            `gorm:"primaryKey" json:"id"`
+	Number            string          `gorm:"type:varchar(50);uniqueIndex" json:"number"`
+	Status            string          `gorm:"type:enum('open','converted','cancelled');default:'open';index" json:"status"` // Open quotes past ValidUntil are expired
+	CustomerID        uint            `gorm:"not null;index" json:"customer_id"`
+	CustomerAddressID *uint           `json:"customer_address_id,omitempty"`
+	TransactionType   string          `gorm:"type:enum('onsite','deliver');default:'onsite'" json:"transaction_type"`
+	ValidUntil        time.Time       `gorm:"type:date;not null;index" json:"valid_until"`
+	Discount          float64         `gorm:"not null;default:0" json:"discount"`
+	TaxExclusive      bool            `gorm:"not null;default:false" json:"tax_exclusive"`
+	TaxBase           float64         `gorm:"not null;default:0" json:"tax_base"`
+	TaxAmount         float64         `gorm:"not null;default:0" json:"tax_amount"`
+	Total             float64         `gorm:"not null;default:0" json:"total"`
+	Note              *string         `gorm:"type:text" json:"note,omitempty"`
+	CreatedByID       *uint           `gorm:"index" json:"created_by_id,omitempty"`
+	TransactionID     *uint           `gorm:"index" json:"transaction_id,omitempty"` // The sale it was converted into
+	ConvertedAt       *time.Time      `json:"converted_at,omitempty"`
+	Expired           bool            `gorm:"-" json:"expired"` // Open and past ValidUntil, set when read
+	Lines             []QuotationLine `json:"lines"`
+	CreatedAt         time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Customer        *Customer        `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	CustomerAddress *CustomerAddress `gorm:"foreignKey:CustomerAddressID" json:"customer_address,omitempty"`
+	CreatedBy       *User            `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+}
+
+// QuotationLine holds the price as quoted, it is what the sale line gets on conversion
+type QuotationLine struct {
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	QuotationID    uint    `gorm:"not null;index" json:"quotation_id"`
+	ItemID         uint    `gorm:"not null" json:"item_id"`
+	Quantity       int     `gorm:"not null" json:"quantity"`
+	ListPrice      float64 `gorm:"not null;default:0" json:"list_price"` // Item.Price when quoted
+	Price          float64 `gorm:"not null" json:"price"`
+	LineDiscount   float64 `gorm:"not null;default:0" json:"line_discount"`
+	DiscountReason *string `gorm:"type:varchar(255)" json:"discount_reason,omitempty"`
+	Subtotal       float64 `gorm:"not null" json:"subtotal"`
+	TaxRate        float64 `gorm:"not null;default:0" json:"tax_rate"`
+	TaxBase        float64 `gorm:"not null;default:0" json:"tax_base"`
+	TaxAmount      float64 `gorm:"not null;default:0" json:"tax_amount"`
+
+	Item Item `gorm:"foreignKey:ItemID" json:"item"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Refund is the document for money and goods returned on a sale.
+// A sale can have several refunds, each covering some of its lines.
+type Refund struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	Number         string       `gorm:"type:varchar(50);index" json:"number"`
+	TransactionID  uint         `gorm:"not null;index" json:"transaction_id"`
+	Reason         *string      `gorm:"type:text" json:"reason,omitempty"`
+	Amount         float64      `gorm:"not null" json:"amount"`
+	CreditedAmount float64      `gorm:"not null;default:0" json:"credited_amount"`                                   // Part of Amount taken off the sale's outstanding balance instead of paid out
+	Method         string       `gorm:"type:enum('cash','qris','debit','credit','exchange');not null" json:"method"` // "exchange" when the value went into an exchange sale
+	UserID         *uint        `gorm:"index" json:"user_id,omitempty"`
+	CashSessionID  *uint        `gorm:"index" json:"cash_session_id,omitempty"` // Drawer the cash was paid out of
+	Lines          []RefundLine `json:"lines"`
+	CreatedAt      time.Time    `gorm:"autoCreateTime;index" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+type RefundLine struct {
+	ID                uint    `gorm:"primaryKey" json:"id"`
+	RefundID          uint    `gorm:"not null;index" json:"refund_id"`
+	TransactionItemID uint    `gorm:"not null;index" json:"transaction_item_id"`
+	ItemID            uint    `gorm:"not null" json:"item_id"`
+	Quantity          int     `gorm:"not null" json:"quantity"`
+	Price             float64 `gorm:"not null" json:"price"`
+	Subtotal          float64 `gorm:"not null" json:"subtotal"`
+
+	Item Item `gorm:"foreignKey:ItemID" json:"item"`
+}
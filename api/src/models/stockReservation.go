@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+type StockReservation struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ItemID        uint       `gorm:"not null;index" json:"item_id"`
+	TransactionID uint       `gorm:"not null;index" json:"transaction_id"`
+	Quantity      int        `gorm:"not null" json:"quantity"`
+	Type          string     `gorm:"type:enum('draft','delivery','layaway');not null" json:"type"` // layaway: held for a partially paid sale until it is paid off
+	Status        string     `gorm:"type:enum('active','released','fulfilled');default:'active';index" json:"status"`
+	ExpiresAt     *time.Time `gorm:"index" json:"expires_at,omitempty"` // Only drafts expire, delivery holds stay until delivered
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Item Item `gorm:"foreignKey:ItemID" json:"item"`
+}
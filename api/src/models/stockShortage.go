@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// StockShortage records every sale that asked for more than the stock on hand
+// and how the negative-stock policy resolved it.
+type StockShortage struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ItemID        uint      `gorm:"not null;index" json:"item_id"`
+	TransactionID *uint     `gorm:"index" json:"transaction_id,omitempty"` // Empty when a new sale was rejected
+	Required      int       `gorm:"not null" json:"required"`
+	Available     int       `gorm:"not null" json:"available"`
+	Policy        string    `gorm:"type:enum('reject','allow','backorder');not null;index" json:"policy"`
+	Role          string    `gorm:"type:varchar(20)" json:"role"`
+	UserID        *uint     `gorm:"index" json:"user_id,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	// Relations
+	Item Item  `gorm:"foreignKey:ItemID" json:"item"`
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
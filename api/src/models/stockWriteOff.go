@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+type StockWriteOff struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ItemID    uint      `gorm:"not null;index" json:"item_id"`
+	Quantity  int       `gorm:"not null" json:"quantity"`
+	Reason    string    `gorm:"type:enum('damaged','expired','lost','internal_use','sample');not null;index" json:"reason"`
+	UnitCost  float64   `gorm:"not null;default:0" json:"unit_cost"`          // Buy price at the time of the write-off
+	TotalCost float64   `gorm:"not null;default:0" json:"total_cost"`         // Quantity * UnitCost
+	ImageURL  *string   `gorm:"type:varchar(255)" json:"image_url,omitempty"` // Photo evidence, uploaded via /upload/image
+	Note      *string   `gorm:"type:text" json:"note,omitempty"`
+	UserID    *uint     `gorm:"index" json:"user_id,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	// Relations
+	Item Item  `gorm:"foreignKey:ItemID" json:"item"`
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Supplier struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	Name         string         `gorm:"unique;type:varchar(150);not null" json:"name"`
+	Phone        *string        `gorm:"type:varchar(30)" json:"phone,omitempty"`
+	Address      *string        `gorm:"type:text" json:"address,omitempty"`
+	LeadTimeDays int            `gorm:"not null;default:7" json:"lead_time_days"` // Days between ordering and goods arriving
+	Note         *string        `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
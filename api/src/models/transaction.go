@@ -8,15 +8,43 @@ import (
 
 type Transaction struct {
     ID          uint              `gorm:"primaryKey" json:"id"`
-    Status      string            `gorm:"type:enum('draft','completed','refunded');default:'draft'" json:"status"`
+    Number      *string           `gorm:"type:varchar(50);uniqueIndex" json:"number,omitempty"` // Invoice number, given when the sale leaves draft
+    Status      string            `gorm:"type:enum('draft','partially_paid','completed','partially_refunded','refunded','voided');default:'draft'" json:"status"` // partially_paid: down payment taken, BalanceDue still open
     Total       float64           `gorm:"not null;default:0" json:"total"`
     Discount    float64           `gorm:"default:0" json:"discount"`
+    PromotionDiscount float64     `gorm:"not null;default:0" json:"promotion_discount"` // Sale-level promotions, line promotions are already in the line subtotals
+    TaxExclusive bool             `gorm:"not null;default:false" json:"tax_exclusive"` // PPN was added on top of the line prices (PRICES_INCLUDE_TAX=false)
+    TaxBase     float64           `gorm:"not null;default:0" json:"tax_base"`   // DPP of the taxable lines
+    TaxAmount   float64           `gorm:"not null;default:0" json:"tax_amount"` // PPN, included in Total either way
     Payment     *float64          `json:"payment,omitempty"`
     Change      *float64          `json:"change,omitempty"`
-    PaymentType *string           `gorm:"type:enum('cash','qris','debit','credit')" json:"payment_type,omitempty"`
+    PaymentType *string           `gorm:"type:enum('cash','qris','debit','credit','split','exchange')" json:"payment_type,omitempty"` // "split" when paid with more than one method
     Items       []TransactionItem `json:"items"`
+    Payments    []TransactionPayment `json:"payments,omitempty"`
+    PaymentSchedule []PaymentScheduleEntry `json:"payment_schedule,omitempty"`
+    Refunds     []Refund          `json:"refunds,omitempty"`
+    Promotions  []TransactionPromotion `json:"promotions,omitempty"`
     Note        *string           `gorm:"type:text" json:"note,omitempty"`
     TransactionType string        `gorm:"type:enum('onsite','deliver');default:'onsite'" json:"transaction_type"`
+    CustomerID  *uint             `gorm:"index" json:"customer_id,omitempty"`
+    CustomerAddressID *uint       `json:"customer_address_id,omitempty"` // Where a deliver order goes
+    Customer    *Customer         `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+    CustomerAddress *CustomerAddress `gorm:"foreignKey:CustomerAddressID" json:"customer_address,omitempty"`
+    DeliveredAt *time.Time        `json:"delivered_at,omitempty"` // Stock for deliver orders is deducted here, not at completion
+    AmountDue   float64           `gorm:"not null;default:0;index" json:"amount_due"` // Part charged on credit the customer still owes
+    DueDate     *time.Time        `gorm:"index" json:"due_date,omitempty"`
+    BalanceDue  float64           `gorm:"not null;default:0;index" json:"balance_due"` // Left to pay on a partially paid sale before it completes
+    ClientUUID  *string           `gorm:"type:char(36);uniqueIndex" json:"client_uuid,omitempty"` // Set by the mobile POS for sales made offline
+    QuotationID *uint             `gorm:"index" json:"quotation_id,omitempty"` // Quote the sale was converted from, its lines keep the quoted prices
+    VoidedAt    *time.Time        `json:"voided_at,omitempty"` // A voided sale never happened: no sale, no refund, stock back on the shelf
+    VoidReason  *string           `gorm:"type:text" json:"void_reason,omitempty"`
+    VoidedByID  *uint             `json:"voided_by_id,omitempty"`
+    VoidApprovedByID *uint        `json:"void_approved_by_id,omitempty"` // Supervisor who entered their PIN
+    CreatedByID *uint             `gorm:"index" json:"created_by_id,omitempty"`   // Who rang the sale up
+    CompletedByID *uint           `gorm:"index" json:"completed_by_id,omitempty"` // Who took the payment that completed it
+    CashSessionID *uint           `gorm:"index" json:"cash_session_id,omitempty"` // Drawer of the last payment taken, the change came out of it
+    CreatedBy   *User             `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+    CompletedBy *User             `gorm:"foreignKey:CompletedByID" json:"completed_by,omitempty"`
 
 
     CreatedAt   time.Time         `gorm:"autoCreateTime" json:"created_at"`
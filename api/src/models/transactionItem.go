@@ -1,13 +1,22 @@
 package models
 
 type TransactionItem struct {
-	ID            uint    `gorm:"primaryKey" json:"id"`
-	TransactionID uint    `gorm:"not null" json:"transaction_id"`
-	ItemID        uint    `gorm:"not null" json:"item_id"`
-	Quantity      int     `gorm:"not null;default:1" json:"quantity"`
-	Price         float64 `gorm:"not null" json:"price"`
-	Subtotal      float64 `gorm:"not null" json:"subtotal"`
+	ID                uint    `gorm:"primaryKey" json:"id"`
+	TransactionID     uint    `gorm:"not null" json:"transaction_id"`
+	ItemID            uint    `gorm:"not null" json:"item_id"`
+	Quantity          int     `gorm:"not null;default:1" json:"quantity"`
+	ListPrice         float64 `gorm:"not null;default:0" json:"list_price"` // Item price at the time of sale
+	Price             float64 `gorm:"not null" json:"price"`                // Unit price charged, differs from ListPrice on a custom price
+	Subtotal          float64 `gorm:"not null" json:"subtotal"`             // Quantity x Price less PromotionDiscount and LineDiscount
+	PromotionDiscount float64 `gorm:"not null;default:0" json:"promotion_discount"`
+	LineDiscount      float64 `gorm:"not null;default:0" json:"line_discount"` // Given by the cashier
+	DiscountReason    *string `gorm:"type:varchar(255)" json:"discount_reason,omitempty"`
+	TaxRate           float64 `gorm:"not null;default:0" json:"tax_rate"`   // 0 for exempt items
+	TaxBase           float64 `gorm:"not null;default:0" json:"tax_base"`   // DPP, the line's share of the sale net of PPN
+	TaxAmount         float64 `gorm:"not null;default:0" json:"tax_amount"` // PPN on TaxBase
+	RefundedQuantity  int     `gorm:"not null;default:0" json:"refunded_quantity"`
+	DeliveredQuantity int     `gorm:"not null;default:0" json:"delivered_quantity"` // Handed over on delivery orders
 
 	// Relasi
 	Item Item `gorm:"foreignKey:ItemID" json:"item"`
-}
\ No newline at end of file
+}
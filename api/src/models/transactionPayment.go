@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TransactionPayment is one tender of a sale. A sale paid part cash, part QRIS has two rows.
+type TransactionPayment struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	TransactionID uint      `gorm:"not null;index" json:"transaction_id"`
+	Method        string    `gorm:"type:enum('cash','qris','debit','credit','exchange');not null" json:"method"` // "exchange" is credit from goods returned in an exchange
+	Amount        float64   `gorm:"not null" json:"amount"`
+	Reference     *string   `gorm:"type:varchar(100)" json:"reference,omitempty"` // e.g. card approval code
+	CashSessionID *uint     `gorm:"index" json:"cash_session_id,omitempty"`       // Drawer the tender went into
+	CreatedAt     time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
@@ -4,5 +4,6 @@ type User struct {
 	ID       uint   `json:"id"`
 	Username string `json:"username"`
 	Password string `json:"-"`
-	Role     string `json:"role" gorm:"type:enum('admin','cashier','owner','dev');default:'cashier'"`
+	PIN      string `gorm:"column:pin" json:"-"` // Hashed supervisor PIN, approves voids
+	Role     string `json:"role" gorm:"type:enum('admin','cashier','owner','dev','driver');default:'cashier'"`
 }
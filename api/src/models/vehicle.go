@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Vehicle is a pickup or truck delivery orders are loaded onto.
+// A zero limit means the vehicle is not checked on that measure.
+type Vehicle struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	PlateNumber string         `gorm:"unique;type:varchar(20);not null" json:"plate_number"`
+	Name        string         `gorm:"type:varchar(100);not null" json:"name"` // e.g. "Pickup L300"
+	MaxWeightKg float64        `gorm:"not null;default:0" json:"max_weight_kg"`
+	MaxVolumeM3 float64        `gorm:"not null;default:0" json:"max_volume_m3"`
+	IsActive    bool           `gorm:"not null;default:true" json:"is_active"`
+	Note        *string        `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
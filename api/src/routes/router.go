@@ -31,17 +31,29 @@ func RegisterRoutes(r *gin.Engine) {
 		health.POST("/reboot", controllers.RebootServer)
 		health.POST("/restart-mysql", controllers.RestartMySQL)
 		health.GET("/logs", controllers.GetSystemLogs)
+		health.GET("/ledger", controllers.GetLedgerIntegrity)
+		health.POST("/ledger/repair", controllers.RepairLedger)
 	}
 	// Inventory
 	inventory := r.Group("/inventory")
-	inventory.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin"))
+	inventory.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin"), middlewares.IdempotencyMiddleware())
 	{
 	inventory.GET("/history", controllers.GetInventoryHistory)
+	inventory.GET("/reservations", controllers.GetStockReservations)
+	inventory.GET("/valuation", controllers.GetInventoryValuation)
+	inventory.GET("/valuation/export/csv", controllers.ExportInventoryValuation)
+	inventory.POST("/write-offs", controllers.CreateWriteOff)
+	inventory.GET("/write-offs", controllers.GetWriteOffs)
+	inventory.GET("/shrinkage", controllers.GetShrinkageReport)
+	inventory.GET("/backorders", controllers.GetBackorders)
+	inventory.POST("/backorders/:id/fulfill", controllers.FulfillBackorder)
+	inventory.POST("/backorders/:id/cancel", controllers.CancelBackorder)
+	inventory.GET("/shortages", controllers.GetStockShortages)
 	}	
 
 	// Items 
 	items := r.Group("/items")
-	items.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter())
+	items.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.IdempotencyMiddleware())
 	{
 		items.GET("/", controllers.GetItems)
 		items.GET("/search", controllers.SearchItems)
@@ -61,14 +73,14 @@ func RegisterRoutes(r *gin.Engine) {
 
 	// Uploads
 	uploadRoute := r.Group("/upload")
-	uploadRoute.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter()) // require login
+	uploadRoute.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.IdempotencyMiddleware()) // require login
 	{
-		uploadRoute.POST("/image", middlewares.RoleMiddleware("owner", "admin"), controllers.UploadImage)
+		uploadRoute.POST("/image", middlewares.RoleMiddleware("owner", "admin", "driver"), controllers.UploadImage) // Drivers upload proof of delivery
 	}
 
 	// Transactions
 	transactions := r.Group("/transactions")
-	transactions.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin", "cashier"))
+	transactions.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin", "cashier"), middlewares.IdempotencyMiddleware())
 	{
 		transactions.POST("/", controllers.CreateTransaction)
 		transactions.GET("/", controllers.GetTransactions)
@@ -79,9 +91,74 @@ func RegisterRoutes(r *gin.Engine) {
 		transactions.GET("/:id", controllers.GetTransactionByID)
 		transactions.PATCH("/:id", controllers.UpdateTransactionStatus)
 		transactions.POST("/:id/refund", controllers.RefundTransaction)
+		transactions.POST("/:id/void", controllers.VoidTransaction)
+		transactions.POST("/:id/deliver", controllers.MarkTransactionDelivered)
+		transactions.POST("/:id/payments", controllers.AddTransactionPayment)
+		transactions.POST("/:id/exchange", controllers.CreateExchange)
+		transactions.GET("/:id/exchanges", controllers.GetTransactionExchanges)
+		transactions.GET("/:id/receipt/escpos", controllers.GetTransactionReceiptEscPos)
+		transactions.GET("/:id/delivery-note.pdf", controllers.GetTransactionDeliveryNote)
 		transactions.DELETE("/:id", controllers.DeleteTransaction)
 	}
 
+	// Delivery orders (drivers see and move their own trips)
+	deliveryOrders := r.Group("/delivery-orders")
+	deliveryOrders.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.IdempotencyMiddleware())
+	{
+		deliveryOrders.GET("/", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.GetDeliveryOrders)
+		deliveryOrders.POST("/", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.CreateDeliveryOrder)
+		deliveryOrders.GET("/schedule", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.GetDeliverySchedule)
+		deliveryOrders.POST("/schedule/assign", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.AssignDeliveryVehicle)
+		deliveryOrders.GET("/my-day", middlewares.RoleMiddleware("owner", "admin", "cashier", "driver"), controllers.GetDriverDeliveries)
+		deliveryOrders.GET("/:id", middlewares.RoleMiddleware("owner", "admin", "cashier", "driver"), controllers.GetDeliveryOrderByID)
+		deliveryOrders.PUT("/:id", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.UpdateDeliveryOrder)
+		deliveryOrders.PATCH("/:id/status", middlewares.RoleMiddleware("owner", "admin", "cashier", "driver"), controllers.UpdateDeliveryOrderStatus)
+		deliveryOrders.GET("/:id/delivery-note.pdf", middlewares.RoleMiddleware("owner", "admin", "cashier", "driver"), controllers.GetDeliveryOrderNote)
+	}
+
+	// Quotations (owner, admin, cashier), conversion stats for owner & admin
+	quotations := r.Group("/quotations")
+	quotations.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.IdempotencyMiddleware())
+	{
+		quotations.GET("/", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.GetQuotations)
+		quotations.POST("/", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.CreateQuotation)
+		quotations.GET("/stats", middlewares.RoleMiddleware("owner", "admin"), controllers.GetQuotationStats)
+		quotations.GET("/:id", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.GetQuotationByID)
+		quotations.PUT("/:id", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.UpdateQuotation)
+		quotations.POST("/:id/cancel", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.CancelQuotation)
+		quotations.POST("/:id/convert", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.ConvertQuotation)
+		quotations.GET("/:id/quotation.pdf", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.GetQuotationPDF)
+	}
+
+	// Customers (owner, admin, cashier)
+	customers := r.Group("/customers")
+	customers.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin", "cashier"), middlewares.IdempotencyMiddleware())
+	{
+		customers.GET("/", controllers.GetCustomers)
+		customers.GET("/:id", controllers.GetCustomerByID)
+		customers.GET("/:id/history", controllers.GetCustomerHistory)
+		customers.GET("/:id/statement", controllers.GetCustomerStatement)
+		customers.GET("/:id/payments", controllers.GetCustomerPayments)
+		customers.POST("/:id/payments", controllers.ReceiveCustomerPayment)
+		customers.POST("/", controllers.CreateCustomer)
+		customers.PUT("/:id", controllers.UpdateCustomer)
+		customers.DELETE("/:id", middlewares.RoleMiddleware("owner", "admin"), controllers.DeleteCustomer)
+		customers.POST("/:id/addresses", controllers.AddCustomerAddress)
+		customers.PUT("/:id/addresses/:addressId", controllers.UpdateCustomerAddress)
+		customers.DELETE("/:id/addresses/:addressId", controllers.DeleteCustomerAddress)
+	}
+
+	// Promotions (managed by owner & admin, cashiers can look up what is running)
+	promotions := r.Group("/promotions")
+	promotions.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.IdempotencyMiddleware())
+	{
+		promotions.GET("/", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.GetPromotions)
+		promotions.GET("/:id", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.GetPromotionByID)
+		promotions.POST("/", middlewares.RoleMiddleware("owner", "admin"), controllers.CreatePromotion)
+		promotions.PUT("/:id", middlewares.RoleMiddleware("owner", "admin"), controllers.UpdatePromotion)
+		promotions.DELETE("/:id", middlewares.RoleMiddleware("owner", "admin"), controllers.DeletePromotion)
+	}
+
 	// Dashboard
 	dashboard := r.Group("/dashboard")
 	dashboard.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner"))
@@ -89,9 +166,23 @@ func RegisterRoutes(r *gin.Engine) {
 		dashboard.GET("/", controllers.GetDashboard)
 	}
 
+	// Reports (owner only)
+	reports := r.Group("/reports")
+	reports.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner"))
+	{
+		reports.GET("/stock-analysis", controllers.GetStockAnalysis)
+		reports.GET("/stock-analysis/export/csv", controllers.ExportStockAnalysis)
+		reports.GET("/payments", controllers.GetPaymentSummary)
+		reports.GET("/receivables/aging", controllers.GetReceivablesAging)
+		reports.GET("/promotions", controllers.GetPromotionCost)
+		reports.GET("/discounts", controllers.GetDiscountReport)
+		reports.GET("/tax", controllers.GetTaxReport)
+		reports.GET("/tax/efaktur/csv", controllers.ExportEFaktur)
+	}
+
 	// Attendance
 	attendance := r.Group("/attendance")
-	attendance.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter())
+	attendance.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.IdempotencyMiddleware())
 	{
 		attendance.GET("/", middlewares.RoleMiddleware("owner"), controllers.GetAttendances)
 		attendance.POST("/", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.CreateAttendance)
@@ -101,7 +192,7 @@ func RegisterRoutes(r *gin.Engine) {
 
 	// Users (owner & dev)
 	users := r.Group("/users")
-	users.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "dev"))
+	users.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "dev"), middlewares.IdempotencyMiddleware())
 	{
 		users.GET("/", controllers.GetUsers)
 		users.POST("/", controllers.CreateUser)
@@ -111,7 +202,7 @@ func RegisterRoutes(r *gin.Engine) {
 
 	// PO Bills (owner & admin only)
 	poBills := r.Group("/po-bills")
-	poBills.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin"))
+	poBills.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin"), middlewares.IdempotencyMiddleware())
 	{
 		poBills.POST("/", controllers.CreatePOBill)
 		poBills.GET("/", controllers.GetPOBills)
@@ -121,13 +212,55 @@ func RegisterRoutes(r *gin.Engine) {
 		poBills.DELETE("/:id", controllers.DeletePOBill)
 	}
 
+	// Suppliers (owner & admin only)
+	suppliers := r.Group("/suppliers")
+	suppliers.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin"), middlewares.IdempotencyMiddleware())
+	{
+		suppliers.GET("/", controllers.GetSuppliers)
+		suppliers.GET("/:id", controllers.GetSupplierByID)
+		suppliers.POST("/", controllers.CreateSupplier)
+		suppliers.PUT("/:id", controllers.UpdateSupplier)
+		suppliers.DELETE("/:id", controllers.DeleteSupplier)
+	}
+
+	// Vehicles (owner & admin manage, cashiers plan runs)
+	vehicles := r.Group("/vehicles")
+	vehicles.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.IdempotencyMiddleware())
+	{
+		vehicles.GET("/", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.GetVehicles)
+		vehicles.GET("/:id", middlewares.RoleMiddleware("owner", "admin", "cashier"), controllers.GetVehicleByID)
+		vehicles.POST("/", middlewares.RoleMiddleware("owner", "admin"), controllers.CreateVehicle)
+		vehicles.PUT("/:id", middlewares.RoleMiddleware("owner", "admin"), controllers.UpdateVehicle)
+		vehicles.DELETE("/:id", middlewares.RoleMiddleware("owner", "admin"), controllers.DeleteVehicle)
+	}
+
+	// Purchase Orders & reorder suggestions (owner & admin only)
+	purchaseOrders := r.Group("/purchase-orders")
+	purchaseOrders.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin"), middlewares.IdempotencyMiddleware())
+	{
+		purchaseOrders.GET("/suggestions", controllers.GetReorderSuggestions)
+		purchaseOrders.POST("/", controllers.CreatePurchaseOrder)
+		purchaseOrders.POST("/from-suggestions", controllers.CreatePurchaseOrderFromSuggestions)
+		purchaseOrders.GET("/", controllers.GetPurchaseOrders)
+		purchaseOrders.GET("/:id", controllers.GetPurchaseOrderByID)
+		purchaseOrders.PUT("/:id/status", controllers.UpdatePurchaseOrderStatus)
+	}
+
 	// Cash Sessions (owner, admin, cashier)
 	cash := r.Group("/cash-sessions")
-	cash.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin", "cashier"))
+	cash.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin", "cashier"), middlewares.IdempotencyMiddleware())
 	{
 		cash.GET("/current", controllers.GetCurrentCashSession)
 		cash.GET("/history", controllers.GetCashSessionHistory)
 		cash.POST("/open", controllers.OpenCashSession)
 		cash.POST("/close", controllers.CloseCashSession)
 	}
+
+	// Offline sync for the mobile POS (owner, admin, cashier)
+	sync := r.Group("/sync")
+	sync.Use(middlewares.AuthMiddleware(), middlewares.GeneralRateLimiter(), middlewares.RoleMiddleware("owner", "admin", "cashier"), middlewares.IdempotencyMiddleware())
+	{
+		sync.GET("/items", controllers.GetSyncItems)
+		sync.POST("/transactions", controllers.UploadSyncTransactions)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BackorderService interface {
+	GetBackorders(filter dtos.BackorderFilter) (*dtos.BackorderListResponse, error)
+	FulfillBackorder(id uint, userID *uint) (*models.Backorder, error)
+	CancelBackorder(id uint) (*models.Backorder, error)
+	GetStockShortages(filter dtos.StockShortageFilter) (*dtos.StockShortageListResponse, error)
+}
+
+type backorderService struct{}
+
+func NewBackorderService() BackorderService {
+	return &backorderService{}
+}
+
+func (s *backorderService) GetBackorders(filter dtos.BackorderFilter) (*dtos.BackorderListResponse, error) {
+	var backorders []models.Backorder
+	var total int64
+
+	db := config.DB.Model(&models.Backorder{})
+	if filter.ItemID != 0 {
+		db = db.Where("item_id = ?", filter.ItemID)
+	}
+	if filter.TransactionID != 0 {
+		db = db.Where("transaction_id = ?", filter.TransactionID)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+	offset := (filter.Page - 1) * filter.Limit
+
+	if err := db.Preload("Item").
+		Order("created_at ASC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&backorders).Error; err != nil {
+		return nil, err
+	}
+
+	return &dtos.BackorderListResponse{
+		Data:       backorders,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+// FulfillBackorder hands over the backordered quantity once stock has arrived,
+// posting the rest of the sale to the ledger.
+func (s *backorderService) FulfillBackorder(id uint, userID *uint) (*models.Backorder, error) {
+	var backorder models.Backorder
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&backorder, id).Error; err != nil {
+			return errors.New("backorder not found")
+		}
+		if backorder.Status != "open" {
+			return errors.New("only open backorders can be fulfilled")
+		}
+
+		var item models.Item
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, backorder.ItemID).Error; err != nil {
+			return errors.New("item not found")
+		}
+		if item.Stock < backorder.Quantity {
+			return fmt.Errorf("insufficient stock for item '%s' (current: %d, required: %d)", item.Name, item.Stock, backorder.Quantity)
+		}
+
+		item.Stock -= backorder.Quantity
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+
+		var transaction models.Transaction
+		if err := tx.Select("id", "number").First(&transaction, backorder.TransactionID).Error; err != nil {
+			return err
+		}
+		ref := transactionNumber(&transaction) + " (BACKORDER)"
+		if err := logTransactionStockChange(tx, &transaction, item.ID, -backorder.Quantity, "sale", ref, userID, "Backorder fulfilled"); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		backorder.Status = "fulfilled"
+		backorder.FulfilledAt = &now
+		return tx.Save(&backorder).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &backorder, nil
+}
+
+func (s *backorderService) CancelBackorder(id uint) (*models.Backorder, error) {
+	var backorder models.Backorder
+	if err := config.DB.First(&backorder, id).Error; err != nil {
+		return nil, errors.New("backorder not found")
+	}
+	if backorder.Status != "open" {
+		return nil, errors.New("only open backorders can be cancelled")
+	}
+
+	backorder.Status = "cancelled"
+	if err := config.DB.Save(&backorder).Error; err != nil {
+		return nil, err
+	}
+
+	return &backorder, nil
+}
+
+func (s *backorderService) GetStockShortages(filter dtos.StockShortageFilter) (*dtos.StockShortageListResponse, error) {
+	var shortages []models.StockShortage
+	var total int64
+
+	db := config.DB.Model(&models.StockShortage{})
+	if filter.ItemID != 0 {
+		db = db.Where("item_id = ?", filter.ItemID)
+	}
+	if filter.Policy != "" {
+		db = db.Where("policy = ?", filter.Policy)
+	}
+	if filter.StartDate != "" {
+		db = db.Where("created_at >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		db = db.Where("created_at <= ?", filter.EndDate+" 23:59:59")
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+	offset := (filter.Page - 1) * filter.Limit
+
+	if err := db.Preload("User").Preload("Item").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&shortages).Error; err != nil {
+		return nil, err
+	}
+
+	return &dtos.StockShortageListResponse{
+		Data:       shortages,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+// reduceOpenBackorders takes up to quantity off a sale's open backorders for an item, newest
+// first, and returns how much it took off. A backorder reduced to nothing is cancelled.
+func reduceOpenBackorders(tx *gorm.DB, transactionID uint, itemID uint, quantity int) (int, error) {
+	var backorders []models.Backorder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("transaction_id = ? AND item_id = ? AND status = ?", transactionID, itemID, "open").
+		Order("id DESC").
+		Find(&backorders).Error; err != nil {
+		return 0, err
+	}
+
+	reduced := 0
+	for _, backorder := range backorders {
+		if reduced == quantity {
+			break
+		}
+
+		take := min(backorder.Quantity, quantity-reduced)
+		reduced += take
+		update := map[string]any{"quantity": backorder.Quantity - take}
+		if take == backorder.Quantity {
+			update = map[string]any{"status": "cancelled"}
+		}
+		if err := tx.Model(&backorder).Updates(update).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	return reduced, nil
+}
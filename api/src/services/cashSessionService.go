@@ -19,6 +19,12 @@ type CashSessionService interface {
 
 type cashSessionService struct{}
 
+// paidStatuses are the statuses of sales whose tenders were taken, refunded ones included
+var paidStatuses = []string{"completed", "partially_refunded", "refunded"}
+
+// drawerStatuses adds sales still being paid off, their down payments are in the drawer too
+var drawerStatuses = append([]string{"partially_paid"}, paidStatuses...)
+
 func NewCashSessionService() CashSessionService {
 	return &cashSessionService{}
 }
@@ -70,35 +76,66 @@ func (s *cashSessionService) CloseCashSession(input dtos.CloseCashSessionInput,
 	}
 
 	var result struct {
-		TotalCashIn float64
-		TotalChange float64
+		TotalCashIn     float64
+		TotalChange     float64
+		TotalRefundCash float64
 	}
 
-	config.DB.Model(&models.Transaction{}).
-		Select(
-			"COALESCE(SUM(payment), 0) AS total_cash_in, " +
-				"COALESCE(SUM(`change`), 0) AS total_change",
-		).
+	// Only cash tenders taken on this drawer count, QRIS/debit/credit parts of a split sale
+	// and other cashiers' sales made at the same time do not
+	config.DB.Model(&models.TransactionPayment{}).
+		Select("COALESCE(SUM(transaction_payments.amount), 0) AS total_cash_in").
+		Joins("JOIN transactions ON transactions.id = transaction_payments.transaction_id").
 		Where(
-			"payment_type = ? AND status = ? AND created_at BETWEEN ? AND ?",
-			"cash", "completed", session.OpenedAt, time.Now(),
+			"transaction_payments.method = ? AND transaction_payments.cash_session_id = ? AND transactions.status IN ?",
+			"cash", session.ID, drawerStatuses,
 		).
 		Scan(&result)
 
+	// Change always comes out of the drawer that took the last payment, whatever the other tenders were
+	config.DB.Model(&models.Transaction{}).
+		Select("COALESCE(SUM(`change`), 0) AS total_change").
+		Where("cash_session_id = ? AND status IN ?", session.ID, drawerStatuses).
+		Scan(&result.TotalChange)
+
+	// Refunds are their own documents now, the original sale's cash stays counted above.
+	// The part credited against an unpaid balance never left the drawer.
+	config.DB.Model(&models.Refund{}).
+		Select("COALESCE(SUM(amount - credited_amount), 0)").
+		Where("method = ? AND cash_session_id = ?", "cash", session.ID).
+		Scan(&result.TotalRefundCash)
+
+	// Customers paying off credit sales in cash
+	var collected float64
+	config.DB.Model(&models.CustomerPayment{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("method = ? AND cash_session_id = ?", "cash", session.ID).
+		Scan(&collected)
+	result.TotalCashIn += collected
+
+	// Exchanges where the customer got money back pay out of the drawer like a refund
+	var exchangePayout float64
+	config.DB.Model(&models.Exchange{}).
+		Select("COALESCE(SUM(-difference), 0)").
+		Where("difference < 0 AND method = ? AND cash_session_id = ?", "cash", session.ID).
+		Scan(&exchangePayout)
+	result.TotalRefundCash += exchangePayout
+
+	now := time.Now()
 	expected := session.OpeningCash +
 		result.TotalCashIn -
-		result.TotalChange
+		result.TotalChange -
+		result.TotalRefundCash
 
 	diff := input.ClosingCash - expected
 
 	session.TotalCashIn = result.TotalCashIn
 	session.TotalChange = result.TotalChange
+	session.TotalRefundCash = result.TotalRefundCash
 	session.ExpectedCash = expected
 	session.ClosingCash = &input.ClosingCash
 	session.Difference = &diff
 	session.Status = "closed"
-
-	now := time.Now()
 	session.ClosedAt = &now
 
 	if err := config.DB.Save(&session).Error; err != nil {
@@ -150,3 +187,33 @@ func (s *cashSessionService) GetSessionHistory(filter dtos.CashSessionHistoryFil
 		TotalPages: int(float64(total)/float64(filter.PageSize) + 0.99),
 	}, nil
 }
+
+// openCashSessionID returns the user's open cash session, nil when the drawer is not open
+func openCashSessionID(tx *gorm.DB, userID *uint) (*uint, error) {
+	if userID == nil {
+		return nil, nil
+	}
+
+	var session models.CashSession
+	if err := tx.Where("user_id = ? AND status = 'open'", *userID).Limit(1).Find(&session).Error; err != nil {
+		return nil, err
+	}
+	if session.ID == 0 {
+		return nil, nil
+	}
+	return &session.ID, nil
+}
+
+// bookOnCashSession links a sale, and the tenders just taken for it, to the user's open drawer
+func bookOnCashSession(tx *gorm.DB, transaction *models.Transaction, payments []models.TransactionPayment, userID *uint) error {
+	sessionID, err := openCashSessionID(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	transaction.CashSessionID = sessionID
+	for i := range payments {
+		payments[i].CashSessionID = sessionID
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"errors"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CustomerService interface {
+	GetCustomers(filter dtos.CustomerFilter) (*dtos.CustomerListResponse, error)
+	GetCustomerByID(id uint) (*models.Customer, error)
+	CreateCustomer(input dtos.CreateCustomerInput) (*models.Customer, error)
+	UpdateCustomer(id uint, input dtos.UpdateCustomerInput) (*models.Customer, error)
+	DeleteCustomer(id uint) error
+	AddAddress(customerID uint, input dtos.CustomerAddressInput) (*models.CustomerAddress, error)
+	UpdateAddress(customerID, addressID uint, input dtos.CustomerAddressInput) (*models.CustomerAddress, error)
+	DeleteAddress(customerID, addressID uint) error
+	GetHistory(id uint, filter dtos.CustomerHistoryFilter) (*dtos.CustomerHistoryResponse, error)
+}
+
+type customerService struct{}
+
+func NewCustomerService() CustomerService {
+	return &customerService{}
+}
+
+func (s *customerService) GetCustomers(filter dtos.CustomerFilter) (*dtos.CustomerListResponse, error) {
+	var customers []models.Customer
+	var total int64
+
+	db := config.DB.Model(&models.Customer{})
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		// Digits match the phone number too, so "0812 34" finds 081234...
+		if digits := normalizePhone(search); len(digits) >= 3 {
+			db = db.Where("LOWER(name) LIKE ? OR phone LIKE ?", "%"+strings.ToLower(search)+"%", "%"+digits+"%")
+		} else {
+			for _, term := range strings.Fields(strings.ToLower(search)) {
+				db = db.Where("LOWER(name) LIKE ?", "%"+term+"%")
+			}
+		}
+	}
+	if filter.Group != "" {
+		db = db.Where("customer_group = ?", filter.Group)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+	offset := (filter.Page - 1) * filter.Limit
+
+	if err := db.Preload("Addresses").
+		Order("name ASC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&customers).Error; err != nil {
+		return nil, err
+	}
+
+	return &dtos.CustomerListResponse{
+		Data:       customers,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+func (s *customerService) GetCustomerByID(id uint) (*models.Customer, error) {
+	var customer models.Customer
+	if err := config.DB.Preload("Addresses").First(&customer, id).Error; err != nil {
+		return nil, errors.New("customer not found")
+	}
+	return &customer, nil
+}
+
+func (s *customerService) CreateCustomer(input dtos.CreateCustomerInput) (*models.Customer, error) {
+	phone, err := uniqueCustomerPhone(input.Phone, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	customer := models.Customer{
+		Name:  strings.TrimSpace(input.Name),
+		Phone: phone,
+		NPWP:  input.NPWP,
+		Group: "retail",
+		Note:  input.Note,
+	}
+	if input.Group != nil {
+		customer.Group = *input.Group
+	}
+	if input.CreditLimit != nil {
+		customer.CreditLimit = *input.CreditLimit
+	}
+	customer.PaymentTermDays = 30
+	if input.PaymentTermDays != nil {
+		customer.PaymentTermDays = *input.PaymentTermDays
+	}
+
+	hasDefault := false
+	for _, address := range input.Addresses {
+		isDefault := address.IsDefault && !hasDefault
+		hasDefault = hasDefault || isDefault
+		customer.Addresses = append(customer.Addresses, models.CustomerAddress{
+			Label:     address.Label,
+			Address:   address.Address,
+			IsDefault: isDefault,
+		})
+	}
+	if !hasDefault && len(customer.Addresses) > 0 {
+		customer.Addresses[0].IsDefault = true
+	}
+
+	if err := config.DB.Create(&customer).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (s *customerService) UpdateCustomer(id uint, input dtos.UpdateCustomerInput) (*models.Customer, error) {
+	var customer models.Customer
+	if err := config.DB.First(&customer, id).Error; err != nil {
+		return nil, errors.New("customer not found")
+	}
+
+	if input.Name != nil {
+		customer.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Phone != nil {
+		phone, err := uniqueCustomerPhone(input.Phone, customer.ID)
+		if err != nil {
+			return nil, err
+		}
+		customer.Phone = phone
+	}
+	if input.NPWP != nil {
+		customer.NPWP = input.NPWP
+	}
+	if input.Group != nil {
+		customer.Group = *input.Group
+	}
+	if input.Note != nil {
+		customer.Note = input.Note
+	}
+	if input.CreditLimit != nil {
+		customer.CreditLimit = *input.CreditLimit
+	}
+	if input.PaymentTermDays != nil {
+		customer.PaymentTermDays = *input.PaymentTermDays
+	}
+
+	if err := config.DB.Save(&customer).Error; err != nil {
+		return nil, err
+	}
+	return s.GetCustomerByID(customer.ID)
+}
+
+func (s *customerService) DeleteCustomer(id uint) error {
+	var customer models.Customer
+	if err := config.DB.First(&customer, id).Error; err != nil {
+		return errors.New("customer not found")
+	}
+	return config.DB.Delete(&customer).Error
+}
+
+func (s *customerService) AddAddress(customerID uint, input dtos.CustomerAddressInput) (*models.CustomerAddress, error) {
+	var customer models.Customer
+	if err := config.DB.First(&customer, customerID).Error; err != nil {
+		return nil, errors.New("customer not found")
+	}
+
+	address := models.CustomerAddress{
+		CustomerID: customerID,
+		Label:      input.Label,
+		Address:    input.Address,
+		IsDefault:  input.IsDefault,
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.CustomerAddress{}).Where("customer_id = ?", customerID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			address.IsDefault = true
+		}
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, customerID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&address).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+func (s *customerService) UpdateAddress(customerID, addressID uint, input dtos.CustomerAddressInput) (*models.CustomerAddress, error) {
+	var address models.CustomerAddress
+	if err := config.DB.Where("customer_id = ?", customerID).First(&address, addressID).Error; err != nil {
+		return nil, errors.New("address not found")
+	}
+
+	address.Label = input.Label
+	address.Address = input.Address
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if input.IsDefault && !address.IsDefault {
+			if err := clearDefaultAddress(tx, customerID); err != nil {
+				return err
+			}
+			address.IsDefault = true
+		}
+		return tx.Save(&address).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+func (s *customerService) DeleteAddress(customerID, addressID uint) error {
+	var address models.CustomerAddress
+	if err := config.DB.Where("customer_id = ?", customerID).First(&address, addressID).Error; err != nil {
+		return errors.New("address not found")
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		// Promote the oldest remaining address so the customer keeps a default
+		var next models.CustomerAddress
+		if err := tx.Where("customer_id = ?", customerID).Order("id ASC").Limit(1).Find(&next).Error; err != nil {
+			return err
+		}
+		if next.ID == 0 {
+			return nil
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+}
+
+// GetHistory lists a customer's transactions with lifetime totals. Lifetime value
+// counts every paid sale (refunded ones included) minus the refunds made on them.
+func (s *customerService) GetHistory(id uint, filter dtos.CustomerHistoryFilter) (*dtos.CustomerHistoryResponse, error) {
+	customer, err := s.GetCustomerByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	paid := config.DB.Model(&models.Transaction{}).
+		Where("customer_id = ? AND status IN ?", customer.ID, paidStatuses)
+
+	var totals struct {
+		TransactionCount int64
+		TotalSpent       float64
+		FirstPurchaseAt  *time.Time
+		LastPurchaseAt   *time.Time
+	}
+	if err := paid.Session(&gorm.Session{}).
+		Select("COUNT(*) AS transaction_count, COALESCE(SUM(total), 0) AS total_spent, " +
+			"MIN(created_at) AS first_purchase_at, MAX(created_at) AS last_purchase_at").
+		Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+
+	var refunded float64
+	if err := config.DB.Model(&models.Refund{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("transaction_id IN (?)", paid.Session(&gorm.Session{}).Select("id")).
+		Scan(&refunded).Error; err != nil {
+		return nil, err
+	}
+
+	outstanding, err := customerOutstanding(config.DB, customer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := dtos.CustomerStats{
+		TransactionCount: totals.TransactionCount,
+		TotalSpent:       totals.TotalSpent,
+		RefundedAmount:   refunded,
+		LifetimeValue:    totals.TotalSpent - refunded,
+		Outstanding:      outstanding,
+		FirstPurchaseAt:  totals.FirstPurchaseAt,
+		LastPurchaseAt:   totals.LastPurchaseAt,
+	}
+	if stats.TransactionCount > 0 {
+		stats.AverageOrderValue = stats.LifetimeValue / float64(stats.TransactionCount)
+	}
+
+	db := config.DB.Model(&models.Transaction{}).Where("customer_id = ?", customer.ID)
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+	offset := (filter.Page - 1) * filter.Limit
+
+	var transactions []models.Transaction
+	if err := db.Preload("Items.Item").Preload("Payments").Preload("Refunds").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	return &dtos.CustomerHistoryResponse{
+		Customer:   *customer,
+		Stats:      stats,
+		Data:       transactions,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+// uniqueCustomerPhone normalises a phone number and checks no other customer has it.
+// An empty phone clears the number.
+func uniqueCustomerPhone(phone *string, customerID uint) (*string, error) {
+	if phone == nil {
+		return nil, nil
+	}
+	normalized := normalizePhone(*phone)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	var count int64
+	if err := config.DB.Model(&models.Customer{}).
+		Where("phone = ? AND id != ?", normalized, customerID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("customer with this phone already exists")
+	}
+	return &normalized, nil
+}
+
+// normalizePhone keeps digits only and writes Indonesian numbers with a leading 0 (+62 812... -> 0812...)
+func normalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(digits, "62") && len(digits) > 9 {
+		digits = "0" + digits[2:]
+	}
+	return digits
+}
+
+func clearDefaultAddress(tx *gorm.DB, customerID uint) error {
+	return tx.Model(&models.CustomerAddress{}).
+		Where("customer_id = ? AND is_default = ?", customerID, true).
+		Update("is_default", false).Error
+}
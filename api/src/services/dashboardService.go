@@ -40,12 +40,12 @@ func (s *dashboardService) GetDashboardStats() (*dtos.DashboardStats, error) {
 	}
 	if err := config.DB.Model(&models.TransactionItem{}).
 		Select(
-			"COALESCE(SUM(transaction_items.quantity * (transaction_items.price - items.buy_price)), 0) AS profit, "+
-				"COALESCE(SUM(transaction_items.quantity * transaction_items.price), 0) AS omzet",
+			"COALESCE(SUM("+soldLineRevenueSQL+" - "+soldLineCostSQL+"), 0) AS profit, "+
+				"COALESCE(SUM("+soldLineRevenueSQL+"), 0) AS omzet",
 		).
 		Joins("JOIN transactions ON transactions.id = transaction_items.transaction_id").
 		Joins("JOIN items ON items.id = transaction_items.item_id").
-		Where("transactions.status = ? AND transactions.created_at >= ? AND transactions.created_at < ? AND transactions.deleted_at IS NULL", "completed", todayStart, todayEnd).
+		Where("transactions.status IN ? AND transactions.created_at >= ? AND transactions.created_at < ? AND transactions.deleted_at IS NULL", soldStatuses, todayStart, todayEnd).
 		Scan(&todayResult).Error; err != nil {
 		return nil, err
 	}
@@ -59,12 +59,12 @@ func (s *dashboardService) GetDashboardStats() (*dtos.DashboardStats, error) {
 	}
 	if err := config.DB.Model(&models.TransactionItem{}).
 		Select(
-			"COALESCE(SUM(transaction_items.quantity * (transaction_items.price - items.buy_price)), 0) AS profit, "+
-				"COALESCE(SUM(transaction_items.quantity * transaction_items.price), 0) AS omzet",
+			"COALESCE(SUM("+soldLineRevenueSQL+" - "+soldLineCostSQL+"), 0) AS profit, "+
+				"COALESCE(SUM("+soldLineRevenueSQL+"), 0) AS omzet",
 		).
 		Joins("JOIN transactions ON transactions.id = transaction_items.transaction_id").
 		Joins("JOIN items ON items.id = transaction_items.item_id").
-		Where("transactions.status = ? AND transactions.created_at >= ? AND transactions.created_at < ? AND transactions.deleted_at IS NULL", "completed", monthStart, monthEnd).
+		Where("transactions.status IN ? AND transactions.created_at >= ? AND transactions.created_at < ? AND transactions.deleted_at IS NULL", soldStatuses, monthStart, monthEnd).
 		Scan(&monthlyResult).Error; err != nil {
 		return nil, err
 	}
@@ -73,7 +73,7 @@ func (s *dashboardService) GetDashboardStats() (*dtos.DashboardStats, error) {
 
 	// Count today's transactions
 	if err := config.DB.Model(&models.Transaction{}).
-		Where("status = ? AND created_at >= ? AND created_at < ?", "completed", todayStart, todayEnd).
+		Where("status IN ? AND created_at >= ? AND created_at < ?", soldStatuses, todayStart, todayEnd).
 		Count(&todayTransactions).Error; err != nil {
 		return nil, err
 	}
@@ -85,10 +85,10 @@ func (s *dashboardService) GetDashboardStats() (*dtos.DashboardStats, error) {
 
 	// Get top selling items (top 5) using JOIN to fetch names directly in a single query
 	if err := config.DB.Model(&models.TransactionItem{}).
-		Select("transaction_items.item_id, items.name, SUM(transaction_items.quantity) as quantity").
+		Select("transaction_items.item_id, items.name, SUM(transaction_items.quantity - transaction_items.refunded_quantity) as quantity").
 		Joins("JOIN transactions ON transactions.id = transaction_items.transaction_id").
 		Joins("JOIN items ON items.id = transaction_items.item_id").
-		Where("transactions.status = ? AND transactions.deleted_at IS NULL", "completed").
+		Where("transactions.status IN ? AND transactions.deleted_at IS NULL", soldStatuses).
 		Group("transaction_items.item_id, items.name").
 		Order("quantity desc").
 		Limit(5).
@@ -96,13 +96,46 @@ func (s *dashboardService) GetDashboardStats() (*dtos.DashboardStats, error) {
 		return nil, err
 	}
 
+	// Balances still to be paid on down-payment sales
+	var layaways struct {
+		Count   int64
+		Balance float64
+	}
+	if err := config.DB.Model(&models.Transaction{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(balance_due), 0) AS balance").
+		Where("status = ?", "partially_paid").
+		Scan(&layaways).Error; err != nil {
+		return nil, err
+	}
+
+	var overdueInstallments float64
+	if err := config.DB.Model(&models.PaymentScheduleEntry{}).
+		Select("COALESCE(SUM(payment_schedule_entries.amount - payment_schedule_entries.paid_amount), 0)").
+		Joins("JOIN transactions ON transactions.id = payment_schedule_entries.transaction_id").
+		Where("transactions.status = ? AND transactions.deleted_at IS NULL AND payment_schedule_entries.due_date < ?", "partially_paid", todayStart.Format("2006-01-02")).
+		Scan(&overdueInstallments).Error; err != nil {
+		return nil, err
+	}
+
+	var receivables float64
+	if err := config.DB.Model(&models.Transaction{}).
+		Select("COALESCE(SUM(amount_due), 0)").
+		Where("amount_due > 0 AND status IN ?", paidStatuses).
+		Scan(&receivables).Error; err != nil {
+		return nil, err
+	}
+
 	return &dtos.DashboardStats{
-		TodayProfit:       todayProfit,
-		MonthlyProfit:     monthlyProfit,
-		TodayOmzet:        todayOmzet,
-		MonthlyOmzet:      monthlyOmzet,
-		TodayTransactions: todayTransactions,
-		LowStock:          lowStock,
-		TopSellingItems:   topItems,
+		TodayProfit:         todayProfit,
+		MonthlyProfit:       monthlyProfit,
+		TodayOmzet:          todayOmzet,
+		MonthlyOmzet:        monthlyOmzet,
+		TodayTransactions:   todayTransactions,
+		LowStock:            lowStock,
+		TopSellingItems:     topItems,
+		OpenLayaways:        layaways.Count,
+		LayawayBalance:      roundMoney(layaways.Balance),
+		OverdueInstallments: roundMoney(overdueInstallments),
+		Receivables:         roundMoney(receivables),
 	}, nil
 }
@@ -0,0 +1,765 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"kd-api/src/utils/common"
+	"kd-api/src/utils/log"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DeliveryOrderService interface {
+	CreateDeliveryOrder(input dtos.CreateDeliveryOrderInput, userID *uint) (*models.DeliveryOrder, []string, error)
+	GetDeliveryOrders(filter dtos.DeliveryOrderFilter) (*dtos.DeliveryOrderListResponse, error)
+	GetDeliveryOrderByID(id uint) (*models.DeliveryOrder, error)
+	UpdateDeliveryOrder(id uint, input dtos.UpdateDeliveryOrderInput) (*models.DeliveryOrder, []string, error)
+	UpdateDeliveryStatus(id uint, input dtos.DeliveryStatusInput, userID *uint, role string, clientIP string) (*models.DeliveryOrder, []string, error)
+	GetDriverDay(driverID uint, date string) ([]models.DeliveryOrder, error)
+	GetSchedule(date string) (*dtos.DeliveryScheduleResponse, error)
+	AssignVehicle(input dtos.AssignVehicleInput) (*dtos.VehicleRun, error)
+}
+
+type deliveryOrderService struct{}
+
+// openDeliveryStatuses are trips that have not ended, their goods are spoken for
+var openDeliveryStatuses = []string{"scheduled", "loaded", "on_the_way"}
+
+// deliveryTransitions lists where an open delivery order may move next
+var deliveryTransitions = map[string][]string{
+	"scheduled":  {"loaded", "failed"},
+	"loaded":     {"on_the_way", "failed"},
+	"on_the_way": {"delivered", "failed"},
+}
+
+func NewDeliveryOrderService() DeliveryOrderService {
+	return &deliveryOrderService{}
+}
+
+// CreateDeliveryOrder schedules a trip for part or all of a deliver sale. A line can only
+// be put on a trip for what is neither delivered, refunded nor already on an open trip.
+// Warnings point out items without a weight or volume and an overloaded vehicle.
+func (s *deliveryOrderService) CreateDeliveryOrder(input dtos.CreateDeliveryOrderInput, userID *uint) (*models.DeliveryOrder, []string, error) {
+	scheduledDate, err := time.ParseInLocation("2006-01-02", input.ScheduledDate, time.Local)
+	if err != nil {
+		return nil, nil, errors.New("invalid scheduled_date, use YYYY-MM-DD")
+	}
+
+	var order models.DeliveryOrder
+	var warnings []string
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var transaction models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			Preload("Customer.Addresses").
+			Preload("CustomerAddress").
+			First(&transaction, input.TransactionID).Error; err != nil {
+			return errors.New("transaction not found")
+		}
+		if !slices.Contains(deliverableStatuses, transaction.Status) || transaction.TransactionType != "deliver" {
+			return errors.New("only completed deliver transactions can get delivery orders")
+		}
+		if transaction.DeliveredAt != nil {
+			return errors.New("transaction already delivered")
+		}
+		if err := validateDriver(tx, input.DriverID); err != nil {
+			return err
+		}
+		if err := validateVehicle(tx, input.VehicleID); err != nil {
+			return err
+		}
+
+		onTrips, err := openDeliveryQuantities(tx, transaction.ID)
+		if err != nil {
+			return err
+		}
+		left := make(map[uint]int)
+		for _, tItem := range undeliveredItems(transaction.Items) {
+			if quantity := tItem.Quantity - onTrips[tItem.ID]; quantity > 0 {
+				left[tItem.ID] = quantity
+			}
+		}
+
+		// Default to everything that is still waiting for a trip
+		requested := input.Lines
+		if len(requested) == 0 {
+			for _, tItem := range transaction.Items {
+				if quantity := left[tItem.ID]; quantity > 0 {
+					requested = append(requested, dtos.DeliveryOrderLineInput{TransactionItemID: tItem.ID, Quantity: quantity})
+				}
+			}
+			if len(requested) == 0 {
+				return errors.New("nothing left to deliver")
+			}
+		}
+
+		var lines []models.DeliveryOrderLine
+		for _, line := range requested {
+			index := slices.IndexFunc(transaction.Items, func(tItem models.TransactionItem) bool {
+				return tItem.ID == line.TransactionItemID
+			})
+			if index < 0 {
+				return fmt.Errorf("transaction item %d not found", line.TransactionItemID)
+			}
+			if line.Quantity > left[line.TransactionItemID] {
+				return fmt.Errorf("delivery quantity for line %d exceeds what is left to deliver (%d)", line.TransactionItemID, left[line.TransactionItemID])
+			}
+			left[line.TransactionItemID] -= line.Quantity
+			lines = append(lines, models.DeliveryOrderLine{
+				TransactionItemID: line.TransactionItemID,
+				ItemID:            transaction.Items[index].ItemID,
+				Quantity:          line.Quantity,
+			})
+		}
+
+		// Contact and address come from the customer unless typed in
+		var recipient, address string
+		var phone *string
+		if transaction.Customer != nil {
+			recipient = transaction.Customer.Name
+			phone = transaction.Customer.Phone
+		}
+		address = transactionAddress(transaction)
+		if input.RecipientName != nil {
+			recipient = strings.TrimSpace(*input.RecipientName)
+		}
+		if input.Phone != nil {
+			phone = input.Phone
+		}
+		if input.Address != nil {
+			address = strings.TrimSpace(*input.Address)
+		}
+		if recipient == "" {
+			return errors.New("recipient_name is required")
+		}
+		if address == "" {
+			return errors.New("delivery address is required")
+		}
+
+		weight, volume, unmeasured, err := deliveryLoad(tx, lines)
+		if err != nil {
+			return err
+		}
+		warnings = unmeasured
+
+		number, err := nextDocumentNumber(tx, config.DocDeliveryOrder, time.Now())
+		if err != nil {
+			return err
+		}
+
+		order = models.DeliveryOrder{
+			Number:        number,
+			TransactionID: transaction.ID,
+			Status:        "scheduled",
+			ScheduledDate: scheduledDate,
+			RecipientName: recipient,
+			Phone:         phone,
+			Address:       address,
+			VehicleID:     input.VehicleID,
+			DriverID:      input.DriverID,
+			TotalWeightKg: weight,
+			TotalVolumeM3: volume,
+			DeliveryFee:   input.DeliveryFee,
+			Note:          input.Note,
+			CreatedByID:   userID,
+			Lines:         lines,
+		}
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+
+		overload, err := vehicleRunWarnings(tx, order.VehicleID, order.ScheduledDate)
+		if err != nil {
+			return err
+		}
+		warnings = append(warnings, overload...)
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	created, err := s.GetDeliveryOrderByID(order.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, warnings, nil
+}
+
+func (s *deliveryOrderService) GetDeliveryOrders(filter dtos.DeliveryOrderFilter) (*dtos.DeliveryOrderListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+
+	db := config.DB.Model(&models.DeliveryOrder{})
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.Date != "" {
+		db = db.Where("scheduled_date = ?", filter.Date)
+	}
+	if filter.DriverID != nil {
+		db = db.Where("driver_id = ?", *filter.DriverID)
+	}
+	if filter.TransactionID != nil {
+		db = db.Where("transaction_id = ?", *filter.TransactionID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var orders []models.DeliveryOrder
+	if err := db.Preload("Lines.Item").
+		Preload("Driver").
+		Preload("Vehicle").
+		Order("scheduled_date DESC, id DESC").
+		Limit(filter.Limit).
+		Offset((filter.Page - 1) * filter.Limit).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	return &dtos.DeliveryOrderListResponse{
+		Data:       orders,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+func (s *deliveryOrderService) GetDeliveryOrderByID(id uint) (*models.DeliveryOrder, error) {
+	var order models.DeliveryOrder
+	if err := config.DB.
+		Preload("Lines.Item").
+		Preload("Driver").
+		Preload("Vehicle").
+		Preload("ProofImage").
+		Preload("Transaction").
+		First(&order, id).Error; err != nil {
+		return nil, errors.New("delivery order not found")
+	}
+	return &order, nil
+}
+
+// UpdateDeliveryOrder reschedules or reassigns a trip that has not left yet
+func (s *deliveryOrderService) UpdateDeliveryOrder(id uint, input dtos.UpdateDeliveryOrderInput) (*models.DeliveryOrder, []string, error) {
+	var warnings []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.DeliveryOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+			return errors.New("delivery order not found")
+		}
+		if order.Status != "scheduled" && order.Status != "loaded" {
+			return errors.New("only scheduled or loaded delivery orders can be changed")
+		}
+
+		if input.ScheduledDate != nil {
+			scheduledDate, err := time.ParseInLocation("2006-01-02", *input.ScheduledDate, time.Local)
+			if err != nil {
+				return errors.New("invalid scheduled_date, use YYYY-MM-DD")
+			}
+			order.ScheduledDate = scheduledDate
+		}
+		if input.RecipientName != nil {
+			if strings.TrimSpace(*input.RecipientName) == "" {
+				return errors.New("recipient_name is required")
+			}
+			order.RecipientName = strings.TrimSpace(*input.RecipientName)
+		}
+		if input.Phone != nil {
+			order.Phone = input.Phone
+		}
+		if input.Address != nil {
+			if strings.TrimSpace(*input.Address) == "" {
+				return errors.New("delivery address is required")
+			}
+			order.Address = strings.TrimSpace(*input.Address)
+		}
+		if input.VehicleID != nil {
+			if *input.VehicleID == 0 {
+				order.VehicleID = nil
+			} else {
+				if err := validateVehicle(tx, input.VehicleID); err != nil {
+					return err
+				}
+				order.VehicleID = input.VehicleID
+			}
+		}
+		if input.DriverID != nil {
+			if *input.DriverID == 0 {
+				order.DriverID = nil
+			} else {
+				if err := validateDriver(tx, input.DriverID); err != nil {
+					return err
+				}
+				order.DriverID = input.DriverID
+			}
+		}
+		if input.DeliveryFee != nil {
+			order.DeliveryFee = *input.DeliveryFee
+		}
+		if input.Note != nil {
+			order.Note = input.Note
+		}
+
+		if err := tx.Save(&order).Error; err != nil {
+			return err
+		}
+
+		var err error
+		warnings, err = vehicleRunWarnings(tx, order.VehicleID, order.ScheduledDate)
+		return err
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated, err := s.GetDeliveryOrderByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, warnings, nil
+}
+
+// UpdateDeliveryStatus moves a trip along scheduled, loaded, on the way and delivered, or
+// ends it as failed. Drivers can only move their own orders. A failed trip leaves its goods
+// waiting for a new order, a delivered one takes what the customer kept off the stock.
+func (s *deliveryOrderService) UpdateDeliveryStatus(id uint, input dtos.DeliveryStatusInput, userID *uint, role string, clientIP string) (*models.DeliveryOrder, []string, error) {
+	var order models.DeliveryOrder
+	var warnings []string
+	var shortages []models.StockShortage
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&order, id).Error; err != nil {
+			return errors.New("delivery order not found")
+		}
+		if role == "driver" && common.GetUintValue(order.DriverID) != common.GetUintValue(userID) {
+			return errors.New("delivery order is assigned to another driver")
+		}
+		if !slices.Contains(deliveryTransitions[order.Status], input.Status) {
+			return fmt.Errorf("cannot move a %s delivery order to %s", order.Status, input.Status)
+		}
+
+		switch input.Status {
+		case "failed":
+			if input.FailureReason == nil || strings.TrimSpace(*input.FailureReason) == "" {
+				return errors.New("failure_reason is required")
+			}
+			reason := strings.TrimSpace(*input.FailureReason)
+			order.FailureReason = &reason
+
+		case "delivered":
+			if input.ReceivedBy == nil || strings.TrimSpace(*input.ReceivedBy) == "" {
+				return errors.New("received_by is required")
+			}
+			imageID, err := proofImageID(tx, input.ProofImageURL)
+			if err != nil {
+				return err
+			}
+			receivedBy := strings.TrimSpace(*input.ReceivedBy)
+			now := time.Now()
+			order.ReceivedBy = &receivedBy
+			order.ProofImageID = &imageID
+			order.DeliveredAt = &now
+
+			warnings, shortages, err = deliverOrderLines(tx, &order, input.Lines, userID, role, clientIP)
+			if err != nil {
+				return err
+			}
+		}
+
+		order.Status = input.Status
+		return tx.Omit(clause.Associations).Save(&order).Error
+	})
+
+	recordStockShortages(shortages, order.TransactionID, err == nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated, err := s.GetDeliveryOrderByID(order.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, warnings, nil
+}
+
+// GetDriverDay lists a driver's trips for one day, today when date is empty
+func (s *deliveryOrderService) GetDriverDay(driverID uint, date string) ([]models.DeliveryOrder, error) {
+	day := time.Now()
+	if date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid date, use YYYY-MM-DD")
+		}
+		day = parsed
+	}
+
+	orders := []models.DeliveryOrder{}
+	if err := config.DB.
+		Preload("Lines.Item").
+		Preload("Vehicle").
+		Where("driver_id = ? AND scheduled_date = ?", driverID, day.Format("2006-01-02")).
+		Order("id ASC").
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetSchedule shows each active vehicle's run for a day with its load against capacity,
+// plus the open orders of that day that have no vehicle yet
+func (s *deliveryOrderService) GetSchedule(date string) (*dtos.DeliveryScheduleResponse, error) {
+	day := time.Now()
+	if date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid date, use YYYY-MM-DD")
+		}
+		day = parsed
+	}
+
+	var vehicles []models.Vehicle
+	if err := config.DB.Where("is_active = ?", true).Order("name ASC").Find(&vehicles).Error; err != nil {
+		return nil, err
+	}
+
+	var orders []models.DeliveryOrder
+	if err := config.DB.
+		Preload("Lines.Item").
+		Preload("Driver").
+		Where("scheduled_date = ? AND status IN ?", day.Format("2006-01-02"), openDeliveryStatuses).
+		Order("id ASC").
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	response := &dtos.DeliveryScheduleResponse{
+		Date:       day.Format("2006-01-02"),
+		Runs:       []dtos.VehicleRun{},
+		Unassigned: []models.DeliveryOrder{},
+	}
+	byVehicle := make(map[uint][]models.DeliveryOrder)
+	for _, order := range orders {
+		if order.VehicleID == nil {
+			response.Unassigned = append(response.Unassigned, order)
+			continue
+		}
+		byVehicle[*order.VehicleID] = append(byVehicle[*order.VehicleID], order)
+	}
+	for _, vehicle := range vehicles {
+		response.Runs = append(response.Runs, buildVehicleRun(vehicle, byVehicle[vehicle.ID], day))
+	}
+
+	return response, nil
+}
+
+// AssignVehicle puts delivery orders that have not left yet on a vehicle's run for a day.
+// Overloading is allowed, the returned run carries the warnings.
+func (s *deliveryOrderService) AssignVehicle(input dtos.AssignVehicleInput) (*dtos.VehicleRun, error) {
+	day, err := time.ParseInLocation("2006-01-02", input.Date, time.Local)
+	if err != nil {
+		return nil, errors.New("invalid date, use YYYY-MM-DD")
+	}
+
+	var run dtos.VehicleRun
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := validateVehicle(tx, &input.VehicleID); err != nil {
+			return err
+		}
+		if err := validateDriver(tx, input.DriverID); err != nil {
+			return err
+		}
+
+		for _, id := range input.OrderIDs {
+			var order models.DeliveryOrder
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+				return fmt.Errorf("delivery order %d not found", id)
+			}
+			if order.Status != "scheduled" && order.Status != "loaded" {
+				return fmt.Errorf("delivery order %s has already left", order.Number)
+			}
+
+			updates := map[string]any{"vehicle_id": input.VehicleID, "scheduled_date": day}
+			if input.DriverID != nil {
+				updates["driver_id"] = *input.DriverID
+			}
+			if err := tx.Model(&order).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		run, err = loadVehicleRun(tx, input.VehicleID, day)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// deliverOrderLines books what the customer kept off the shelf and shrinks the sale's stock
+// hold to what is still to come. The sale counts as delivered once nothing is left.
+func deliverOrderLines(tx *gorm.DB, order *models.DeliveryOrder, taken []dtos.DeliveredLineInput, userID *uint, role string, clientIP string) ([]string, []models.StockShortage, error) {
+	var transaction models.Transaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Items.Item").
+		First(&transaction, order.TransactionID).Error; err != nil {
+		return nil, nil, errors.New("transaction not found")
+	}
+	oldCopy := transaction
+
+	// Everything loaded was taken unless the driver says otherwise
+	kept := make(map[uint]int, len(order.Lines))
+	for _, line := range order.Lines {
+		kept[line.ID] = line.Quantity
+	}
+	for _, line := range taken {
+		index := slices.IndexFunc(order.Lines, func(l models.DeliveryOrderLine) bool { return l.ID == line.LineID })
+		if index < 0 {
+			return nil, nil, fmt.Errorf("delivery order line %d not found", line.LineID)
+		}
+		if line.Quantity > order.Lines[index].Quantity {
+			return nil, nil, fmt.Errorf("delivered quantity for line %d exceeds the %d loaded", line.LineID, order.Lines[index].Quantity)
+		}
+		kept[line.LineID] = line.Quantity
+	}
+
+	var delivered []models.TransactionItem
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		index := slices.IndexFunc(transaction.Items, func(tItem models.TransactionItem) bool { return tItem.ID == line.TransactionItemID })
+		if index < 0 {
+			return nil, nil, fmt.Errorf("transaction item %d not found", line.TransactionItemID)
+		}
+		tItem := &transaction.Items[index]
+
+		// The sale may have been refunded since the trip was planned
+		quantity := kept[line.ID]
+		if left := tItem.Quantity - tItem.RefundedQuantity - tItem.DeliveredQuantity; quantity > left {
+			return nil, nil, fmt.Errorf("only %d of '%s' is left to deliver", max(left, 0), tItem.Item.Name)
+		}
+
+		line.DeliveredQuantity = quantity
+		if err := tx.Model(line).Update("delivered_quantity", quantity).Error; err != nil {
+			return nil, nil, err
+		}
+		if quantity == 0 {
+			continue
+		}
+		tItem.DeliveredQuantity += quantity
+		if err := tx.Model(tItem).Update("delivered_quantity", tItem.DeliveredQuantity).Error; err != nil {
+			return nil, nil, err
+		}
+		delivered = append(delivered, models.TransactionItem{ItemID: tItem.ItemID, Quantity: quantity})
+	}
+
+	warnings, shortages, err := deductStockForTransaction(tx, delivered, &transaction, userID, role, "Delivered on "+order.Number)
+	if err != nil {
+		return nil, shortages, err
+	}
+
+	reservationService := NewReservationService()
+	if err := reservationService.FulfillForTransaction(tx, transaction.ID); err != nil {
+		return nil, shortages, err
+	}
+	undelivered := undeliveredItems(transaction.Items)
+	if err := reservationService.ReserveForTransaction(tx, transaction.ID, undelivered, "delivery"); err != nil {
+		return nil, shortages, err
+	}
+
+	if len(undelivered) > 0 {
+		return warnings, shortages, nil
+	}
+
+	transaction.DeliveredAt = order.DeliveredAt
+	if err := tx.Model(&transaction).Update("delivered_at", transaction.DeliveredAt).Error; err != nil {
+		return nil, shortages, err
+	}
+	return warnings, shortages, log.CreateTransactionAuditLog(
+		tx,
+		"update",
+		transaction.ID,
+		&oldCopy,
+		&transaction,
+		userID,
+		clientIP,
+		fmt.Sprintf("Transaction #%d delivered (%s)", transaction.ID, order.Number),
+	)
+}
+
+// openDeliveryQuantities sums per sale line what sits on trips that have not ended
+func openDeliveryQuantities(tx *gorm.DB, transactionID uint) (map[uint]int, error) {
+	var rows []struct {
+		TransactionItemID uint
+		Quantity          int
+	}
+	if err := tx.Model(&models.DeliveryOrderLine{}).
+		Select("delivery_order_lines.transaction_item_id, COALESCE(SUM(delivery_order_lines.quantity), 0) AS quantity").
+		Joins("JOIN delivery_orders ON delivery_orders.id = delivery_order_lines.delivery_order_id").
+		Where("delivery_orders.transaction_id = ? AND delivery_orders.status IN ?", transactionID, openDeliveryStatuses).
+		Group("delivery_order_lines.transaction_item_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		quantities[row.TransactionItemID] = row.Quantity
+	}
+	return quantities, nil
+}
+
+// deliveryLoad adds up the weight and volume of the lines going on a trip. Items without
+// a measure count as nothing and come back as warnings, so the planner knows the total is low.
+func deliveryLoad(tx *gorm.DB, lines []models.DeliveryOrderLine) (float64, float64, []string, error) {
+	var weight, volume float64
+	var warnings []string
+	for _, line := range lines {
+		var item models.Item
+		if err := tx.Select("id", "name", "weight_kg", "volume_m3").First(&item, line.ItemID).Error; err != nil {
+			return 0, 0, nil, err
+		}
+		if item.WeightKg == nil {
+			warnings = append(warnings, fmt.Sprintf("Warning: Item '%s' has no weight, it is not counted in the load", item.Name))
+		} else {
+			weight += *item.WeightKg * float64(line.Quantity)
+		}
+		if item.VolumeM3 == nil {
+			warnings = append(warnings, fmt.Sprintf("Warning: Item '%s' has no volume, it is not counted in the load", item.Name))
+		} else {
+			volume += *item.VolumeM3 * float64(line.Quantity)
+		}
+	}
+	return roundMoney(weight), math.Round(volume*1000) / 1000, warnings, nil
+}
+
+// loadVehicleRun collects the open orders a vehicle carries on a day
+func loadVehicleRun(tx *gorm.DB, vehicleID uint, day time.Time) (dtos.VehicleRun, error) {
+	var vehicle models.Vehicle
+	if err := tx.First(&vehicle, vehicleID).Error; err != nil {
+		return dtos.VehicleRun{}, errors.New("vehicle not found")
+	}
+
+	var orders []models.DeliveryOrder
+	if err := tx.
+		Preload("Lines.Item").
+		Preload("Driver").
+		Where("vehicle_id = ? AND scheduled_date = ? AND status IN ?", vehicleID, day.Format("2006-01-02"), openDeliveryStatuses).
+		Order("id ASC").
+		Find(&orders).Error; err != nil {
+		return dtos.VehicleRun{}, err
+	}
+
+	return buildVehicleRun(vehicle, orders, day), nil
+}
+
+// vehicleRunWarnings returns the overload warnings of the run an order was put on
+func vehicleRunWarnings(tx *gorm.DB, vehicleID *uint, day time.Time) ([]string, error) {
+	if vehicleID == nil {
+		return nil, nil
+	}
+	run, err := loadVehicleRun(tx, *vehicleID, day)
+	if err != nil {
+		return nil, err
+	}
+	return run.Warnings, nil
+}
+
+func buildVehicleRun(vehicle models.Vehicle, orders []models.DeliveryOrder, day time.Time) dtos.VehicleRun {
+	run := dtos.VehicleRun{Vehicle: vehicle, Orders: orders}
+	if run.Orders == nil {
+		run.Orders = []models.DeliveryOrder{}
+	}
+	for _, order := range orders {
+		run.TotalWeightKg += order.TotalWeightKg
+		run.TotalVolumeM3 += order.TotalVolumeM3
+	}
+	run.TotalWeightKg = roundMoney(run.TotalWeightKg)
+	run.TotalVolumeM3 = math.Round(run.TotalVolumeM3*1000) / 1000
+
+	if vehicle.MaxWeightKg > 0 {
+		percent := roundMoney(run.TotalWeightKg / vehicle.MaxWeightKg * 100)
+		run.WeightLoadPct = &percent
+		if run.TotalWeightKg > vehicle.MaxWeightKg {
+			run.Overloaded = true
+			run.Warnings = append(run.Warnings, fmt.Sprintf(
+				"Warning: %s (%s) is overloaded on %s: %.1f kg of %.1f kg",
+				vehicle.Name, vehicle.PlateNumber, day.Format("2006-01-02"), run.TotalWeightKg, vehicle.MaxWeightKg,
+			))
+		}
+	}
+	if vehicle.MaxVolumeM3 > 0 {
+		percent := roundMoney(run.TotalVolumeM3 / vehicle.MaxVolumeM3 * 100)
+		run.VolumeLoadPct = &percent
+		if run.TotalVolumeM3 > vehicle.MaxVolumeM3 {
+			run.Overloaded = true
+			run.Warnings = append(run.Warnings, fmt.Sprintf(
+				"Warning: %s (%s) is over its volume on %s: %.2f m3 of %.2f m3",
+				vehicle.Name, vehicle.PlateNumber, day.Format("2006-01-02"), run.TotalVolumeM3, vehicle.MaxVolumeM3,
+			))
+		}
+	}
+	return run
+}
+
+func validateVehicle(tx *gorm.DB, vehicleID *uint) error {
+	if vehicleID == nil {
+		return nil
+	}
+	var vehicle models.Vehicle
+	if err := tx.Select("id", "is_active").First(&vehicle, *vehicleID).Error; err != nil {
+		return errors.New("vehicle not found")
+	}
+	if !vehicle.IsActive {
+		return errors.New("vehicle is not active")
+	}
+	return nil
+}
+
+func validateDriver(tx *gorm.DB, driverID *uint) error {
+	if driverID == nil {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.User{}).Where("id = ? AND role = ?", *driverID, "driver").Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("driver not found")
+	}
+	return nil
+}
+
+// proofImageID resolves the /images/... URL returned by the upload endpoint
+func proofImageID(tx *gorm.DB, url *string) (uint, error) {
+	if url == nil || strings.TrimSpace(*url) == "" {
+		return 0, errors.New("proof_image_url is required")
+	}
+	fileName := strings.TrimPrefix(strings.TrimSpace(*url), "/images/")
+
+	var image models.Image
+	if err := tx.Select("id").Where("file_name = ?", fileName).First(&image).Error; err != nil {
+		return 0, errors.New("proof image not found")
+	}
+	return image.ID, nil
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"kd-api/src/utils/log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExchangeService interface {
+	CreateExchange(transactionID string, input dtos.ExchangeInput, userID *uint, role string, clientIP string) (*models.Exchange, []string, error)
+	GetExchanges(transactionID string) ([]models.Exchange, error)
+}
+
+type exchangeService struct{}
+
+func NewExchangeService() ExchangeService {
+	return &exchangeService{}
+}
+
+// CreateExchange refunds the returned lines into exchange credit and sells the new lines
+// against it in one DB transaction, so the refund and sale inventory logs land together.
+// Any difference is collected as an extra tender on the new sale or paid out to the customer.
+func (s *exchangeService) CreateExchange(transactionID string, input dtos.ExchangeInput, userID *uint, role string, clientIP string) (*models.Exchange, []string, error) {
+	var exchange models.Exchange
+	var warnings []string
+	var shortages []models.StockShortage
+
+	method := "cash"
+	if input.Method != nil {
+		method = *input.Method
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var original models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&original, transactionID).Error; err != nil {
+			return errors.New("transaction not found")
+		}
+		oldOriginal := original
+
+		exchangeMethod := "exchange"
+		refund, err := createRefund(tx, &original, dtos.RefundInput{
+			Lines:  input.ReturnLines,
+			Reason: input.Note,
+			Method: &exchangeMethod,
+		}, userID)
+		if err != nil {
+			return err
+		}
+
+		var newItems []models.TransactionItem
+		var newTotal float64
+		for _, line := range input.NewLines {
+			if line.Quantity <= 0 {
+				return fmt.Errorf("invalid quantity for item %d", line.ItemID)
+			}
+
+			var item models.Item
+			if err := tx.First(&item, line.ItemID).Error; err != nil {
+				return fmt.Errorf("item %d not found", line.ItemID)
+			}
+
+			newItem, err := priceSaleLine(item, line, role)
+			if err != nil {
+				return err
+			}
+			newTotal += newItem.Subtotal
+			newItems = append(newItems, newItem)
+		}
+
+		note := "Exchange for " + transactionNumber(&original)
+		newTransaction := models.Transaction{
+			Status:          "completed",
+			Total:           newTotal,
+			Items:           newItems,
+			Note:            &note,
+			TransactionType: "onsite",
+			CustomerID:      original.CustomerID,
+			TaxExclusive:    !config.PricesIncludeTax(),
+		}
+		applyTransactionTax(&newTransaction)
+		newTotal = newTransaction.Total
+
+		// Exchange credit pays for the new goods first, the rest is settled with Method.
+		// Whatever the refund took off an unpaid credit balance was never paid, so it buys nothing.
+		returned := refund.Amount - refund.CreditedAmount
+		credit := returned
+		if credit > newTotal {
+			credit = newTotal
+		}
+		difference := newTotal - returned
+
+		var payments []models.TransactionPayment
+		if credit > 0 {
+			payments = append(payments, models.TransactionPayment{Method: "exchange", Amount: credit})
+		}
+		if difference > 0 {
+			payments = append(payments, models.TransactionPayment{Method: method, Amount: difference})
+		}
+
+		var paid float64
+		paymentType := "exchange"
+		for _, p := range payments {
+			paid += p.Amount
+		}
+		if len(payments) > 1 {
+			paymentType = "split"
+		} else if len(payments) == 1 {
+			paymentType = payments[0].Method
+		}
+		change := 0.0
+
+		newTransaction.Payment = &paid
+		newTransaction.Change = &change
+		newTransaction.PaymentType = &paymentType
+		newTransaction.Payments = payments
+		newTransaction.CreatedByID = userID
+		newTransaction.CompletedByID = userID
+		if err := bookOnCashSession(tx, &newTransaction, newTransaction.Payments, userID); err != nil {
+			return err
+		}
+		if err := assignTransactionNumbers(tx, &newTransaction); err != nil {
+			return err
+		}
+		if err := tx.Create(&newTransaction).Error; err != nil {
+			return err
+		}
+
+		warnings, shortages, err = deductStockForTransaction(tx, newTransaction.Items, &newTransaction, userID, role, note)
+		if err != nil {
+			return err
+		}
+
+		exchange = models.Exchange{
+			OriginalTransactionID: original.ID,
+			RefundID:              refund.ID,
+			NewTransactionID:      newTransaction.ID,
+			ReturnedAmount:        refund.Amount,
+			NewAmount:             newTotal,
+			Difference:            difference,
+			Note:                  input.Note,
+			UserID:                userID,
+		}
+		if difference != 0 {
+			exchange.Method = &method
+		}
+		if difference < 0 && method == "cash" {
+			sessionID, err := openCashSessionID(tx, userID)
+			if err != nil {
+				return err
+			}
+			exchange.CashSessionID = sessionID
+		}
+		exchange.Number, err = nextDocumentNumber(tx, config.DocExchange, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&exchange).Error; err != nil {
+			return err
+		}
+
+		if err := log.CreateTransactionAuditLog(
+			tx,
+			"update",
+			original.ID,
+			&oldOriginal,
+			&original,
+			userID,
+			clientIP,
+			fmt.Sprintf("Transaction #%d exchanged (%s)", original.ID, exchange.Number),
+		); err != nil {
+			return err
+		}
+
+		return log.CreateTransactionAuditLog(
+			tx,
+			"create",
+			newTransaction.ID,
+			nil,
+			&newTransaction,
+			userID,
+			clientIP,
+			fmt.Sprintf("Transaction #%d create (%s)", newTransaction.ID, exchange.Number),
+		)
+	})
+
+	recordStockShortages(shortages, exchange.NewTransactionID, err == nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := config.DB.Preload("Refund.Lines.Item").Preload("NewTransaction.Items.Item").Preload("NewTransaction.Payments").
+		First(&exchange, exchange.ID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &exchange, warnings, nil
+}
+
+func (s *exchangeService) GetExchanges(transactionID string) ([]models.Exchange, error) {
+	var exchanges []models.Exchange
+	if err := config.DB.Preload("Refund.Lines.Item").Preload("NewTransaction.Items.Item").Preload("NewTransaction.Payments").
+		Where("original_transaction_id = ?", transactionID).
+		Order("created_at ASC").
+		Find(&exchanges).Error; err != nil {
+		return nil, err
+	}
+	return exchanges, nil
+}
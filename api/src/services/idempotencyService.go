@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"kd-api/src/config"
+	"kd-api/src/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyService interface {
+	Begin(userID uint, key, method, path, requestHash string) (*models.IdempotentRequest, bool, error)
+	Complete(id uint, statusCode int, contentType string, response []byte) error
+	Release(id uint) error
+	PurgeExpired() (int64, error)
+}
+
+type idempotencyService struct{}
+
+func NewIdempotencyService() IdempotencyService {
+	return &idempotencyService{}
+}
+
+// Begin claims a key for a request. It returns true when the caller owns the key and must
+// run the request, or the finished earlier request to replay. A key reused for another
+// request, or one whose first request is still running, is an error.
+func (s *idempotencyService) Begin(userID uint, key, method, path, requestHash string) (*models.IdempotentRequest, bool, error) {
+	// A key past its TTL is free again, try once more after clearing it
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		request := models.IdempotentRequest{
+			UserID:      userID,
+			Key:         key,
+			Method:      method,
+			Path:        path,
+			RequestHash: requestHash,
+			ExpiresAt:   now.Add(config.IdempotencyTTL()),
+		}
+		result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&request)
+		if result.Error != nil {
+			return nil, false, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return &request, true, nil
+		}
+
+		var existing models.IdempotentRequest
+		if err := config.DB.Where("user_id = ? AND request_key = ?", userID, key).First(&existing).Error; err != nil {
+			continue // Expired and purged in between
+		}
+		if existing.ExpiresAt.Before(now) {
+			if err := config.DB.Delete(&existing).Error; err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+		if existing.RequestHash != requestHash {
+			return nil, false, errors.New("idempotency key was already used for a different request")
+		}
+		if existing.CompletedAt == nil {
+			return nil, false, errors.New("a request with this idempotency key is still in progress")
+		}
+		return &existing, false, nil
+	}
+
+	return nil, false, errors.New("a request with this idempotency key is still in progress")
+}
+
+// Complete stores the response to replay for the key
+func (s *idempotencyService) Complete(id uint, statusCode int, contentType string, response []byte) error {
+	now := time.Now()
+	return config.DB.Model(&models.IdempotentRequest{}).Where("id = ?", id).Updates(map[string]any{
+		"status_code":  statusCode,
+		"content_type": contentType,
+		"response":     response,
+		"completed_at": now,
+	}).Error
+}
+
+// Release frees a key whose request failed on the server side, so the client may retry it
+func (s *idempotencyService) Release(id uint) error {
+	return config.DB.Delete(&models.IdempotentRequest{}, id).Error
+}
+
+func (s *idempotencyService) PurgeExpired() (int64, error) {
+	result := config.DB.Where("expires_at < ?", time.Now()).Delete(&models.IdempotentRequest{})
+	return result.RowsAffected, result.Error
+}
+
+// StartIdempotencyCleanupJob deletes expired idempotency keys every hour
+func StartIdempotencyCleanupJob() {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if _, err := NewIdempotencyService().PurgeExpired(); err != nil {
+				log.Println("Idempotency key cleanup failed:", err)
+			}
+		}
+	}()
+}
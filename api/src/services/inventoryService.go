@@ -1,17 +1,25 @@
 package services
 
 import (
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"kd-api/src/config"
 	"kd-api/src/dtos"
 	"kd-api/src/models"
+	"sort"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type InventoryService interface {
 	LogStockChange(tx *gorm.DB, itemID uint, change int, logType string, refID string, userID *uint, note string) error
 	GetInventoryHistory(filter dtos.InventoryFilter) (*dtos.InventoryListResponse, error)
+	GetValuation(asOf string) (*dtos.InventoryValuationResponse, error)
+	ExportValuation(writer io.Writer, valuation *dtos.InventoryValuationResponse) error
 }
 
 type inventoryService struct{}
@@ -77,21 +85,31 @@ func (s *inventoryService) GetInventoryHistory(filter dtos.InventoryFilter) (*dt
 }
 
 func (s *inventoryService) LogStockChange(tx *gorm.DB, itemID uint, change int, logType string, refID string, userID *uint, note string) error {
-	// 1. Get current stock to ensure accuracy (locking row would be ideal but simple read is start)
+	return logStockChange(tx, itemID, change, logType, refID, nil, userID, note)
+}
+
+// logTransactionStockChange logs a stock change made for a sale, linked to it by ID
+func logTransactionStockChange(tx *gorm.DB, transaction *models.Transaction, itemID uint, change int, logType string, refID string, userID *uint, note string) error {
+	return logStockChange(tx, itemID, change, logType, refID, &transaction.ID, userID, note)
+}
+
+func logStockChange(tx *gorm.DB, itemID uint, change int, logType string, refID string, transactionID *uint, userID *uint, note string) error {
+	// 1. Get current stock under a row lock so a concurrent writer can't slip in between
 	var item models.Item
-	if err := tx.First(&item, itemID).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, itemID).Error; err != nil {
 		return fmt.Errorf("item not found for inventory log: %w", err)
 	}
 
 	// 2. Create Log
 	log := models.InventoryLog{
-		ItemID:      itemID,
-		Change:      change,
-		FinalStock:  item.Stock, // This assumes the item.Stock has already been updated in the DB by the caller
-		Type:        logType,
-		ReferenceID: refID,
-		UserID:      userID,
-		Note:        note,
+		ItemID:        itemID,
+		Change:        change,
+		FinalStock:    item.Stock, // This assumes the item.Stock has already been updated in the DB by the caller
+		Type:          logType,
+		ReferenceID:   refID,
+		TransactionID: transactionID,
+		UserID:        userID,
+		Note:          note,
 	}
 
 	if err := tx.Create(&log).Error; err != nil {
@@ -100,3 +118,165 @@ func (s *inventoryService) LogStockChange(tx *gorm.DB, itemID uint, change int,
 
 	return nil
 }
+
+// GetValuation rebuilds stock per item as of a past moment from the ledger
+// (last FinalStock at or before asOf) and values it at the buy price effective then.
+func (s *inventoryService) GetValuation(asOf string) (*dtos.InventoryValuationResponse, error) {
+	cutoff, err := parseAsOf(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	// Include items deleted after the cutoff, they were still on the shelf back then
+	var items []models.Item
+	if err := config.DB.Unscoped().
+		Where("created_at <= ? AND (deleted_at IS NULL OR deleted_at > ?)", cutoff, cutoff).
+		Where("is_stock_managed = ?", true).
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	var stockRows []struct {
+		ItemID     uint
+		FinalStock int
+	}
+	if err := config.DB.Table("inventory_logs AS l").
+		Select("l.item_id, l.final_stock").
+		Joins("JOIN (SELECT item_id, MAX(id) AS id FROM inventory_logs WHERE created_at <= ? GROUP BY item_id) last_log ON last_log.id = l.id", cutoff).
+		Scan(&stockRows).Error; err != nil {
+		return nil, err
+	}
+	quantities := make(map[uint]int, len(stockRows))
+	for _, row := range stockRows {
+		quantities[row.ItemID] = row.FinalStock
+	}
+
+	var costRows []struct {
+		ItemID   uint
+		BuyPrice float64
+	}
+	if err := config.DB.Table("item_cost_histories AS h").
+		Select("h.item_id, h.buy_price").
+		Joins("JOIN (SELECT item_id, MAX(id) AS id FROM item_cost_histories WHERE effective_at <= ? GROUP BY item_id) last_cost ON last_cost.id = h.id", cutoff).
+		Scan(&costRows).Error; err != nil {
+		return nil, err
+	}
+	costs := make(map[uint]float64, len(costRows))
+	for _, row := range costRows {
+		costs[row.ItemID] = row.BuyPrice
+	}
+
+	result := &dtos.InventoryValuationResponse{
+		AsOf:       cutoff,
+		Items:      []dtos.InventoryValuationLine{},
+		Categories: []dtos.CategoryValuation{},
+	}
+	categories := make(map[string]*dtos.CategoryValuation)
+
+	for _, item := range items {
+		quantity := quantities[item.ID]
+		if quantity == 0 {
+			continue
+		}
+
+		// An item with no cost recorded yet at the cutoff, from before cost history was kept, falls back to its current buy price
+		unitCost, ok := costs[item.ID]
+		if !ok {
+			unitCost = item.BuyPrice
+		}
+
+		category := valuationCategory(item.Category)
+		line := dtos.InventoryValuationLine{
+			ItemID:   item.ID,
+			Name:     item.Name,
+			Category: category,
+			Quantity: quantity,
+			UnitCost: unitCost,
+			Value:    float64(quantity) * unitCost,
+		}
+		result.Items = append(result.Items, line)
+
+		group, ok := categories[category]
+		if !ok {
+			group = &dtos.CategoryValuation{Category: category}
+			categories[category] = group
+		}
+		group.ItemCount++
+		group.Quantity += line.Quantity
+		group.Value += line.Value
+
+		result.TotalQuantity += line.Quantity
+		result.TotalValue += line.Value
+	}
+
+	sort.Slice(result.Items, func(i, j int) bool {
+		if result.Items[i].Category != result.Items[j].Category {
+			return result.Items[i].Category < result.Items[j].Category
+		}
+		return result.Items[i].Name < result.Items[j].Name
+	})
+
+	for _, group := range categories {
+		result.Categories = append(result.Categories, *group)
+	}
+	sort.Slice(result.Categories, func(i, j int) bool {
+		return result.Categories[i].Category < result.Categories[j].Category
+	})
+
+	return result, nil
+}
+
+func (s *inventoryService) ExportValuation(writer io.Writer, valuation *dtos.InventoryValuationResponse) error {
+	csvWriter := csv.NewWriter(writer)
+	defer csvWriter.Flush()
+
+	csvWriter.Write([]string{"item_id", "name", "category", "quantity", "unit_cost", "value"})
+
+	// Items are sorted by category, a subtotal row closes each category block
+	for i, line := range valuation.Items {
+		csvWriter.Write([]string{
+			fmt.Sprintf("%d", line.ItemID),
+			line.Name,
+			line.Category,
+			fmt.Sprintf("%d", line.Quantity),
+			fmt.Sprintf("%.2f", line.UnitCost),
+			fmt.Sprintf("%.2f", line.Value),
+		})
+
+		if i == len(valuation.Items)-1 || valuation.Items[i+1].Category != line.Category {
+			for _, group := range valuation.Categories {
+				if group.Category == line.Category {
+					csvWriter.Write([]string{"", "SUBTOTAL", group.Category, fmt.Sprintf("%d", group.Quantity), "", fmt.Sprintf("%.2f", group.Value)})
+				}
+			}
+		}
+	}
+
+	csvWriter.Write([]string{"", "TOTAL", "", fmt.Sprintf("%d", valuation.TotalQuantity), "", fmt.Sprintf("%.2f", valuation.TotalValue)})
+
+	return csvWriter.Error()
+}
+
+// parseAsOf accepts YYYY-MM-DD (end of that day) or RFC3339; empty means now
+func parseAsOf(asOf string) (time.Time, error) {
+	if asOf == "" {
+		return time.Now(), nil
+	}
+
+	if day, err := time.ParseInLocation("2006-01-02", asOf, time.Local); err == nil {
+		return day.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, asOf); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, errors.New("invalid as_of, use YYYY-MM-DD or RFC3339")
+}
+
+func valuationCategory(category *string) string {
+	if category == nil || *category == "" {
+		return "Uncategorized"
+	}
+	return *category
+}
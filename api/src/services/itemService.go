@@ -13,8 +13,10 @@ import (
 	"kd-api/src/utils/pagination"
 	"kd-api/src/utils/response"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ItemService interface {
@@ -60,6 +62,10 @@ func (s *itemService) GetItems(filter dtos.ItemFilter, role string) (*dtos.ItemL
 		return nil, err
 	}
 
+	if err := applyAvailability(config.DB, items); err != nil {
+		return nil, err
+	}
+
 	meta := dtos.PaginationMeta{
 		Page:       p.Page,
 		Limit:      p.PageSize,
@@ -107,6 +113,10 @@ func (s *itemService) SearchItems(filter dtos.ItemFilter, role string) (*dtos.It
 		return nil, err
 	}
 
+	if err := applyAvailability(config.DB, items); err != nil {
+		return nil, err
+	}
+
 	var meta dtos.PaginationMeta
 	if !filter.SkipCount {
 		meta = dtos.PaginationMeta{
@@ -135,7 +145,12 @@ func (s *itemService) GetItemByID(id string, role string) (interface{}, error) {
 	if err := config.DB.First(&item, id).Error; err != nil {
 		return nil, errors.New("Item not found")
 	}
-	return response.FilterItemForRole(item, role), nil
+
+	items := []models.Item{item}
+	if err := applyAvailability(config.DB, items); err != nil {
+		return nil, err
+	}
+	return response.FilterItemForRole(items[0], role), nil
 }
 
 func (s *itemService) CreateItem(input dtos.CreateItemInput, userID *uint, clientIP string, role string) (interface{}, error) {
@@ -146,25 +161,43 @@ func (s *itemService) CreateItem(input dtos.CreateItemInput, userID *uint, clien
 
 	// DB default is true, but to be sure we can set a pointer
 	defaultStockManaged := true
+	defaultTaxable := true
 	item := models.Item{
-		Name:           input.Name,
-		Description:    input.Description,
-		Stock:          input.Stock,
-		BuyPrice:       input.BuyPrice,
-		Price:          input.Price,
-		ImageURL:       input.ImageURL,
-		IsStockManaged: &defaultStockManaged,
+		Name:                input.Name,
+		Description:         input.Description,
+		Stock:               input.Stock,
+		BuyPrice:            input.BuyPrice,
+		Price:               input.Price,
+		ImageURL:            input.ImageURL,
+		Category:            input.Category,
+		SupplierID:          input.SupplierID,
+		IsStockManaged:      &defaultStockManaged,
+		NegativeStockPolicy: input.NegativeStockPolicy,
+		IsTaxable:           &defaultTaxable,
+		TaxRate:             input.TaxRate,
+		WeightKg:            input.WeightKg,
+		VolumeM3:            input.VolumeM3,
 	}
 
 	if input.IsStockManaged != nil {
 		item.IsStockManaged = input.IsStockManaged
 	}
+	if input.IsTaxable != nil {
+		item.IsTaxable = input.IsTaxable
+	}
 
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&item).Error; err != nil {
 			return err
 		}
 
+		if err := recordItemCost(tx, item.ID, item.BuyPrice, userID); err != nil {
+			return err
+		}
+		if err := recordItemPrice(tx, item.ID, item.Price, userID); err != nil {
+			return err
+		}
+
 		description := fmt.Sprintf("Item '%s' created", item.Name)
 		if err := log.CreateItemAuditLog(
 			tx,
@@ -212,6 +245,12 @@ func (s *itemService) UpdateItem(id string, input dtos.UpdateItemInput, userID *
 	oldCopy := oldItem
 
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Re-read under lock so a sale committed since the first read is not overwritten unlogged
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&oldItem, oldItem.ID).Error; err != nil {
+			return errors.New("Item not found")
+		}
+		oldCopy = oldItem
+
 		oldItem.Name = input.Name
 		oldItem.Description = input.Description
 		oldItem.Stock = input.Stock
@@ -221,11 +260,31 @@ func (s *itemService) UpdateItem(id string, input dtos.UpdateItemInput, userID *
 		oldItem.BuyPrice = input.BuyPrice
 		oldItem.Price = input.Price
 		oldItem.ImageURL = input.ImageURL
+		oldItem.Category = input.Category
+		oldItem.SupplierID = input.SupplierID
+		oldItem.NegativeStockPolicy = input.NegativeStockPolicy
+		if input.IsTaxable != nil {
+			oldItem.IsTaxable = input.IsTaxable
+		}
+		oldItem.TaxRate = input.TaxRate
+		oldItem.WeightKg = input.WeightKg
+		oldItem.VolumeM3 = input.VolumeM3
 
 		if err := tx.Save(&oldItem).Error; err != nil {
 			return err
 		}
 
+		if oldItem.BuyPrice != oldCopy.BuyPrice {
+			if err := recordItemCost(tx, oldItem.ID, oldItem.BuyPrice, userID); err != nil {
+				return err
+			}
+		}
+		if oldItem.Price != oldCopy.Price {
+			if err := recordItemPrice(tx, oldItem.ID, oldItem.Price, userID); err != nil {
+				return err
+			}
+		}
+
 		description := fmt.Sprintf("Item '%s' updated", oldItem.Name)
 		if err := log.CreateItemAuditLog(
 			tx,
@@ -241,9 +300,22 @@ func (s *itemService) UpdateItem(id string, input dtos.UpdateItemInput, userID *
 		}
 
 		// Inventory Log (Stock Adjustment)
-		stockChange := oldItem.Stock - oldCopy.Stock
-		if stockChange != 0 && oldItem.IsStockManaged != nil && *oldItem.IsStockManaged {
-			invService := NewInventoryService()
+		wasManaged := oldCopy.IsStockManaged != nil && *oldCopy.IsStockManaged
+		isManaged := oldItem.IsStockManaged != nil && *oldItem.IsStockManaged
+		invService := NewInventoryService()
+
+		if isManaged && !wasManaged {
+			// Stock was untracked until now, re-anchor the ledger on the entered stock
+			ledgerStock, err := lastLedgerStock(tx, oldItem.ID)
+			if err != nil {
+				return err
+			}
+			if change := oldItem.Stock - ledgerStock; change != 0 {
+				if err := invService.LogStockChange(tx, oldItem.ID, change, "audit", "MANUAL", userID, "Stock tracking enabled"); err != nil {
+					return err
+				}
+			}
+		} else if stockChange := oldItem.Stock - oldCopy.Stock; stockChange != 0 && isManaged {
 			if err := invService.LogStockChange(tx, oldItem.ID, stockChange, "adjustment", "MANUAL", userID, "Manual stock update"); err != nil {
 				return err
 			}
@@ -268,6 +340,23 @@ func (s *itemService) DeleteItem(id string, userID *uint, clientIP string) error
 	itemCopy := item
 
 	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, item.ID).Error; err != nil {
+			return errors.New("Item not found")
+		}
+		itemCopy = item
+
+		// Take the remaining stock off the ledger so the item's chain ends at zero
+		if item.Stock != 0 && item.IsStockManaged != nil && *item.IsStockManaged {
+			if err := tx.Model(&item).Update("stock", 0).Error; err != nil {
+				return err
+			}
+
+			invService := NewInventoryService()
+			if err := invService.LogStockChange(tx, item.ID, -itemCopy.Stock, "delete", "DELETE", userID, "Item deleted"); err != nil {
+				return err
+			}
+		}
+
 		if err := tx.Delete(&item).Error; err != nil {
 			return err
 		}
@@ -304,6 +393,13 @@ func (s *itemService) BulkCreateItems(inputs dtos.BulkCreateItemInput, userID *u
 		}
 
 		for _, item := range items {
+			if err := recordItemCost(tx, item.ID, item.BuyPrice, userID); err != nil {
+				return err
+			}
+			if err := recordItemPrice(tx, item.ID, item.Price, userID); err != nil {
+				return err
+			}
+
 			description := fmt.Sprintf("Item '%s' created via bulk import", item.Name)
 			if err := log.CreateItemAuditLog(
 				tx,
@@ -355,6 +451,39 @@ func (s *itemService) ExportItems(writer io.Writer, role string) error {
 }
 
 
+// recordItemCost appends a buy price to the item's cost history (used for historical valuation)
+func recordItemCost(tx *gorm.DB, itemID uint, buyPrice float64, userID *uint) error {
+	return tx.Create(&models.ItemCostHistory{
+		ItemID:      itemID,
+		BuyPrice:    buyPrice,
+		EffectiveAt: time.Now(),
+		UserID:      userID,
+	}).Error
+}
+
+// recordItemPrice appends a selling price to the item's price history (used to check offline sales)
+func recordItemPrice(tx *gorm.DB, itemID uint, price float64, userID *uint) error {
+	return tx.Create(&models.ItemPriceHistory{
+		ItemID:      itemID,
+		Price:       price,
+		EffectiveAt: time.Now(),
+		UserID:      userID,
+	}).Error
+}
+
+// itemPriceAt is the selling price an item had at a moment, its current price when no history goes back that far
+func itemPriceAt(db *gorm.DB, item models.Item, at time.Time) (float64, error) {
+	var history []models.ItemPriceHistory
+	if err := db.Where("item_id = ? AND effective_at <= ?", item.ID, at).
+		Order("effective_at DESC, id DESC").Limit(1).Find(&history).Error; err != nil {
+		return 0, err
+	}
+	if len(history) == 0 {
+		return item.Price, nil
+	}
+	return history[0].Price, nil
+}
+
 // Helper functions for CSV (internal to service)
 func formatItemCSVRow(item models.Item, role string) []string {
 	desc := common.GetStringValue(item.Description)
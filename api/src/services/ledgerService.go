@@ -0,0 +1,243 @@
+package services
+
+import (
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerService interface {
+	CheckIntegrity(itemID uint) (*dtos.LedgerIntegrityReport, error)
+	Repair(input dtos.LedgerRepairInput, userID *uint) (*dtos.LedgerRepairResponse, error)
+}
+
+type ledgerService struct{}
+
+func NewLedgerService() LedgerService {
+	return &ledgerService{}
+}
+
+// CheckIntegrity replays every stock-managed item's inventory log in order.
+// A break is a log whose FinalStock differs from previous FinalStock + Change,
+// a mismatch is a chain that does not end at the item's current stock.
+func (s *ledgerService) CheckIntegrity(itemID uint) (*dtos.LedgerIntegrityReport, error) {
+	var items []models.Item
+	query := config.DB.Where("is_stock_managed = ?", true)
+	if itemID != 0 {
+		query = query.Where("id = ?", itemID)
+	}
+	if err := query.Order("id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	report := &dtos.LedgerIntegrityReport{
+		CheckedAt:    time.Now(),
+		ItemsChecked: len(items),
+		Items:        []dtos.LedgerItemReport{},
+	}
+	if len(items) == 0 {
+		return report, nil
+	}
+
+	itemIDs := make([]uint, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+	}
+
+	type chainState struct {
+		ledgerStock int
+		breaks      []dtos.LedgerBreak
+	}
+	chains := make(map[uint]*chainState, len(items))
+
+	rows, err := config.DB.Model(&models.InventoryLog{}).
+		Select("id, item_id, `change`, final_stock, type, reference_id, created_at").
+		Where("item_id IN ?", itemIDs).
+		Order("item_id, id").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.InventoryLog
+		if err := config.DB.ScanRows(rows, &entry); err != nil {
+			return nil, err
+		}
+
+		chain, ok := chains[entry.ItemID]
+		if !ok {
+			// Every chain starts from zero, initial stock is logged as a restock
+			chain = &chainState{}
+			chains[entry.ItemID] = chain
+		}
+
+		expected := chain.ledgerStock + entry.Change
+		if entry.FinalStock != expected {
+			chain.breaks = append(chain.breaks, dtos.LedgerBreak{
+				LogID:         entry.ID,
+				Type:          entry.Type,
+				ReferenceID:   entry.ReferenceID,
+				PreviousStock: chain.ledgerStock,
+				Change:        entry.Change,
+				ExpectedStock: expected,
+				FinalStock:    entry.FinalStock,
+				CreatedAt:     entry.CreatedAt,
+			})
+		}
+		chain.ledgerStock = entry.FinalStock
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		chain, ok := chains[item.ID]
+		if !ok {
+			chain = &chainState{}
+		}
+
+		if len(chain.breaks) == 0 && chain.ledgerStock == item.Stock {
+			continue
+		}
+
+		breaks := chain.breaks
+		if breaks == nil {
+			breaks = []dtos.LedgerBreak{}
+		}
+		report.Items = append(report.Items, dtos.LedgerItemReport{
+			ItemID:      item.ID,
+			Name:        item.Name,
+			Stock:       item.Stock,
+			LedgerStock: chain.ledgerStock,
+			Difference:  item.Stock - chain.ledgerStock,
+			Breaks:      breaks,
+		})
+	}
+	report.ItemsWithIssues = len(report.Items)
+
+	return report, nil
+}
+
+// Repair posts an adjustment for every item whose ledger does not end at its
+// current stock. Without Confirm it only returns the adjustments it would post.
+// Historical breaks stay in the report, only the tail of the chain is corrected.
+func (s *ledgerService) Repair(input dtos.LedgerRepairInput, userID *uint) (*dtos.LedgerRepairResponse, error) {
+	selected := make(map[uint]bool, len(input.ItemIDs))
+	for _, id := range input.ItemIDs {
+		selected[id] = true
+	}
+
+	response := &dtos.LedgerRepairResponse{
+		Confirmed:   input.Confirm,
+		Adjustments: []dtos.LedgerAdjustment{},
+	}
+
+	report, err := s.CheckIntegrity(0)
+	if err != nil {
+		return nil, err
+	}
+
+	invService := NewInventoryService()
+	for _, itemReport := range report.Items {
+		if len(selected) > 0 && !selected[itemReport.ItemID] {
+			continue
+		}
+		if itemReport.Difference == 0 {
+			continue
+		}
+
+		if !input.Confirm {
+			response.Adjustments = append(response.Adjustments, dtos.LedgerAdjustment{
+				ItemID:      itemReport.ItemID,
+				Name:        itemReport.Name,
+				LedgerStock: itemReport.LedgerStock,
+				Stock:       itemReport.Stock,
+				Change:      itemReport.Difference,
+			})
+			continue
+		}
+
+		// Recompute under lock, a sale may have moved the item since the report ran
+		var adjustment *dtos.LedgerAdjustment
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var item models.Item
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, itemReport.ItemID).Error; err != nil {
+				return err
+			}
+
+			ledgerStock, err := lastLedgerStock(tx, item.ID)
+			if err != nil {
+				return err
+			}
+
+			change := item.Stock - ledgerStock
+			if change == 0 {
+				return nil
+			}
+
+			if err := invService.LogStockChange(tx, item.ID, change, "adjustment", "RECONCILE", userID, "Ledger reconciliation"); err != nil {
+				return err
+			}
+
+			adjustment = &dtos.LedgerAdjustment{
+				ItemID:      item.ID,
+				Name:        item.Name,
+				LedgerStock: ledgerStock,
+				Stock:       item.Stock,
+				Change:      change,
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if adjustment != nil {
+			response.Adjustments = append(response.Adjustments, *adjustment)
+		}
+	}
+
+	return response, nil
+}
+
+// lastLedgerStock returns the FinalStock of the item's latest inventory log (0 if none)
+func lastLedgerStock(tx *gorm.DB, itemID uint) (int, error) {
+	var last models.InventoryLog
+	err := tx.Where("item_id = ?", itemID).Order("id DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return 0, err
+	}
+	return last.FinalStock, nil
+}
+
+// StartLedgerCheckJob runs the integrity check on a fixed interval and logs
+// any drift it finds. It never repairs, that stays a confirmed manual action.
+func StartLedgerCheckJob(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			report, err := NewLedgerService().CheckIntegrity(0)
+			if err != nil {
+				log.Println("Ledger check failed:", err)
+				continue
+			}
+
+			if report.ItemsWithIssues > 0 {
+				log.Printf("Ledger check: %d of %d items out of sync with their inventory log\n", report.ItemsWithIssues, report.ItemsChecked)
+			}
+		}
+	}()
+}
@@ -6,7 +6,6 @@ import (
 	"kd-api/src/dtos"
 	"kd-api/src/models"
 	"kd-api/src/utils/pagination"
-	"strconv"
 	"time"
 
 	"gorm.io/gorm"
@@ -41,10 +40,14 @@ func (s *poBillService) CreatePOBill(input dtos.CreatePOBillInput) (*models.POBi
 	var bill models.POBill
 
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Bills without the supplier's own invoice number get one of ours
 		invoiceNum := input.InvoiceNumber
 		if invoiceNum == "" {
-			// Use temporary invoice number to satisfy NOT NULL constraint
-			invoiceNum = "PO-TEMP-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+			number, err := nextDocumentNumber(tx, config.DocPOBill, time.Now())
+			if err != nil {
+				return err
+			}
+			invoiceNum = number
 		}
 
 		bill = models.POBill{
@@ -58,18 +61,7 @@ func (s *poBillService) CreatePOBill(input dtos.CreatePOBillInput) (*models.POBi
 			Notes:         input.Notes,
 		}
 
-		if err := tx.Create(&bill).Error; err != nil {
-			return err
-		}
-
-		if input.InvoiceNumber == "" {
-			bill.InvoiceNumber = "PO-" + strconv.FormatUint(uint64(bill.ID), 10)
-			if err := tx.Save(&bill).Error; err != nil {
-				return err
-			}
-		}
-
-		return nil
+		return tx.Create(&bill).Error
 	})
 
 	if err != nil {
@@ -0,0 +1,505 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PromotionService interface {
+	GetPromotions(filter dtos.PromotionFilter) (*dtos.PromotionListResponse, error)
+	GetPromotionByID(id uint) (*models.Promotion, error)
+	CreatePromotion(input dtos.CreatePromotionInput) (*models.Promotion, error)
+	UpdatePromotion(id uint, input dtos.UpdatePromotionInput) (*models.Promotion, error)
+	DeletePromotion(id uint) error
+	GetPromotionCost(filter dtos.PromotionCostFilter) (*dtos.PromotionCostResponse, error)
+}
+
+type promotionService struct{}
+
+func NewPromotionService() PromotionService {
+	return &promotionService{}
+}
+
+func (s *promotionService) GetPromotions(filter dtos.PromotionFilter) (*dtos.PromotionListResponse, error) {
+	var promotions []models.Promotion
+	var total int64
+
+	db := config.DB.Model(&models.Promotion{})
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		db = db.Where("(LOWER(name) LIKE ? OR coupon_code = ?)", "%"+strings.ToLower(search)+"%", normalizeCouponCode(search))
+	}
+	if filter.Type != "" {
+		db = db.Where("type = ?", filter.Type)
+	}
+	if filter.ActiveOnly {
+		now := time.Now()
+		db = db.Where("is_active = ? AND (starts_at IS NULL OR starts_at <= ?) AND (ends_at IS NULL OR ends_at > ?)", true, now, now)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+	offset := (filter.Page - 1) * filter.Limit
+
+	if err := db.Preload("Item").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+
+	return &dtos.PromotionListResponse{
+		Data:       promotions,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+func (s *promotionService) GetPromotionByID(id uint) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := config.DB.Preload("Item").First(&promotion, id).Error; err != nil {
+		return nil, errors.New("promotion not found")
+	}
+	return &promotion, nil
+}
+
+func (s *promotionService) CreatePromotion(input dtos.CreatePromotionInput) (*models.Promotion, error) {
+	promotion := models.Promotion{
+		Name:         strings.TrimSpace(input.Name),
+		Type:         input.Type,
+		DiscountType: "percent",
+		Value:        input.Value,
+		ItemID:       input.ItemID,
+		Category:     input.Category,
+		BuyQuantity:  input.BuyQuantity,
+		FreeQuantity: input.FreeQuantity,
+		MinSpend:     input.MinSpend,
+		MaxDiscount:  input.MaxDiscount,
+		UsageLimit:   input.UsageLimit,
+		StartsAt:     input.StartsAt,
+		EndsAt:       input.EndsAt,
+		IsActive:     true,
+	}
+	if input.DiscountType != nil {
+		promotion.DiscountType = *input.DiscountType
+	}
+	if input.CouponCode != nil {
+		if code := normalizeCouponCode(*input.CouponCode); code != "" {
+			promotion.CouponCode = &code
+		}
+	}
+	if input.IsActive != nil {
+		promotion.IsActive = *input.IsActive
+	}
+
+	if err := validatePromotion(&promotion); err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Create(&promotion).Error; err != nil {
+		return nil, err
+	}
+	return s.GetPromotionByID(promotion.ID)
+}
+
+func (s *promotionService) UpdatePromotion(id uint, input dtos.UpdatePromotionInput) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := config.DB.First(&promotion, id).Error; err != nil {
+		return nil, errors.New("promotion not found")
+	}
+
+	if input.Name != nil {
+		promotion.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.DiscountType != nil {
+		promotion.DiscountType = *input.DiscountType
+	}
+	if input.Value != nil {
+		promotion.Value = *input.Value
+	}
+	if input.ItemID != nil {
+		promotion.ItemID = input.ItemID
+		if *input.ItemID == 0 {
+			promotion.ItemID = nil
+		}
+	}
+	if input.Category != nil {
+		promotion.Category = input.Category
+		if strings.TrimSpace(*input.Category) == "" {
+			promotion.Category = nil
+		}
+	}
+	if input.BuyQuantity != nil {
+		promotion.BuyQuantity = *input.BuyQuantity
+	}
+	if input.FreeQuantity != nil {
+		promotion.FreeQuantity = *input.FreeQuantity
+	}
+	if input.MinSpend != nil {
+		promotion.MinSpend = *input.MinSpend
+	}
+	if input.MaxDiscount != nil {
+		promotion.MaxDiscount = input.MaxDiscount
+		if *input.MaxDiscount == 0 {
+			promotion.MaxDiscount = nil
+		}
+	}
+	if input.CouponCode != nil {
+		promotion.CouponCode = nil
+		if code := normalizeCouponCode(*input.CouponCode); code != "" {
+			promotion.CouponCode = &code
+		}
+	}
+	if input.UsageLimit != nil {
+		promotion.UsageLimit = input.UsageLimit
+		if *input.UsageLimit == 0 {
+			promotion.UsageLimit = nil
+		}
+	}
+	if input.StartsAt != nil {
+		promotion.StartsAt = input.StartsAt
+	}
+	if input.EndsAt != nil {
+		promotion.EndsAt = input.EndsAt
+	}
+	if input.IsActive != nil {
+		promotion.IsActive = *input.IsActive
+	}
+
+	if err := validatePromotion(&promotion); err != nil {
+		return nil, err
+	}
+
+	// Drop the preloaded item so Save writes ItemID as set above
+	promotion.Item = nil
+	if err := config.DB.Save(&promotion).Error; err != nil {
+		return nil, err
+	}
+	return s.GetPromotionByID(promotion.ID)
+}
+
+func (s *promotionService) DeletePromotion(id uint) error {
+	var promotion models.Promotion
+	if err := config.DB.First(&promotion, id).Error; err != nil {
+		return errors.New("promotion not found")
+	}
+	return config.DB.Delete(&promotion).Error
+}
+
+// GetPromotionCost sums what each promotion gave away on sales in the period,
+// as granted at the till. Refunds do not give the discount back.
+func (s *promotionService) GetPromotionCost(filter dtos.PromotionCostFilter) (*dtos.PromotionCostResponse, error) {
+	db := config.DB.Model(&models.TransactionPromotion{}).
+		Joins("JOIN transactions ON transactions.id = transaction_promotions.transaction_id").
+		Joins("LEFT JOIN promotions ON promotions.id = transaction_promotions.promotion_id").
+		Where("transactions.status IN ? AND transactions.deleted_at IS NULL", soldStatuses)
+	if filter.StartDate != "" {
+		db = db.Where("transactions.created_at >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		db = db.Where("transactions.created_at <= ?", filter.EndDate+" 23:59:59")
+	}
+
+	response := &dtos.PromotionCostResponse{Promotions: []dtos.PromotionCostLine{}}
+	if err := db.Select(
+		"transaction_promotions.promotion_id, " +
+			"MAX(transaction_promotions.name) AS name, " +
+			"MAX(promotions.type) AS type, " +
+			"COUNT(DISTINCT transaction_promotions.transaction_id) AS transactions, " +
+			"COALESCE(SUM(transaction_promotions.amount), 0) AS cost",
+	).
+		Group("transaction_promotions.promotion_id").
+		Order("cost DESC").
+		Scan(&response.Promotions).Error; err != nil {
+		return nil, err
+	}
+
+	for _, line := range response.Promotions {
+		response.TotalCost += line.Cost
+	}
+
+	return response, nil
+}
+
+// appliedPromotion is one promotion given on a sale, line is -1 for the whole sale
+type appliedPromotion struct {
+	line      int
+	promotion models.Promotion
+	amount    float64
+}
+
+// applyPromotions discounts the sale lines with the promotions running now and returns
+// what was applied plus the sale-level discount. Each line gets its single best item
+// promotion and the sale its best spend threshold, promotions do not stack.
+// Lines with a custom price or a line discount were negotiated at the till and get no promotion.
+func applyPromotions(tx *gorm.DB, lines []models.TransactionItem, items map[uint]models.Item, negotiated map[int]bool, couponCodes []string) ([]appliedPromotion, float64, error) {
+	codes := make([]string, 0, len(couponCodes))
+	seen := make(map[string]bool)
+	for _, code := range couponCodes {
+		code = normalizeCouponCode(code)
+		if code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+
+	now := time.Now()
+	db := tx.Where("is_active = ? AND (starts_at IS NULL OR starts_at <= ?) AND (ends_at IS NULL OR ends_at > ?)", true, now, now)
+	if len(codes) > 0 {
+		db = db.Where("(coupon_code IS NULL OR coupon_code IN ?)", codes)
+	} else {
+		db = db.Where("coupon_code IS NULL")
+	}
+
+	var promotions []models.Promotion
+	if err := db.Find(&promotions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	found := make(map[string]models.Promotion)
+	for _, promotion := range promotions {
+		if promotion.CouponCode != nil {
+			found[*promotion.CouponCode] = promotion
+		}
+	}
+	for _, code := range codes {
+		promotion, ok := found[code]
+		if !ok {
+			return nil, 0, fmt.Errorf("coupon '%s' is not valid", code)
+		}
+		if promotion.UsageLimit != nil && promotion.UsageCount >= *promotion.UsageLimit {
+			return nil, 0, fmt.Errorf("coupon '%s' has reached its usage limit", code)
+		}
+	}
+
+	var applied []appliedPromotion
+	for i := range lines {
+		line := &lines[i]
+		line.PromotionDiscount = 0
+		if negotiated[i] || line.Quantity <= 0 {
+			continue
+		}
+
+		item := items[line.ItemID]
+		bestIndex, bestAmount := -1, 0.0
+		for j, promotion := range promotions {
+			if promotion.Type == "spend_threshold" || !promotionMatchesItem(promotion, item) {
+				continue
+			}
+			if amount := promotionLineDiscount(promotion, *line); amount > bestAmount {
+				bestIndex, bestAmount = j, amount
+			}
+		}
+		if bestIndex < 0 {
+			continue
+		}
+
+		line.PromotionDiscount = bestAmount
+		line.Subtotal = roundMoney(line.Subtotal - bestAmount)
+		applied = append(applied, appliedPromotion{line: i, promotion: promotions[bestIndex], amount: bestAmount})
+	}
+
+	var spend float64
+	for _, line := range lines {
+		spend += line.Subtotal
+	}
+
+	bestIndex, saleDiscount := -1, 0.0
+	for j, promotion := range promotions {
+		if promotion.Type != "spend_threshold" || spend < promotion.MinSpend {
+			continue
+		}
+		amount := promotion.Value
+		if promotion.DiscountType == "percent" {
+			amount = spend * promotion.Value / 100
+			if promotion.MaxDiscount != nil && amount > *promotion.MaxDiscount {
+				amount = *promotion.MaxDiscount
+			}
+		}
+		amount = roundMoney(math.Min(amount, spend))
+		if amount > saleDiscount {
+			bestIndex, saleDiscount = j, amount
+		}
+	}
+	if bestIndex >= 0 {
+		applied = append(applied, appliedPromotion{line: -1, promotion: promotions[bestIndex], amount: saleDiscount})
+	}
+
+	return applied, saleDiscount, nil
+}
+
+// saveTransactionPromotions replaces the promotion rows of a sale. Lines must already have IDs.
+func saveTransactionPromotions(tx *gorm.DB, transaction *models.Transaction, applied []appliedPromotion) error {
+	if err := tx.Where("transaction_id = ?", transaction.ID).Delete(&models.TransactionPromotion{}).Error; err != nil {
+		return err
+	}
+
+	for _, a := range applied {
+		row := models.TransactionPromotion{
+			TransactionID: transaction.ID,
+			PromotionID:   a.promotion.ID,
+			Name:          a.promotion.Name,
+			CouponCode:    a.promotion.CouponCode,
+			Amount:        a.amount,
+		}
+		if a.line >= 0 {
+			lineID := transaction.Items[a.line].ID
+			row.TransactionItemID = &lineID
+		}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consumeCoupons counts a completed sale against the usage limit of every coupon it used
+func consumeCoupons(tx *gorm.DB, transactionID uint) error {
+	var promotionIDs []uint
+	if err := tx.Model(&models.TransactionPromotion{}).
+		Where("transaction_id = ? AND coupon_code IS NOT NULL", transactionID).
+		Distinct().
+		Pluck("promotion_id", &promotionIDs).Error; err != nil {
+		return err
+	}
+
+	for _, promotionID := range promotionIDs {
+		var promotion models.Promotion
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promotion, promotionID).Error; err != nil {
+			return err
+		}
+		if promotion.UsageLimit != nil && promotion.UsageCount >= *promotion.UsageLimit {
+			return fmt.Errorf("coupon '%s' has reached its usage limit", *promotion.CouponCode)
+		}
+		if err := tx.Model(&promotion).Update("usage_count", gorm.Expr("usage_count + 1")).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseCoupons gives back the coupon uses of a sale that was voided or fully refunded
+func releaseCoupons(tx *gorm.DB, transactionID uint) error {
+	var promotionIDs []uint
+	if err := tx.Model(&models.TransactionPromotion{}).
+		Where("transaction_id = ? AND coupon_code IS NOT NULL", transactionID).
+		Distinct().
+		Pluck("promotion_id", &promotionIDs).Error; err != nil {
+		return err
+	}
+	if len(promotionIDs) == 0 {
+		return nil
+	}
+
+	return tx.Model(&models.Promotion{}).
+		Where("id IN ? AND usage_count > 0", promotionIDs).
+		Update("usage_count", gorm.Expr("usage_count - 1")).Error
+}
+
+func promotionMatchesItem(promotion models.Promotion, item models.Item) bool {
+	if promotion.ItemID != nil {
+		return *promotion.ItemID == item.ID
+	}
+	if promotion.Category != nil {
+		return item.Category != nil && strings.EqualFold(*item.Category, *promotion.Category)
+	}
+	return true
+}
+
+// promotionLineDiscount is what an item promotion takes off a line, never more than the line
+func promotionLineDiscount(promotion models.Promotion, line models.TransactionItem) float64 {
+	gross := float64(line.Quantity) * line.Price
+
+	var amount float64
+	switch promotion.Type {
+	case "item_discount":
+		if promotion.DiscountType == "percent" {
+			amount = gross * promotion.Value / 100
+		} else {
+			amount = math.Min(promotion.Value, line.Price) * float64(line.Quantity)
+		}
+	case "buy_x_get_y":
+		if set := promotion.BuyQuantity + promotion.FreeQuantity; set > 0 {
+			free := line.Quantity / set * promotion.FreeQuantity
+			amount = float64(free) * line.Price
+		}
+	}
+
+	return roundMoney(math.Min(amount, gross))
+}
+
+func validatePromotion(promotion *models.Promotion) error {
+	if promotion.Name == "" {
+		return errors.New("invalid promotion: name is required")
+	}
+
+	switch promotion.Type {
+	case "item_discount", "spend_threshold":
+		if promotion.Value <= 0 {
+			return errors.New("invalid promotion: value must be greater than 0")
+		}
+		if promotion.DiscountType == "percent" && promotion.Value > 100 {
+			return errors.New("invalid promotion: percent value cannot exceed 100")
+		}
+		if promotion.Type == "spend_threshold" && promotion.MinSpend <= 0 {
+			return errors.New("invalid promotion: min_spend must be greater than 0")
+		}
+	case "buy_x_get_y":
+		if promotion.BuyQuantity < 1 || promotion.FreeQuantity < 1 {
+			return errors.New("invalid promotion: buy_quantity and free_quantity must be at least 1")
+		}
+	}
+
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return errors.New("invalid promotion: ends_at must be after starts_at")
+	}
+
+	if promotion.ItemID != nil {
+		var count int64
+		if err := config.DB.Model(&models.Item{}).Where("id = ?", *promotion.ItemID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("item not found")
+		}
+	}
+
+	if promotion.CouponCode != nil {
+		var count int64
+		if err := config.DB.Model(&models.Promotion{}).
+			Where("coupon_code = ? AND id != ?", *promotion.CouponCode, promotion.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("coupon code already exists")
+		}
+	}
+
+	return nil
+}
+
+// normalizeCouponCode makes codes case-insensitive, "summer10 " and "SUMMER10" are the same coupon
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"kd-api/src/utils/log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PurchaseOrderService interface {
+	CreatePurchaseOrder(input dtos.CreatePurchaseOrderInput, userID *uint) (*models.PurchaseOrder, error)
+	CreateFromSuggestions(input dtos.CreatePurchaseOrderFromSuggestionsInput, userID *uint) (*models.PurchaseOrder, error)
+	GetPurchaseOrders(filter dtos.PurchaseOrderFilter) (*dtos.PurchaseOrderListResponse, error)
+	GetPurchaseOrderByID(id uint) (*models.PurchaseOrder, error)
+	UpdateStatus(id uint, input dtos.UpdatePurchaseOrderStatusInput, userID *uint, clientIP string) (*models.PurchaseOrder, error)
+}
+
+type purchaseOrderService struct{}
+
+func NewPurchaseOrderService() PurchaseOrderService {
+	return &purchaseOrderService{}
+}
+
+func (s *purchaseOrderService) CreatePurchaseOrder(input dtos.CreatePurchaseOrderInput, userID *uint) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var supplier models.Supplier
+		if err := tx.First(&supplier, input.SupplierID).Error; err != nil {
+			return errors.New("supplier not found")
+		}
+
+		var lines []models.PurchaseOrderLine
+		var total float64
+		for _, l := range input.Lines {
+			var item models.Item
+			if err := tx.First(&item, l.ItemID).Error; err != nil {
+				return fmt.Errorf("item %d not found", l.ItemID)
+			}
+
+			unitCost := item.BuyPrice
+			if l.UnitCost != nil {
+				unitCost = *l.UnitCost
+			}
+
+			subtotal := float64(l.Quantity) * unitCost
+			total += subtotal
+			lines = append(lines, models.PurchaseOrderLine{
+				ItemID:   item.ID,
+				Quantity: l.Quantity,
+				UnitCost: unitCost,
+				Subtotal: subtotal,
+			})
+		}
+
+		number, err := nextDocumentNumber(tx, config.DocPurchaseOrder, time.Now())
+		if err != nil {
+			return err
+		}
+
+		order = models.PurchaseOrder{
+			Number:      number,
+			SupplierID:  supplier.ID,
+			Status:      "draft",
+			Total:       total,
+			Note:        input.Note,
+			CreatedByID: userID,
+			Lines:       lines,
+		}
+		return tx.Create(&order).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPurchaseOrderByID(order.ID)
+}
+
+// CreateFromSuggestions drafts a PO for one supplier straight from the reorder list
+func (s *purchaseOrderService) CreateFromSuggestions(input dtos.CreatePurchaseOrderFromSuggestionsInput, userID *uint) (*models.PurchaseOrder, error) {
+	lines := input.Lines
+	if len(lines) == 0 {
+		suggestions, err := NewReorderService().GetSuggestions(dtos.ReorderFilter{
+			WindowDays: input.WindowDays,
+			CoverDays:  input.CoverDays,
+			SupplierID: input.SupplierID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range suggestions.Suppliers {
+			if group.SupplierID == nil || *group.SupplierID != input.SupplierID {
+				continue
+			}
+			for _, suggestion := range group.Items {
+				unitCost := suggestion.UnitCost
+				lines = append(lines, dtos.PurchaseOrderLineInput{
+					ItemID:   suggestion.ItemID,
+					Quantity: suggestion.SuggestedQuantity,
+					UnitCost: &unitCost,
+				})
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("no reorder suggestions for this supplier")
+	}
+
+	return s.CreatePurchaseOrder(dtos.CreatePurchaseOrderInput{
+		SupplierID: input.SupplierID,
+		Note:       input.Note,
+		Lines:      lines,
+	}, userID)
+}
+
+func (s *purchaseOrderService) GetPurchaseOrders(filter dtos.PurchaseOrderFilter) (*dtos.PurchaseOrderListResponse, error) {
+	var orders []models.PurchaseOrder
+	var total int64
+
+	db := config.DB.Model(&models.PurchaseOrder{})
+	if filter.SupplierID != 0 {
+		db = db.Where("supplier_id = ?", filter.SupplierID)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+	offset := (filter.Page - 1) * filter.Limit
+
+	if err := db.Preload("Supplier").Preload("Lines.Item").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	return &dtos.PurchaseOrderListResponse{
+		Data:       orders,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+func (s *purchaseOrderService) GetPurchaseOrderByID(id uint) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	if err := config.DB.Preload("Supplier").Preload("Lines.Item").First(&order, id).Error; err != nil {
+		return nil, errors.New("purchase order not found")
+	}
+	return &order, nil
+}
+
+// UpdateStatus moves a PO through draft -> ordered -> received (or cancelled).
+// Receiving restocks every line on the inventory ledger.
+func (s *purchaseOrderService) UpdateStatus(id uint, input dtos.UpdatePurchaseOrderStatusInput, userID *uint, clientIP string) (*models.PurchaseOrder, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.PurchaseOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&order, id).Error; err != nil {
+			return errors.New("purchase order not found")
+		}
+
+		allowed := map[string][]string{
+			"draft":   {"ordered", "cancelled"},
+			"ordered": {"received", "cancelled"},
+		}
+		valid := false
+		for _, next := range allowed[order.Status] {
+			if next == input.Status {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("cannot change purchase order from %s to %s", order.Status, input.Status)
+		}
+
+		now := time.Now()
+		switch input.Status {
+		case "ordered":
+			order.OrderedAt = &now
+		case "received":
+			order.ReceivedAt = &now
+
+			invService := NewInventoryService()
+			for _, line := range order.Lines {
+				var item models.Item
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, line.ItemID).Error; err != nil {
+					return err
+				}
+
+				// Goods bought at a new cost move the buy price, valuations from now on use it
+				if line.UnitCost > 0 && line.UnitCost != item.BuyPrice {
+					oldCopy := item
+					item.BuyPrice = line.UnitCost
+					if err := tx.Model(&item).Update("buy_price", item.BuyPrice).Error; err != nil {
+						return err
+					}
+					if err := recordItemCost(tx, item.ID, item.BuyPrice, userID); err != nil {
+						return err
+					}
+					description := fmt.Sprintf("Buy price of '%s' updated on receiving purchase order %s", item.Name, order.Number)
+					if err := log.CreateItemAuditLog(tx, "update", item.ID, &oldCopy, &item, userID, clientIP, description); err != nil {
+						return err
+					}
+				}
+
+				if item.IsStockManaged == nil || !*item.IsStockManaged {
+					continue
+				}
+
+				item.Stock += line.Quantity
+				if err := tx.Save(&item).Error; err != nil {
+					return err
+				}
+
+				if err := invService.LogStockChange(tx, item.ID, line.Quantity, "restock", order.Number, userID, "Received purchase order"); err != nil {
+					return err
+				}
+			}
+		}
+
+		order.Status = input.Status
+		return tx.Omit("Lines").Save(&order).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPurchaseOrderByID(id)
+}
@@ -0,0 +1,489 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"kd-api/src/utils/common"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type QuotationService interface {
+	CreateQuotation(input dtos.CreateQuotationInput, userID *uint, role string) (*models.Quotation, error)
+	GetQuotations(filter dtos.QuotationFilter) (*dtos.QuotationListResponse, error)
+	GetQuotationByID(id uint) (*models.Quotation, error)
+	UpdateQuotation(id uint, input dtos.UpdateQuotationInput, role string) (*models.Quotation, error)
+	CancelQuotation(id uint) (*models.Quotation, error)
+	ConvertQuotation(id uint, input dtos.ConvertQuotationInput, userID *uint, role string, clientIP string) (*models.Transaction, []string, error)
+	GetQuotationStats(filter dtos.QuotationStatsFilter) (*dtos.QuotationStatsResponse, error)
+}
+
+type quotationService struct{}
+
+func NewQuotationService() QuotationService {
+	return &quotationService{}
+}
+
+// CreateQuotation prices the lines at today's prices and discounts. Nothing is reserved,
+// stock is only checked when the quotation becomes a sale.
+func (s *quotationService) CreateQuotation(input dtos.CreateQuotationInput, userID *uint, role string) (*models.Quotation, error) {
+	validUntil, err := parseValidUntil(input.ValidUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	var quotation models.Quotation
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := validateTransactionCustomer(tx, &input.CustomerID, input.CustomerAddressID); err != nil {
+			return err
+		}
+
+		quotation = models.Quotation{
+			Status:            "open",
+			CustomerID:        input.CustomerID,
+			CustomerAddressID: input.CustomerAddressID,
+			TransactionType:   "onsite",
+			ValidUntil:        validUntil,
+			Note:              input.Note,
+			CreatedByID:       userID,
+		}
+		if input.TransactionType != nil {
+			quotation.TransactionType = *input.TransactionType
+		}
+		if input.Discount != nil {
+			quotation.Discount = *input.Discount
+		}
+		if err := priceQuotation(tx, &quotation, input.Items, role); err != nil {
+			return err
+		}
+
+		number, err := nextDocumentNumber(tx, config.DocQuotation, time.Now())
+		if err != nil {
+			return err
+		}
+		quotation.Number = number
+
+		return tx.Create(&quotation).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetQuotationByID(quotation.ID)
+}
+
+func (s *quotationService) GetQuotations(filter dtos.QuotationFilter) (*dtos.QuotationListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+
+	today := time.Now().Format("2006-01-02")
+	db := config.DB.Model(&models.Quotation{})
+	switch filter.Status {
+	case "":
+	case "open":
+		db = db.Where("status = ? AND valid_until >= ?", "open", today)
+	case "expired":
+		db = db.Where("status = ? AND valid_until < ?", "open", today)
+	default:
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.CustomerID != nil {
+		db = db.Where("customer_id = ?", *filter.CustomerID)
+	}
+	if filter.Number != "" {
+		db = db.Where("number = ?", filter.Number)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var quotations []models.Quotation
+	if err := db.Preload("Lines.Item").
+		Preload("Customer").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset((filter.Page - 1) * filter.Limit).
+		Find(&quotations).Error; err != nil {
+		return nil, err
+	}
+	for i := range quotations {
+		markQuotationExpiry(&quotations[i])
+	}
+
+	return &dtos.QuotationListResponse{
+		Data:       quotations,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+func (s *quotationService) GetQuotationByID(id uint) (*models.Quotation, error) {
+	var quotation models.Quotation
+	if err := config.DB.
+		Preload("Lines.Item").
+		Preload("Customer").
+		Preload("CustomerAddress").
+		Preload("CreatedBy").
+		First(&quotation, id).Error; err != nil {
+		return nil, errors.New("quotation not found")
+	}
+	markQuotationExpiry(&quotation)
+	return &quotation, nil
+}
+
+// UpdateQuotation changes an open quotation. New items are priced at today's prices,
+// a new discount alone keeps the quoted lines as they are.
+func (s *quotationService) UpdateQuotation(id uint, input dtos.UpdateQuotationInput, role string) (*models.Quotation, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var quotation models.Quotation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&quotation, id).Error; err != nil {
+			return errors.New("quotation not found")
+		}
+		if quotation.Status != "open" {
+			return errors.New("only open quotations can be changed")
+		}
+
+		if input.ValidUntil != nil {
+			validUntil, err := parseValidUntil(input.ValidUntil)
+			if err != nil {
+				return err
+			}
+			quotation.ValidUntil = validUntil
+		}
+		if input.CustomerAddressID != nil {
+			if *input.CustomerAddressID == 0 {
+				quotation.CustomerAddressID = nil
+			} else {
+				if err := validateTransactionCustomer(tx, &quotation.CustomerID, input.CustomerAddressID); err != nil {
+					return err
+				}
+				quotation.CustomerAddressID = input.CustomerAddressID
+			}
+		}
+		if input.TransactionType != nil {
+			quotation.TransactionType = *input.TransactionType
+		}
+		if input.Note != nil {
+			quotation.Note = input.Note
+		}
+		if input.Discount != nil {
+			quotation.Discount = *input.Discount
+		}
+
+		if len(input.Items) > 0 {
+			if err := tx.Where("quotation_id = ?", quotation.ID).Delete(&models.QuotationLine{}).Error; err != nil {
+				return err
+			}
+			if err := priceQuotation(tx, &quotation, input.Items, role); err != nil {
+				return err
+			}
+			for i := range quotation.Lines {
+				quotation.Lines[i].QuotationID = quotation.ID
+			}
+			if err := tx.Create(&quotation.Lines).Error; err != nil {
+				return err
+			}
+		} else {
+			quotationTotals(&quotation)
+			for _, line := range quotation.Lines {
+				if err := tx.Model(&line).Updates(map[string]any{"tax_base": line.TaxBase, "tax_amount": line.TaxAmount}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Omit("Lines").Save(&quotation).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetQuotationByID(id)
+}
+
+func (s *quotationService) CancelQuotation(id uint) (*models.Quotation, error) {
+	result := config.DB.Model(&models.Quotation{}).
+		Where("id = ? AND status = ?", id, "open").
+		Update("status", "cancelled")
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := s.GetQuotationByID(id); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("only open quotations can be cancelled")
+	}
+
+	return s.GetQuotationByID(id)
+}
+
+// ConvertQuotation sells the quoted lines to the quoted customer as a draft or completed sale.
+// It goes through CreateTransaction, so stock, payments and numbering work as for any sale.
+func (s *quotationService) ConvertQuotation(id uint, input dtos.ConvertQuotationInput, userID *uint, role string, clientIP string) (*models.Transaction, []string, error) {
+	quotation, err := s.GetQuotationByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sale := dtos.CreateTransactionInput{
+		Status:            input.Status,
+		PaymentAmount:     input.PaymentAmount,
+		PaymentType:       input.PaymentType,
+		Payments:          input.Payments,
+		Note:              quotation.Note,
+		TransactionType:   &quotation.TransactionType,
+		Discount:          &quotation.Discount,
+		CustomerID:        &quotation.CustomerID,
+		CustomerAddressID: quotation.CustomerAddressID,
+		CouponCodes:       input.CouponCodes,
+		QuotationID:       &quotation.ID,
+		PaymentSchedule:   input.PaymentSchedule,
+	}
+	if input.Note != nil {
+		sale.Note = input.Note
+	}
+	if input.CustomerAddressID != nil {
+		sale.CustomerAddressID = input.CustomerAddressID
+	}
+	for _, line := range quotation.Lines {
+		sale.Items = append(sale.Items, dtos.TransactionItemInput{ItemID: line.ItemID, Quantity: line.Quantity})
+	}
+
+	return NewTransactionService().CreateTransaction(sale, userID, role, clientIP)
+}
+
+// GetQuotationStats counts the quotations made in a period and how many became sales
+func (s *quotationService) GetQuotationStats(filter dtos.QuotationStatsFilter) (*dtos.QuotationStatsResponse, error) {
+	today := time.Now().Format("2006-01-02")
+	if filter.StartDate == "" {
+		filter.StartDate = time.Now().AddDate(0, -1, 0).Format("2006-01-02")
+	}
+	if filter.EndDate == "" {
+		filter.EndDate = today
+	}
+	if _, err := time.Parse("2006-01-02", filter.StartDate); err != nil {
+		return nil, errors.New("invalid start_date, use YYYY-MM-DD")
+	}
+	if _, err := time.Parse("2006-01-02", filter.EndDate); err != nil {
+		return nil, errors.New("invalid end_date, use YYYY-MM-DD")
+	}
+
+	period := func() *gorm.DB {
+		return config.DB.Model(&models.Quotation{}).
+			Where("quotations.created_at >= ? AND quotations.created_at <= ?", filter.StartDate, filter.EndDate+" 23:59:59")
+	}
+
+	var totals struct {
+		Quotations        int64
+		Open              int64
+		Expired           int64
+		Converted         int64
+		Cancelled         int64
+		QuotedValue       float64
+		ConvertedValue    float64
+		AvgHoursToConvert float64
+	}
+	if err := period().Select(`
+		COUNT(*) AS quotations,
+		COALESCE(SUM(CASE WHEN status = 'open' AND valid_until >= ? THEN 1 ELSE 0 END), 0) AS open,
+		COALESCE(SUM(CASE WHEN status = 'open' AND valid_until < ? THEN 1 ELSE 0 END), 0) AS expired,
+		COALESCE(SUM(CASE WHEN status = 'converted' THEN 1 ELSE 0 END), 0) AS converted,
+		COALESCE(SUM(CASE WHEN status = 'cancelled' THEN 1 ELSE 0 END), 0) AS cancelled,
+		COALESCE(SUM(total), 0) AS quoted_value,
+		COALESCE(SUM(CASE WHEN status = 'converted' THEN total ELSE 0 END), 0) AS converted_value,
+		COALESCE(AVG(CASE WHEN status = 'converted' THEN TIMESTAMPDIFF(HOUR, created_at, converted_at) END), 0) AS avg_hours_to_convert`,
+		today, today).
+		Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+
+	byCreator := []dtos.QuotationCreatorStats{}
+	if err := period().
+		Select(`quotations.created_by_id, COALESCE(users.username, '') AS username,
+			COUNT(*) AS quotations,
+			COALESCE(SUM(CASE WHEN quotations.status = 'converted' THEN 1 ELSE 0 END), 0) AS converted`).
+		Joins("LEFT JOIN users ON users.id = quotations.created_by_id").
+		Group("quotations.created_by_id, users.username").
+		Order("converted DESC, quotations DESC").
+		Scan(&byCreator).Error; err != nil {
+		return nil, err
+	}
+	for i := range byCreator {
+		if byCreator[i].Quotations > 0 {
+			byCreator[i].ConversionRate = roundMoney(float64(byCreator[i].Converted) / float64(byCreator[i].Quotations) * 100)
+		}
+	}
+
+	response := &dtos.QuotationStatsResponse{
+		StartDate:        filter.StartDate,
+		EndDate:          filter.EndDate,
+		Quotations:       totals.Quotations,
+		Open:             totals.Open,
+		Expired:          totals.Expired,
+		Converted:        totals.Converted,
+		Cancelled:        totals.Cancelled,
+		QuotedValue:      roundMoney(totals.QuotedValue),
+		ConvertedValue:   roundMoney(totals.ConvertedValue),
+		AvgDaysToConvert: roundMoney(totals.AvgHoursToConvert / 24),
+		ByCreator:        byCreator,
+	}
+	if decided := totals.Converted + totals.Cancelled + totals.Expired; decided > 0 {
+		response.ConversionRate = roundMoney(float64(totals.Converted) / float64(decided) * 100)
+	}
+
+	return response, nil
+}
+
+// priceQuotation prices the requested items like sale lines and sets the quotation's lines and totals
+func priceQuotation(tx *gorm.DB, quotation *models.Quotation, inputs []dtos.TransactionItemInput, role string) error {
+	quotation.Lines = nil
+	seen := make(map[uint]bool)
+	for _, input := range inputs {
+		if input.Quantity <= 0 {
+			return fmt.Errorf("invalid quantity for item %d", input.ItemID)
+		}
+		if seen[input.ItemID] {
+			return fmt.Errorf("item %d is quoted more than once", input.ItemID)
+		}
+		seen[input.ItemID] = true
+
+		var item models.Item
+		if err := tx.First(&item, input.ItemID).Error; err != nil {
+			return fmt.Errorf("item %d not found", input.ItemID)
+		}
+		line, err := priceSaleLine(item, input, role)
+		if err != nil {
+			return err
+		}
+		quotation.Lines = append(quotation.Lines, models.QuotationLine{
+			ItemID:         line.ItemID,
+			Quantity:       line.Quantity,
+			ListPrice:      line.ListPrice,
+			Price:          line.Price,
+			LineDiscount:   line.LineDiscount,
+			DiscountReason: line.DiscountReason,
+			Subtotal:       line.Subtotal,
+			TaxRate:        line.TaxRate,
+		})
+	}
+
+	quotationTotals(quotation)
+	return nil
+}
+
+// quotationTotals works out PPN and the total the same way a sale of the lines would
+func quotationTotals(quotation *models.Quotation) {
+	sale := models.Transaction{TaxExclusive: !config.PricesIncludeTax()}
+	var gross float64
+	for _, line := range quotation.Lines {
+		sale.Items = append(sale.Items, models.TransactionItem{Subtotal: line.Subtotal, TaxRate: line.TaxRate})
+		gross += line.Subtotal
+	}
+	sale.Total = max(gross-quotation.Discount, 0)
+	applyTransactionTax(&sale)
+
+	for i := range quotation.Lines {
+		quotation.Lines[i].TaxBase = sale.Items[i].TaxBase
+		quotation.Lines[i].TaxAmount = sale.Items[i].TaxAmount
+	}
+	quotation.TaxExclusive = sale.TaxExclusive
+	quotation.TaxBase = sale.TaxBase
+	quotation.TaxAmount = sale.TaxAmount
+	quotation.Total = sale.Total
+}
+
+// quotedSaleLine is a sale line at the quoted unit price and tax rate. Selling less than quoted scales
+// the quoted line discount with it, selling more is turned down by CreateTransaction.
+func quotedSaleLine(item models.Item, quoted models.QuotationLine, quantity int) models.TransactionItem {
+	line := models.TransactionItem{
+		ItemID:    item.ID,
+		Quantity:  quantity,
+		ListPrice: quoted.ListPrice,
+		Price:     quoted.Price,
+		Subtotal:  roundMoney(float64(quantity) * quoted.Price),
+		TaxRate:   quoted.TaxRate,
+	}
+	if quoted.LineDiscount > 0 && quoted.Quantity > 0 {
+		line.LineDiscount = roundMoney(quoted.LineDiscount * float64(quantity) / float64(quoted.Quantity))
+		line.DiscountReason = quoted.DiscountReason
+		line.Subtotal = roundMoney(line.Subtotal - line.LineDiscount)
+	}
+	return line
+}
+
+// quotationForSale locks the quotation a sale is made from. It must be open and valid,
+// unless it was already converted into this very sale (a draft being edited or completed).
+func quotationForSale(tx *gorm.DB, quotationID uint, transactionID uint) (*models.Quotation, error) {
+	var quotation models.Quotation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&quotation, quotationID).Error; err != nil {
+		return nil, errors.New("quotation not found")
+	}
+
+	if transactionID != 0 && quotation.Status == "converted" && common.GetUintValue(quotation.TransactionID) == transactionID {
+		return &quotation, nil
+	}
+	switch quotation.Status {
+	case "converted":
+		return nil, errors.New("quotation already converted")
+	case "cancelled":
+		return nil, errors.New("quotation is cancelled")
+	}
+	markQuotationExpiry(&quotation)
+	if quotation.Expired {
+		return nil, errors.New("quotation has expired")
+	}
+	return &quotation, nil
+}
+
+func markQuotationConverted(tx *gorm.DB, quotation *models.Quotation, transactionID uint) error {
+	now := time.Now()
+	return tx.Model(&models.Quotation{}).Where("id = ?", quotation.ID).Updates(map[string]any{
+		"status":         "converted",
+		"transaction_id": transactionID,
+		"converted_at":   now,
+	}).Error
+}
+
+// reopenQuotation puts back a quotation whose draft sale was deleted
+func reopenQuotation(tx *gorm.DB, transactionID uint) error {
+	return tx.Model(&models.Quotation{}).
+		Where("transaction_id = ? AND status = ?", transactionID, "converted").
+		Updates(map[string]any{"status": "open", "transaction_id": nil, "converted_at": nil}).Error
+}
+
+func markQuotationExpiry(quotation *models.Quotation) {
+	quotation.Expired = quotation.Status == "open" &&
+		quotation.ValidUntil.Format("2006-01-02") < time.Now().Format("2006-01-02")
+}
+
+// parseValidUntil reads a YYYY-MM-DD validity date, nil gives the default validity from today
+func parseValidUntil(value *string) (time.Time, error) {
+	today, _ := time.ParseInLocation("2006-01-02", time.Now().Format("2006-01-02"), time.Local)
+	if value == nil || *value == "" {
+		return today.AddDate(0, 0, config.QuotationValidDays()), nil
+	}
+	validUntil, err := time.ParseInLocation("2006-01-02", *value, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("invalid valid_until, use YYYY-MM-DD")
+	}
+	if validUntil.Before(today) {
+		return time.Time{}, errors.New("valid_until cannot be in the past")
+	}
+	return validUntil, nil
+}
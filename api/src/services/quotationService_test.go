@@ -0,0 +1,123 @@
+package services
+
+import (
+	"testing"
+
+	"kd-api/src/models"
+)
+
+func TestQuotationTotals(t *testing.T) {
+	tests := []struct {
+		name          string
+		includeTax    string
+		discount      float64
+		lines         []models.QuotationLine
+		wantExclusive bool
+		wantBase      float64
+		wantTax       float64
+		wantTotal     float64
+	}{
+		{
+			name:       "inclusive prices",
+			includeTax: "true",
+			lines:      []models.QuotationLine{{Subtotal: 222, TaxRate: 11}},
+			wantBase:   200,
+			wantTax:    22,
+			wantTotal:  222,
+		},
+		{
+			name:          "exclusive prices with a quotation discount",
+			includeTax:    "false",
+			discount:      100,
+			lines:         []models.QuotationLine{{Subtotal: 200, TaxRate: 11}},
+			wantExclusive: true,
+			wantBase:      100,
+			wantTax:       11,
+			wantTotal:     111,
+		},
+		{
+			name:       "discount larger than the lines",
+			includeTax: "true",
+			discount:   500,
+			lines:      []models.QuotationLine{{Subtotal: 222, TaxRate: 11}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PRICES_INCLUDE_TAX", tt.includeTax)
+			quotation := models.Quotation{Discount: tt.discount, Lines: tt.lines}
+			quotationTotals(&quotation)
+
+			if quotation.TaxExclusive != tt.wantExclusive {
+				t.Errorf("tax exclusive %t, want %t", quotation.TaxExclusive, tt.wantExclusive)
+			}
+			if quotation.TaxBase != tt.wantBase || quotation.TaxAmount != tt.wantTax || quotation.Total != tt.wantTotal {
+				t.Errorf("base %.2f, tax %.2f, total %.2f, want %.2f, %.2f, %.2f",
+					quotation.TaxBase, quotation.TaxAmount, quotation.Total, tt.wantBase, tt.wantTax, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestQuotedSaleLine(t *testing.T) {
+	exempt := false
+	item := models.Item{ID: 1, Name: "Semen", Price: 12, IsTaxable: &exempt}
+	reason := "Project price"
+
+	tests := []struct {
+		name         string
+		quoted       models.QuotationLine
+		quantity     int
+		wantDiscount float64
+		wantSubtotal float64
+	}{
+		{
+			name:         "full quoted quantity keeps the whole discount",
+			quoted:       models.QuotationLine{Quantity: 10, ListPrice: 12, Price: 10, LineDiscount: 20, DiscountReason: &reason, TaxRate: 11},
+			quantity:     10,
+			wantDiscount: 20,
+			wantSubtotal: 80,
+		},
+		{
+			name:         "half the quantity gets half the discount",
+			quoted:       models.QuotationLine{Quantity: 10, ListPrice: 12, Price: 10, LineDiscount: 20, DiscountReason: &reason, TaxRate: 11},
+			quantity:     5,
+			wantDiscount: 10,
+			wantSubtotal: 40,
+		},
+		{
+			name:         "scaled discount is rounded to cents",
+			quoted:       models.QuotationLine{Quantity: 3, ListPrice: 12, Price: 10, LineDiscount: 10, DiscountReason: &reason, TaxRate: 11},
+			quantity:     1,
+			wantDiscount: 3.33,
+			wantSubtotal: 6.67,
+		},
+		{
+			name:         "no line discount",
+			quoted:       models.QuotationLine{Quantity: 10, ListPrice: 12, Price: 10, TaxRate: 11},
+			quantity:     3,
+			wantSubtotal: 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := quotedSaleLine(item, tt.quoted, tt.quantity)
+
+			if line.LineDiscount != tt.wantDiscount || line.Subtotal != tt.wantSubtotal {
+				t.Errorf("discount %.2f, subtotal %.2f, want %.2f, %.2f", line.LineDiscount, line.Subtotal, tt.wantDiscount, tt.wantSubtotal)
+			}
+			if line.Price != tt.quoted.Price || line.ListPrice != tt.quoted.ListPrice {
+				t.Errorf("price %.2f (list %.2f), want the quoted %.2f (list %.2f)", line.Price, line.ListPrice, tt.quoted.Price, tt.quoted.ListPrice)
+			}
+			// The quoted rate stands even though the item has since become exempt
+			if line.TaxRate != tt.quoted.TaxRate {
+				t.Errorf("tax rate %.2f, want the quoted %.2f", line.TaxRate, tt.quoted.TaxRate)
+			}
+			if (line.DiscountReason != nil) != (tt.wantDiscount > 0) {
+				t.Errorf("discount reason %v with discount %.2f", line.DiscountReason, line.LineDiscount)
+			}
+		})
+	}
+}
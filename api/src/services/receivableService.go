@@ -0,0 +1,451 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"kd-api/src/utils/log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReceivableService interface {
+	ReceivePayment(customerID uint, input dtos.CustomerPaymentInput, userID *uint, clientIP string) (*models.CustomerPayment, error)
+	GetPayments(customerID uint, filter dtos.CustomerPaymentFilter) (*dtos.CustomerPaymentListResponse, error)
+	GetStatement(customerID uint, filter dtos.StatementFilter) (*dtos.CustomerStatementResponse, error)
+	GetAgingReport() (*dtos.AgingReportResponse, error)
+}
+
+type receivableService struct{}
+
+func NewReceivableService() ReceivableService {
+	return &receivableService{}
+}
+
+// ReceivePayment books money from a customer against their open credit sales.
+// Without explicit allocations the oldest due invoices are settled first.
+func (s *receivableService) ReceivePayment(customerID uint, input dtos.CustomerPaymentInput, userID *uint, clientIP string) (*models.CustomerPayment, error) {
+	var payment models.CustomerPayment
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var customer models.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, customerID).Error; err != nil {
+			return errors.New("customer not found")
+		}
+
+		var invoices []models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("customer_id = ? AND amount_due > 0 AND status IN ?", customerID, paidStatuses).
+			Order("due_date ASC, id ASC").
+			Find(&invoices).Error; err != nil {
+			return err
+		}
+
+		var outstanding float64
+		for _, invoice := range invoices {
+			outstanding += invoice.AmountDue
+		}
+		if input.Amount > roundMoney(outstanding) {
+			return fmt.Errorf("payment exceeds outstanding balance (%.2f)", outstanding)
+		}
+
+		allocated := make(map[uint]float64)
+		if len(input.Allocations) > 0 {
+			due := make(map[uint]float64, len(invoices))
+			for _, invoice := range invoices {
+				due[invoice.ID] = invoice.AmountDue
+			}
+
+			var sum float64
+			for _, allocation := range input.Allocations {
+				amountDue, ok := due[allocation.TransactionID]
+				if !ok {
+					return fmt.Errorf("transaction %d is not an open invoice of this customer", allocation.TransactionID)
+				}
+				if allocated[allocation.TransactionID]+allocation.Amount > amountDue {
+					return fmt.Errorf("allocation for transaction %d exceeds its amount due (%.2f)", allocation.TransactionID, amountDue)
+				}
+				allocated[allocation.TransactionID] += allocation.Amount
+				sum += allocation.Amount
+			}
+			if roundMoney(sum) != roundMoney(input.Amount) {
+				return errors.New("allocations must add up to the payment amount")
+			}
+		} else {
+			left := input.Amount
+			for _, invoice := range invoices {
+				if left <= 0 {
+					break
+				}
+				share := math.Min(left, invoice.AmountDue)
+				allocated[invoice.ID] = share
+				left -= share
+			}
+		}
+
+		payment = models.CustomerPayment{
+			CustomerID: customerID,
+			Amount:     input.Amount,
+			Method:     input.Method,
+			Reference:  input.Reference,
+			Note:       input.Note,
+			UserID:     userID,
+		}
+		if input.Method == "cash" {
+			sessionID, err := openCashSessionID(tx, userID)
+			if err != nil {
+				return err
+			}
+			payment.CashSessionID = sessionID
+		}
+
+		for _, invoice := range invoices {
+			if share, ok := allocated[invoice.ID]; ok {
+				payment.Allocations = append(payment.Allocations, models.CustomerPaymentAllocation{
+					TransactionID: invoice.ID,
+					Amount:        roundMoney(share),
+				})
+			}
+		}
+
+		number, err := nextDocumentNumber(tx, config.DocCustomerPayment, time.Now())
+		if err != nil {
+			return err
+		}
+		payment.Number = number
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+
+		for i := range invoices {
+			invoice := &invoices[i]
+			share, ok := allocated[invoice.ID]
+			if !ok {
+				continue
+			}
+
+			oldCopy := *invoice
+			invoice.AmountDue = roundMoney(invoice.AmountDue - share)
+			if err := tx.Model(invoice).Update("amount_due", invoice.AmountDue).Error; err != nil {
+				return err
+			}
+
+			description := fmt.Sprintf("Transaction #%d paid %.2f (%s)", invoice.ID, share, payment.Number)
+			if err := log.CreateTransactionAuditLog(
+				tx,
+				"update",
+				invoice.ID,
+				&oldCopy,
+				invoice,
+				userID,
+				clientIP,
+				description,
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (s *receivableService) GetPayments(customerID uint, filter dtos.CustomerPaymentFilter) (*dtos.CustomerPaymentListResponse, error) {
+	var payments []models.CustomerPayment
+	var total int64
+
+	db := config.DB.Model(&models.CustomerPayment{}).Where("customer_id = ?", customerID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+	offset := (filter.Page - 1) * filter.Limit
+
+	if err := db.Preload("Allocations").Preload("User").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+
+	return &dtos.CustomerPaymentListResponse{
+		Data:       payments,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+// GetStatement lists every movement on a customer's account in the period with a running
+// balance: credit sales raise it, payments and refunds credited against unpaid sales lower it.
+func (s *receivableService) GetStatement(customerID uint, filter dtos.StatementFilter) (*dtos.CustomerStatementResponse, error) {
+	var customer models.Customer
+	if err := config.DB.First(&customer, customerID).Error; err != nil {
+		return nil, errors.New("customer not found")
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if filter.StartDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", filter.StartDate, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid start_date, expected YYYY-MM-DD")
+		}
+		start = parsed
+	}
+	if filter.EndDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", filter.EndDate, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid end_date, expected YYYY-MM-DD")
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		return nil, errors.New("end_date must not be before start_date")
+	}
+	endExclusive := end.AddDate(0, 0, 1)
+
+	var lines []dtos.StatementLine
+
+	var invoices []struct {
+		TransactionID uint
+		Number        string
+		Amount        float64
+		CreatedAt     time.Time
+		DueDate       *time.Time
+	}
+	if err := config.DB.Model(&models.TransactionPayment{}).
+		Select("transactions.id AS transaction_id, COALESCE(transactions.number, CONCAT('TX-', transactions.id)) AS number, transaction_payments.amount, transaction_payments.created_at, transactions.due_date").
+		Joins("JOIN transactions ON transactions.id = transaction_payments.transaction_id").
+		Where("transaction_payments.method = ? AND transactions.customer_id = ? AND transactions.status IN ? AND transactions.deleted_at IS NULL AND transaction_payments.created_at < ?",
+			"credit", customerID, paidStatuses, endExclusive).
+		Scan(&invoices).Error; err != nil {
+		return nil, err
+	}
+	invoiceNumbers := make(map[uint]string, len(invoices))
+	for _, invoice := range invoices {
+		invoiceNumbers[invoice.TransactionID] = invoice.Number
+		description := "Credit sale"
+		if invoice.DueDate != nil {
+			description += ", due " + invoice.DueDate.Format("02/01/2006")
+		}
+		lines = append(lines, dtos.StatementLine{
+			Date:        invoice.CreatedAt,
+			Type:        "invoice",
+			Reference:   invoice.Number,
+			Description: description,
+			Debit:       invoice.Amount,
+		})
+	}
+
+	var refunds []models.Refund
+	if err := config.DB.Model(&models.Refund{}).
+		Joins("JOIN transactions ON transactions.id = refunds.transaction_id").
+		Where("transactions.customer_id = ? AND refunds.credited_amount > 0 AND refunds.created_at < ?", customerID, endExclusive).
+		Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+	for _, refund := range refunds {
+		lines = append(lines, dtos.StatementLine{
+			Date:        refund.CreatedAt,
+			Type:        "refund",
+			Reference:   refund.Number,
+			Description: "Return on " + invoiceNumbers[refund.TransactionID],
+			Credit:      refund.CreditedAmount,
+		})
+	}
+
+	var payments []models.CustomerPayment
+	if err := config.DB.Where("customer_id = ? AND created_at < ?", customerID, endExclusive).
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	for _, payment := range payments {
+		lines = append(lines, dtos.StatementLine{
+			Date:        payment.CreatedAt,
+			Type:        "payment",
+			Reference:   payment.Number,
+			Description: "Payment (" + payment.Method + ")",
+			Credit:      payment.Amount,
+		})
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Date.Before(lines[j].Date) })
+
+	response := &dtos.CustomerStatementResponse{
+		Customer:     customer,
+		StartDate:    start.Format("2006-01-02"),
+		EndDate:      end.Format("2006-01-02"),
+		Lines:        []dtos.StatementLine{},
+		OpenInvoices: []dtos.OpenInvoice{},
+	}
+
+	balance := 0.0
+	for _, line := range lines {
+		balance = roundMoney(balance + line.Debit - line.Credit)
+		if line.Date.Before(start) {
+			response.OpeningBalance = balance
+			continue
+		}
+		line.Balance = balance
+		response.Lines = append(response.Lines, line)
+	}
+	response.ClosingBalance = balance
+
+	var open []models.Transaction
+	if err := config.DB.Where("customer_id = ? AND amount_due > 0 AND status IN ?", customerID, paidStatuses).
+		Order("due_date ASC, id ASC").
+		Find(&open).Error; err != nil {
+		return nil, err
+	}
+	for _, invoice := range open {
+		response.OpenInvoices = append(response.OpenInvoices, dtos.OpenInvoice{
+			TransactionID: invoice.ID,
+			Reference:     transactionNumber(&invoice),
+			Date:          invoice.CreatedAt,
+			DueDate:       invoice.DueDate,
+			Total:         invoice.Total,
+			AmountDue:     invoice.AmountDue,
+			DaysOverdue:   daysOverdue(invoice.DueDate, now),
+		})
+	}
+
+	return response, nil
+}
+
+// GetAgingReport buckets every customer's unpaid credit sales by days past their due date
+func (s *receivableService) GetAgingReport() (*dtos.AgingReportResponse, error) {
+	var invoices []models.Transaction
+	if err := config.DB.Preload("Customer").
+		Where("customer_id IS NOT NULL AND amount_due > 0 AND status IN ?", paidStatuses).
+		Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	response := &dtos.AgingReportResponse{AsOf: now, Customers: []dtos.CustomerAging{}}
+	byCustomer := make(map[uint]*dtos.CustomerAging)
+	var order []uint
+
+	for _, invoice := range invoices {
+		customerID := *invoice.CustomerID
+		aging, ok := byCustomer[customerID]
+		if !ok {
+			aging = &dtos.CustomerAging{CustomerID: customerID}
+			if invoice.Customer != nil {
+				aging.Name = invoice.Customer.Name
+				aging.Phone = invoice.Customer.Phone
+				aging.CreditLimit = invoice.Customer.CreditLimit
+			}
+			byCustomer[customerID] = aging
+			order = append(order, customerID)
+		}
+
+		days := daysOverdue(invoice.DueDate, now)
+		addToAgingBucket(&aging.AgingBuckets, days, invoice.AmountDue)
+		addToAgingBucket(&response.Totals, days, invoice.AmountDue)
+	}
+
+	for _, customerID := range order {
+		response.Customers = append(response.Customers, *byCustomer[customerID])
+	}
+	// Largest balances first, that is who gets called
+	sort.SliceStable(response.Customers, func(i, j int) bool {
+		return response.Customers[i].Total > response.Customers[j].Total
+	})
+
+	return response, nil
+}
+
+// reserveCustomerCredit checks a credit sale fits in the customer's credit limit
+// and returns its due date. The customer row stays locked until tx ends, so two
+// tills cannot both use the last of the limit.
+func reserveCustomerCredit(tx *gorm.DB, customerID *uint, amount float64) (*time.Time, error) {
+	if customerID == nil {
+		return nil, errors.New("credit sales require a customer")
+	}
+
+	var customer models.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, *customerID).Error; err != nil {
+		return nil, errors.New("customer not found")
+	}
+	if customer.CreditLimit <= 0 {
+		return nil, errors.New("customer has no credit limit")
+	}
+
+	outstanding, err := customerOutstanding(tx, customer.ID)
+	if err != nil {
+		return nil, err
+	}
+	if roundMoney(outstanding+amount) > customer.CreditLimit {
+		return nil, fmt.Errorf("credit limit exceeded (outstanding %.2f, limit %.2f)", outstanding, customer.CreditLimit)
+	}
+
+	dueDate := time.Now().AddDate(0, 0, customer.PaymentTermDays)
+	return &dueDate, nil
+}
+
+// customerOutstanding sums what a customer still owes on credit sales
+func customerOutstanding(db *gorm.DB, customerID uint) (float64, error) {
+	var outstanding float64
+	err := db.Model(&models.Transaction{}).
+		Select("COALESCE(SUM(amount_due), 0)").
+		Where("customer_id = ? AND status IN ?", customerID, paidStatuses).
+		Scan(&outstanding).Error
+	return outstanding, err
+}
+
+// daysOverdue counts whole days past the due date, 0 when not yet due
+func daysOverdue(dueDate *time.Time, asOf time.Time) int {
+	if dueDate == nil {
+		return 0
+	}
+	due := time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day(), 0, 0, 0, 0, time.Local)
+	today := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.Local)
+	days := int(today.Sub(due).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+func addToAgingBucket(buckets *dtos.AgingBuckets, days int, amount float64) {
+	switch {
+	case days == 0:
+		buckets.Current += amount
+	case days <= 30:
+		buckets.Days1To30 += amount
+	case days <= 60:
+		buckets.Days31To60 += amount
+	case days <= 90:
+		buckets.Days61To90 += amount
+	default:
+		buckets.Over90 += amount
+	}
+	buckets.Total += amount
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// defaultLeadTimeDays applies to items without a supplier
+const defaultLeadTimeDays = 7
+
+type ReorderService interface {
+	GetSuggestions(filter dtos.ReorderFilter) (*dtos.ReorderSuggestionResponse, error)
+}
+
+type reorderService struct{}
+
+func NewReorderService() ReorderService {
+	return &reorderService{}
+}
+
+// GetSuggestions computes average daily demand over a rolling window of completed
+// sales and suggests an order once available + on-order stock drops to the
+// reorder point (demand during the lead time). The suggested quantity tops stock
+// up to cover the lead time plus CoverDays.
+func (s *reorderService) GetSuggestions(filter dtos.ReorderFilter) (*dtos.ReorderSuggestionResponse, error) {
+	if filter.WindowDays < 1 {
+		filter.WindowDays = 30
+	}
+	if filter.WindowDays > 365 {
+		filter.WindowDays = 365
+	}
+	if filter.CoverDays < 1 {
+		filter.CoverDays = 14
+	}
+	since := time.Now().AddDate(0, 0, -filter.WindowDays)
+
+	var items []models.Item
+	query := config.DB.Where("is_stock_managed = ?", true)
+	if filter.SupplierID != 0 {
+		query = query.Where("supplier_id = ?", filter.SupplierID)
+	}
+	if err := query.Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if err := applyAvailability(config.DB, items); err != nil {
+		return nil, err
+	}
+
+	var soldRows []struct {
+		ItemID   uint
+		Quantity int
+	}
+	if err := config.DB.Model(&models.TransactionItem{}).
+		Select("transaction_items.item_id, COALESCE(SUM(transaction_items.quantity - transaction_items.refunded_quantity), 0) AS quantity").
+		Joins("JOIN transactions ON transactions.id = transaction_items.transaction_id").
+		Where("transactions.status IN ? AND transactions.created_at >= ? AND transactions.deleted_at IS NULL", soldStatuses, since).
+		Group("transaction_items.item_id").
+		Scan(&soldRows).Error; err != nil {
+		return nil, err
+	}
+	sold := make(map[uint]int, len(soldRows))
+	for _, row := range soldRows {
+		sold[row.ItemID] = row.Quantity
+	}
+
+	onOrder, err := openPurchaseQuantities()
+	if err != nil {
+		return nil, err
+	}
+
+	var suppliers []models.Supplier
+	if err := config.DB.Find(&suppliers).Error; err != nil {
+		return nil, err
+	}
+	supplierByID := make(map[uint]models.Supplier, len(suppliers))
+	for _, supplier := range suppliers {
+		supplierByID[supplier.ID] = supplier
+	}
+
+	groups := make(map[uint]*dtos.SupplierReorderSuggestion)
+	for _, item := range items {
+		demand := float64(sold[item.ID]) / float64(filter.WindowDays)
+		if demand <= 0 {
+			continue
+		}
+
+		// Key 0 groups items without a (still existing) supplier
+		var groupKey uint
+		leadTime := defaultLeadTimeDays
+		supplierName := "No supplier"
+		if item.SupplierID != nil {
+			if supplier, ok := supplierByID[*item.SupplierID]; ok {
+				groupKey = supplier.ID
+				leadTime = supplier.LeadTimeDays
+				supplierName = supplier.Name
+			}
+		}
+
+		position := item.AvailableStock + onOrder[item.ID]
+		reorderPoint := demand * float64(leadTime)
+		if float64(position) > reorderPoint {
+			continue
+		}
+
+		target := demand * float64(leadTime+filter.CoverDays)
+		quantity := int(math.Ceil(target - float64(position)))
+		if quantity <= 0 {
+			continue
+		}
+
+		daysOfCover := float64(item.AvailableStock) / demand
+		if daysOfCover < 0 {
+			daysOfCover = 0
+		}
+
+		suggestion := dtos.ReorderSuggestion{
+			ItemID:             item.ID,
+			Name:               item.Name,
+			Stock:              item.Stock,
+			Reserved:           item.ReservedStock,
+			Available:          item.AvailableStock,
+			OnOrder:            onOrder[item.ID],
+			SoldInWindow:       sold[item.ID],
+			AverageDailyDemand: math.Round(demand*100) / 100,
+			DaysOfCover:        &daysOfCover,
+			ReorderPoint:       math.Round(reorderPoint*100) / 100,
+			SuggestedQuantity:  quantity,
+			UnitCost:           item.BuyPrice,
+			EstimatedCost:      float64(quantity) * item.BuyPrice,
+		}
+
+		group, ok := groups[groupKey]
+		if !ok {
+			group = &dtos.SupplierReorderSuggestion{
+				SupplierName: supplierName,
+				LeadTimeDays: leadTime,
+				Items:        []dtos.ReorderSuggestion{},
+			}
+			if groupKey != 0 {
+				supplierID := groupKey
+				group.SupplierID = &supplierID
+			}
+			groups[groupKey] = group
+		}
+		group.Items = append(group.Items, suggestion)
+		group.EstimatedCost += suggestion.EstimatedCost
+	}
+
+	response := &dtos.ReorderSuggestionResponse{
+		WindowDays: filter.WindowDays,
+		CoverDays:  filter.CoverDays,
+		Suppliers:  []dtos.SupplierReorderSuggestion{},
+	}
+	for _, group := range groups {
+		// Most urgent first
+		sort.Slice(group.Items, func(i, j int) bool {
+			return *group.Items[i].DaysOfCover < *group.Items[j].DaysOfCover
+		})
+		response.Suppliers = append(response.Suppliers, *group)
+	}
+	sort.Slice(response.Suppliers, func(i, j int) bool {
+		return response.Suppliers[i].SupplierName < response.Suppliers[j].SupplierName
+	})
+
+	return response, nil
+}
+
+// openPurchaseQuantities sums quantities on draft and ordered purchase orders per item
+func openPurchaseQuantities() (map[uint]int, error) {
+	var rows []struct {
+		ItemID   uint
+		Quantity int
+	}
+	if err := config.DB.Model(&models.PurchaseOrderLine{}).
+		Select("purchase_order_lines.item_id, COALESCE(SUM(purchase_order_lines.quantity), 0) AS quantity").
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_lines.purchase_order_id").
+		Where("purchase_orders.status IN ?", []string{"draft", "ordered"}).
+		Group("purchase_order_lines.item_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		quantities[row.ItemID] = row.Quantity
+	}
+	return quantities, nil
+}
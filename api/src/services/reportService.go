@@ -0,0 +1,695 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"kd-api/src/utils/common"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ABC thresholds on the cumulative share of the chosen metric
+const (
+	abcClassALimit = 80.0
+	abcClassBLimit = 95.0
+)
+
+type ReportService interface {
+	GetStockAnalysis(filter dtos.StockAnalysisFilter) (*dtos.StockAnalysisResponse, error)
+	ExportStockAnalysis(writer io.Writer, report *dtos.StockAnalysisResponse) error
+	GetPaymentSummary(filter dtos.PaymentSummaryFilter) (*dtos.PaymentSummaryResponse, error)
+	GetDiscountReport(filter dtos.DiscountReportFilter) (*dtos.DiscountReportResponse, error)
+	GetTaxReport(filter dtos.TaxReportFilter) (*dtos.TaxReportResponse, error)
+	ExportEFaktur(writer io.Writer, filter dtos.EFakturFilter) error
+}
+
+type reportService struct{}
+
+func NewReportService() ReportService {
+	return &reportService{}
+}
+
+// GetStockAnalysis ranks items by revenue or profit over the last Days and
+// classifies them A (top 80% of the total), B (next 15%) and C (the rest).
+// Stock-managed items with stock left and no sale in DeadDays are dead stock.
+func (s *reportService) GetStockAnalysis(filter dtos.StockAnalysisFilter) (*dtos.StockAnalysisResponse, error) {
+	if filter.Days < 1 {
+		filter.Days = 90
+	}
+	if filter.Days > 730 {
+		filter.Days = 730
+	}
+	if filter.DeadDays < 1 {
+		filter.DeadDays = 90
+	}
+	if filter.Metric == "" {
+		filter.Metric = "revenue"
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -filter.Days)
+	deadCutoff := now.AddDate(0, 0, -filter.DeadDays)
+
+	var items []models.Item
+	if err := config.DB.Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	var salesRows []struct {
+		ItemID   uint
+		Quantity int
+		Revenue  float64
+		Profit   float64
+	}
+	if err := config.DB.Model(&models.TransactionItem{}).
+		Select(
+			"transaction_items.item_id, "+
+				"COALESCE(SUM(transaction_items.quantity - transaction_items.refunded_quantity), 0) AS quantity, "+
+				"COALESCE(SUM("+soldLineRevenueSQL+"), 0) AS revenue, "+
+				"COALESCE(SUM("+soldLineRevenueSQL+" - "+soldLineCostSQL+"), 0) AS profit",
+		).
+		Joins("JOIN transactions ON transactions.id = transaction_items.transaction_id").
+		Joins("JOIN items ON items.id = transaction_items.item_id").
+		Where("transactions.status IN ? AND transactions.created_at >= ? AND transactions.deleted_at IS NULL", soldStatuses, from).
+		Group("transaction_items.item_id").
+		Scan(&salesRows).Error; err != nil {
+		return nil, err
+	}
+	type itemSales struct {
+		quantity int
+		revenue  float64
+		profit   float64
+	}
+	sales := make(map[uint]itemSales, len(salesRows))
+	for _, row := range salesRows {
+		sales[row.ItemID] = itemSales{row.Quantity, row.Revenue, row.Profit}
+	}
+
+	// Last sale is looked up over all history, not only the window
+	var lastSoldRows []struct {
+		ItemID     uint
+		LastSoldAt time.Time
+	}
+	if err := config.DB.Model(&models.TransactionItem{}).
+		Select("transaction_items.item_id, MAX(transactions.created_at) AS last_sold_at").
+		Joins("JOIN transactions ON transactions.id = transaction_items.transaction_id").
+		Where("transactions.status IN ? AND transactions.deleted_at IS NULL", soldStatuses).
+		Group("transaction_items.item_id").
+		Scan(&lastSoldRows).Error; err != nil {
+		return nil, err
+	}
+	lastSold := make(map[uint]time.Time, len(lastSoldRows))
+	for _, row := range lastSoldRows {
+		lastSold[row.ItemID] = row.LastSoldAt
+	}
+
+	// Opening stock is the ledger position right before the window starts
+	var openingRows []struct {
+		ItemID     uint
+		FinalStock int
+	}
+	if err := config.DB.Table("inventory_logs AS l").
+		Select("l.item_id, l.final_stock").
+		Joins("JOIN (SELECT item_id, MAX(id) AS id FROM inventory_logs WHERE created_at < ? GROUP BY item_id) last_log ON last_log.id = l.id", from).
+		Scan(&openingRows).Error; err != nil {
+		return nil, err
+	}
+	opening := make(map[uint]int, len(openingRows))
+	for _, row := range openingRows {
+		opening[row.ItemID] = row.FinalStock
+	}
+
+	var receivedRows []struct {
+		ItemID   uint
+		Quantity int
+	}
+	if err := config.DB.Model(&models.InventoryLog{}).
+		Select("item_id, COALESCE(SUM(`change`), 0) AS quantity").
+		Where("type = ? AND `change` > 0 AND created_at >= ?", "restock", from).
+		Group("item_id").
+		Scan(&receivedRows).Error; err != nil {
+		return nil, err
+	}
+	received := make(map[uint]int, len(receivedRows))
+	for _, row := range receivedRows {
+		received[row.ItemID] = row.Quantity
+	}
+
+	report := &dtos.StockAnalysisResponse{
+		Metric:   filter.Metric,
+		Days:     filter.Days,
+		DeadDays: filter.DeadDays,
+		From:     from,
+		To:       now,
+		Items:    []dtos.StockAnalysisLine{},
+		Classes:  []dtos.StockClassSummary{},
+	}
+
+	lines := make([]dtos.StockAnalysisLine, 0, len(items))
+	for _, item := range items {
+		sold := sales[item.ID]
+		stockManaged := item.IsStockManaged != nil && *item.IsStockManaged
+
+		line := dtos.StockAnalysisLine{
+			ItemID:       item.ID,
+			Name:         item.Name,
+			Category:     valuationCategory(item.Category),
+			Stock:        item.Stock,
+			UnitCost:     item.BuyPrice,
+			QuantitySold: sold.quantity,
+			Revenue:      sold.revenue,
+			Profit:       sold.profit,
+		}
+		if stockManaged && item.Stock > 0 {
+			line.StockValue = float64(item.Stock) * item.BuyPrice
+		}
+
+		if soldAt, ok := lastSold[item.ID]; ok {
+			line.LastSoldAt = &soldAt
+			days := int(now.Sub(soldAt).Hours() / 24)
+			line.DaysSinceLastSale = &days
+		}
+
+		// Ledger-less items fall back to what was sold against what is left
+		available := opening[item.ID] + received[item.ID]
+		if available <= 0 {
+			available = sold.quantity
+			if item.Stock > 0 {
+				available += item.Stock
+			}
+		}
+		if available > 0 {
+			line.SellThroughRate = math.Round(float64(sold.quantity)/float64(available)*10000) / 100
+		}
+
+		// Items never sold count from their creation, new stock is not dead yet
+		lastActivity := item.CreatedAt
+		if line.LastSoldAt != nil {
+			lastActivity = *line.LastSoldAt
+		}
+		line.IsDeadStock = stockManaged && item.Stock > 0 && lastActivity.Before(deadCutoff)
+		lines = append(lines, line)
+	}
+
+	classifyStockLines(lines, filter.Metric)
+
+	classes := map[string]*dtos.StockClassSummary{
+		"A": {Class: "A"},
+		"B": {Class: "B"},
+		"C": {Class: "C"},
+	}
+
+	for i := range lines {
+		line := &lines[i]
+
+		summary := classes[line.Class]
+		summary.ItemCount++
+		summary.Revenue += line.Revenue
+		summary.Profit += line.Profit
+		summary.StockValue += line.StockValue
+
+		report.TotalRevenue += line.Revenue
+		report.TotalProfit += line.Profit
+		if line.IsDeadStock {
+			report.DeadStockCount++
+			report.DeadStockValue += line.StockValue
+		}
+
+		if filter.Class != "" && line.Class != filter.Class {
+			continue
+		}
+		if filter.DeadOnly && !line.IsDeadStock {
+			continue
+		}
+		report.Items = append(report.Items, *line)
+	}
+
+	for _, class := range []string{"A", "B", "C"} {
+		report.Classes = append(report.Classes, *classes[class])
+	}
+
+	return report, nil
+}
+
+func (s *reportService) ExportStockAnalysis(writer io.Writer, report *dtos.StockAnalysisResponse) error {
+	csvWriter := csv.NewWriter(writer)
+	defer csvWriter.Flush()
+
+	csvWriter.Write([]string{
+		"item_id", "name", "category", "class", "quantity_sold", "revenue", "profit", "contribution_pct",
+		"cumulative_pct", "stock", "unit_cost", "stock_value", "last_sold_at", "days_since_last_sale",
+		"sell_through_pct", "dead_stock",
+	})
+
+	for _, line := range report.Items {
+		lastSoldAt, daysSince := "", ""
+		if line.LastSoldAt != nil {
+			lastSoldAt = line.LastSoldAt.Format("2006-01-02")
+			daysSince = fmt.Sprintf("%d", *line.DaysSinceLastSale)
+		}
+
+		csvWriter.Write([]string{
+			fmt.Sprintf("%d", line.ItemID),
+			line.Name,
+			line.Category,
+			line.Class,
+			fmt.Sprintf("%d", line.QuantitySold),
+			fmt.Sprintf("%.2f", line.Revenue),
+			fmt.Sprintf("%.2f", line.Profit),
+			fmt.Sprintf("%.2f", line.Contribution),
+			fmt.Sprintf("%.2f", line.CumulativeShare),
+			fmt.Sprintf("%d", line.Stock),
+			fmt.Sprintf("%.2f", line.UnitCost),
+			fmt.Sprintf("%.2f", line.StockValue),
+			lastSoldAt,
+			daysSince,
+			fmt.Sprintf("%.2f", line.SellThroughRate),
+			fmt.Sprintf("%t", line.IsDeadStock),
+		})
+	}
+
+	return csvWriter.Error()
+}
+
+// GetPaymentSummary totals completed sales per tender method. Split payments count
+// once per method, so the cash line holds only the cash part of a split sale.
+// Exchange credit is listed but kept out of the total, it is not money collected.
+func (s *reportService) GetPaymentSummary(filter dtos.PaymentSummaryFilter) (*dtos.PaymentSummaryResponse, error) {
+	db := config.DB.Model(&models.TransactionPayment{}).
+		Joins("JOIN transactions ON transactions.id = transaction_payments.transaction_id").
+		Where("transactions.status IN ? AND transactions.deleted_at IS NULL", soldStatuses)
+	if filter.StartDate != "" {
+		db = db.Where("transaction_payments.created_at >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		db = db.Where("transaction_payments.created_at <= ?", filter.EndDate+" 23:59:59")
+	}
+
+	response := &dtos.PaymentSummaryResponse{Methods: []dtos.PaymentMethodTotal{}}
+
+	if err := db.Session(&gorm.Session{}).
+		Select("transaction_payments.method, COUNT(*) AS count, COALESCE(SUM(transaction_payments.amount), 0) AS amount").
+		Group("transaction_payments.method").
+		Order("amount DESC").
+		Scan(&response.Methods).Error; err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Model(&models.Transaction{}).
+		Select("COALESCE(SUM(`change`), 0)").
+		Where("id IN (?)", db.Session(&gorm.Session{}).Distinct("transaction_payments.transaction_id")).
+		Scan(&response.TotalChange).Error; err != nil {
+		return nil, err
+	}
+
+	collections := config.DB.Model(&models.CustomerPayment{})
+	if filter.StartDate != "" {
+		collections = collections.Where("created_at >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		collections = collections.Where("created_at <= ?", filter.EndDate+" 23:59:59")
+	}
+	response.Collections = []dtos.PaymentMethodTotal{}
+	if err := collections.
+		Select("method, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Group("method").
+		Order("amount DESC").
+		Scan(&response.Collections).Error; err != nil {
+		return nil, err
+	}
+
+	for _, method := range response.Methods {
+		switch method.Method {
+		case "exchange":
+			// Credit from returned goods, the money was counted when they were first sold
+			response.ExchangeCredit = method.Amount
+			continue
+		case "cash":
+			response.NetCash = method.Amount
+		}
+		response.Total += method.Amount
+	}
+	for _, method := range response.Collections {
+		if method.Method == "cash" {
+			response.NetCash += method.Amount
+		}
+	}
+	response.NetCash -= response.TotalChange
+	response.Total -= response.TotalChange
+
+	return response, nil
+}
+
+// GetDiscountReport totals what sales gave away off list price, as granted at the till:
+// refunded lines keep counting, the discount was given when they were sold.
+func (s *reportService) GetDiscountReport(filter dtos.DiscountReportFilter) (*dtos.DiscountReportResponse, error) {
+	sold := func(model any) *gorm.DB {
+		db := config.DB.Model(model).Where("transactions.status IN ? AND transactions.deleted_at IS NULL", soldStatuses)
+		if filter.StartDate != "" {
+			db = db.Where("transactions.created_at >= ?", filter.StartDate)
+		}
+		if filter.EndDate != "" {
+			db = db.Where("transactions.created_at <= ?", filter.EndDate+" 23:59:59")
+		}
+		return db
+	}
+	lines := func() *gorm.DB {
+		return sold(&models.TransactionItem{}).
+			Joins("JOIN transactions ON transactions.id = transaction_items.transaction_id")
+	}
+	const belowListSQL = "CASE WHEN transaction_items.price < transaction_items.list_price " +
+		"THEN transaction_items.quantity * (transaction_items.list_price - transaction_items.price) ELSE 0 END"
+
+	response := &dtos.DiscountReportResponse{
+		ByReason: []dtos.DiscountByReason{},
+		ByItem:   []dtos.DiscountByItem{},
+	}
+
+	var lineTotals struct {
+		LineDiscounts      float64
+		BelowListPrice     float64
+		PromotionDiscounts float64
+	}
+	if err := lines().
+		Select(
+			"COALESCE(SUM(transaction_items.line_discount), 0) AS line_discounts, " +
+				"COALESCE(SUM(" + belowListSQL + "), 0) AS below_list_price, " +
+				"COALESCE(SUM(transaction_items.promotion_discount), 0) AS promotion_discounts",
+		).
+		Scan(&lineTotals).Error; err != nil {
+		return nil, err
+	}
+
+	var saleTotals struct {
+		SaleDiscounts      float64
+		PromotionDiscounts float64
+	}
+	if err := sold(&models.Transaction{}).
+		Select("COALESCE(SUM(transactions.discount), 0) AS sale_discounts, COALESCE(SUM(transactions.promotion_discount), 0) AS promotion_discounts").
+		Scan(&saleTotals).Error; err != nil {
+		return nil, err
+	}
+
+	response.Totals = dtos.DiscountTotals{
+		LineDiscounts:      lineTotals.LineDiscounts,
+		BelowListPrice:     lineTotals.BelowListPrice,
+		PromotionDiscounts: lineTotals.PromotionDiscounts + saleTotals.PromotionDiscounts,
+		SaleDiscounts:      saleTotals.SaleDiscounts,
+	}
+	response.Totals.Total = response.Totals.LineDiscounts + response.Totals.BelowListPrice +
+		response.Totals.PromotionDiscounts + response.Totals.SaleDiscounts
+
+	if err := lines().
+		Select("COALESCE(transaction_items.discount_reason, '') AS reason, COUNT(*) AS `lines`, COALESCE(SUM(transaction_items.line_discount), 0) AS amount").
+		Where("transaction_items.line_discount > 0").
+		Group("transaction_items.discount_reason").
+		Order("amount DESC").
+		Scan(&response.ByReason).Error; err != nil {
+		return nil, err
+	}
+
+	if err := lines().
+		Joins("JOIN items ON items.id = transaction_items.item_id").
+		Select(
+			"transaction_items.item_id, items.name, COUNT(*) AS `lines`, " +
+				"COALESCE(SUM(transaction_items.line_discount), 0) AS line_discounts, " +
+				"COALESCE(SUM(" + belowListSQL + "), 0) AS below_list_price",
+		).
+		Where("transaction_items.line_discount > 0 OR transaction_items.price < transaction_items.list_price").
+		Group("transaction_items.item_id, items.name").
+		Order("line_discounts + below_list_price DESC").
+		Scan(&response.ByItem).Error; err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// taxRemainingSQL is the share of a sold line the customer kept, the tax on refunded
+// quantities was handed back with the refund
+const taxRemainingSQL = "(transaction_items.quantity - transaction_items.refunded_quantity) / transaction_items.quantity"
+
+// GetTaxReport sums the PPN on sales per rate and per tax month, net of refunds
+func (s *reportService) GetTaxReport(filter dtos.TaxReportFilter) (*dtos.TaxReportResponse, error) {
+	lines := func() *gorm.DB {
+		db := config.DB.Model(&models.TransactionItem{}).
+			Joins("JOIN transactions ON transactions.id = transaction_items.transaction_id").
+			Where("transactions.status IN ? AND transactions.deleted_at IS NULL AND transaction_items.quantity <> 0", soldStatuses)
+		if filter.StartDate != "" {
+			db = db.Where("transactions.created_at >= ?", filter.StartDate)
+		}
+		if filter.EndDate != "" {
+			db = db.Where("transactions.created_at <= ?", filter.EndDate+" 23:59:59")
+		}
+		return db
+	}
+	const (
+		baseSQL   = "COALESCE(SUM(CASE WHEN transaction_items.tax_rate > 0 THEN transaction_items.tax_base * " + taxRemainingSQL + " ELSE 0 END), 0)"
+		taxSQL    = "COALESCE(SUM(transaction_items.tax_amount * " + taxRemainingSQL + "), 0)"
+		exemptSQL = "COALESCE(SUM(CASE WHEN transaction_items.tax_rate = 0 THEN transaction_items.tax_base * " + taxRemainingSQL + " ELSE 0 END), 0)"
+	)
+
+	response := &dtos.TaxReportResponse{
+		ByRate:   []dtos.TaxByRate{},
+		ByPeriod: []dtos.TaxByPeriod{},
+	}
+
+	if err := lines().
+		Select(baseSQL + " AS tax_base, " + taxSQL + " AS tax_amount, " + exemptSQL + " AS exempt_sales").
+		Scan(&response.Totals).Error; err != nil {
+		return nil, err
+	}
+
+	if err := lines().
+		Select("transaction_items.tax_rate AS rate, COUNT(*) AS `lines`, " + baseSQL + " AS tax_base, " + taxSQL + " AS tax_amount").
+		Where("transaction_items.tax_rate > 0").
+		Group("transaction_items.tax_rate").
+		Order("rate DESC").
+		Scan(&response.ByRate).Error; err != nil {
+		return nil, err
+	}
+
+	if err := lines().
+		Select("DATE_FORMAT(transactions.created_at, '%Y-%m') AS period, " + baseSQL + " AS tax_base, " + taxSQL + " AS tax_amount, " + exemptSQL + " AS exempt_sales").
+		Group("period").
+		Order("period").
+		Scan(&response.ByPeriod).Error; err != nil {
+		return nil, err
+	}
+
+	response.Totals.TaxBase = roundMoney(response.Totals.TaxBase)
+	response.Totals.TaxAmount = roundMoney(response.Totals.TaxAmount)
+	response.Totals.ExemptSales = roundMoney(response.Totals.ExemptSales)
+	for i := range response.ByRate {
+		response.ByRate[i].TaxBase = roundMoney(response.ByRate[i].TaxBase)
+		response.ByRate[i].TaxAmount = roundMoney(response.ByRate[i].TaxAmount)
+	}
+	for i := range response.ByPeriod {
+		response.ByPeriod[i].TaxBase = roundMoney(response.ByPeriod[i].TaxBase)
+		response.ByPeriod[i].TaxAmount = roundMoney(response.ByPeriod[i].TaxAmount)
+		response.ByPeriod[i].ExemptSales = roundMoney(response.ByPeriod[i].ExemptSales)
+	}
+
+	return response, nil
+}
+
+// ExportEFaktur writes output tax invoices in the e-Faktur import CSV layout: the FK/LT/OF
+// header rows, then one FK row per taxable sale to a customer with an NPWP, followed by an
+// OF row per taxable line. NOMOR_FAKTUR is left empty for e-Faktur to assign and refunded
+// quantities are left out.
+func (s *reportService) ExportEFaktur(writer io.Writer, filter dtos.EFakturFilter) error {
+	db := config.DB.
+		Preload("Items", "tax_rate > 0").
+		Preload("Items.Item").
+		Preload("Customer.Addresses").
+		Preload("CustomerAddress").
+		Where("status IN ? AND tax_amount > 0 AND customer_id IS NOT NULL", soldStatuses)
+	if filter.StartDate != "" {
+		db = db.Where("created_at >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		db = db.Where("created_at <= ?", filter.EndDate+" 23:59:59")
+	}
+	if filter.CustomerID != nil {
+		db = db.Where("customer_id = ?", *filter.CustomerID)
+	}
+
+	var transactions []models.Transaction
+	if err := db.Order("created_at").Find(&transactions).Error; err != nil {
+		return err
+	}
+
+	csvWriter := csv.NewWriter(writer)
+	defer csvWriter.Flush()
+
+	csvWriter.Write([]string{
+		"FK", "KD_JENIS_TRANSAKSI", "FG_PENGGANTI", "NOMOR_FAKTUR", "MASA_PAJAK", "TAHUN_PAJAK", "TANGGAL_FAKTUR",
+		"NPWP", "NAMA", "ALAMAT_LENGKAP", "JUMLAH_DPP", "JUMLAH_PPN", "JUMLAH_PPNBM", "ID_KETERANGAN_TAMBAHAN",
+		"FG_UANG_MUKA", "UANG_MUKA_DPP", "UANG_MUKA_PPN", "UANG_MUKA_PPNBM", "REFERENSI", "KODE_DOKUMEN_PENDUKUNG",
+	})
+	csvWriter.Write([]string{
+		"LT", "NPWP", "NAMA", "JALAN", "BLOK", "NOMOR", "RT", "RW", "KECAMATAN", "KELURAHAN", "KABUPATEN",
+		"PROPINSI", "KODE_POS", "NOMOR_TELEPON",
+	})
+	csvWriter.Write([]string{
+		"OF", "KODE_OBJEK", "NAMA", "HARGA_SATUAN", "JUMLAH_BARANG", "HARGA_TOTAL", "DISKON", "DPP", "PPN",
+		"TARIF_PPNBM", "PPNBM",
+	})
+
+	for _, transaction := range transactions {
+		if transaction.Customer == nil {
+			continue
+		}
+		npwp := digitsOnly(common.GetStringValue(transaction.Customer.NPWP))
+		if npwp == "" {
+			continue
+		}
+
+		var objects [][]string
+		var totalBase, totalTax float64
+		for _, line := range transaction.Items {
+			remaining := line.Quantity - line.RefundedQuantity
+			if line.Quantity <= 0 || remaining <= 0 {
+				continue
+			}
+			kept := float64(remaining) / float64(line.Quantity)
+
+			unitPrice := line.Price
+			if !transaction.TaxExclusive {
+				unitPrice = line.Price / (1 + line.TaxRate/100)
+			}
+			lineTotal := math.Round(unitPrice * float64(remaining))
+			base := math.Round(line.TaxBase * kept)
+			tax := math.Floor(line.TaxAmount * kept)
+			totalBase += base
+			totalTax += tax
+
+			objects = append(objects, []string{
+				"OF",
+				fmt.Sprintf("%d", line.ItemID),
+				line.Item.Name,
+				fmt.Sprintf("%.0f", math.Round(unitPrice)),
+				fmt.Sprintf("%d", remaining),
+				fmt.Sprintf("%.0f", lineTotal),
+				fmt.Sprintf("%.0f", math.Max(lineTotal-base, 0)),
+				fmt.Sprintf("%.0f", base),
+				fmt.Sprintf("%.0f", tax),
+				"0",
+				"0",
+			})
+		}
+		if len(objects) == 0 {
+			continue
+		}
+
+		csvWriter.Write([]string{
+			"FK",
+			"01",
+			"0",
+			"",
+			fmt.Sprintf("%d", int(transaction.CreatedAt.Month())),
+			fmt.Sprintf("%d", transaction.CreatedAt.Year()),
+			transaction.CreatedAt.Format("02/01/2006"),
+			npwp,
+			transaction.Customer.Name,
+			transactionAddress(transaction),
+			fmt.Sprintf("%.0f", totalBase),
+			fmt.Sprintf("%.0f", totalTax),
+			"0",
+			"",
+			"0",
+			"0",
+			"0",
+			"0",
+			transactionNumber(&transaction),
+			"",
+		})
+		for _, object := range objects {
+			csvWriter.Write(object)
+		}
+	}
+
+	return csvWriter.Error()
+}
+
+// transactionAddress picks the address a sale goes to: its delivery address, else the
+// customer's default one, else their first
+func transactionAddress(transaction models.Transaction) string {
+	if transaction.CustomerAddress != nil {
+		return transaction.CustomerAddress.Address
+	}
+	if transaction.Customer == nil || len(transaction.Customer.Addresses) == 0 {
+		return ""
+	}
+	for _, address := range transaction.Customer.Addresses {
+		if address.IsDefault {
+			return address.Address
+		}
+	}
+	return transaction.Customer.Addresses[0].Address
+}
+
+func digitsOnly(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// classifyStockLines ranks the lines by the metric, highest first, and puts each in class A, B or C
+// by its cumulative share. Lines with nothing to show for the metric are always C.
+func classifyStockLines(lines []dtos.StockAnalysisLine, metric string) {
+	var metricTotal float64
+	for _, line := range lines {
+		if value := stockAnalysisMetric(line, metric); value > 0 {
+			metricTotal += value
+		}
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		vi, vj := stockAnalysisMetric(lines[i], metric), stockAnalysisMetric(lines[j], metric)
+		if vi != vj {
+			return vi > vj
+		}
+		return lines[i].Name < lines[j].Name
+	})
+
+	var cumulative float64
+	for i := range lines {
+		line := &lines[i]
+		value := stockAnalysisMetric(*line, metric)
+
+		// An item belongs to A while the share ranked above it is still under the limit
+		line.Class = "C"
+		if value > 0 && metricTotal > 0 {
+			before := cumulative / metricTotal * 100
+			switch {
+			case before < abcClassALimit:
+				line.Class = "A"
+			case before < abcClassBLimit:
+				line.Class = "B"
+			}
+			cumulative += value
+			line.Contribution = math.Round(value/metricTotal*10000) / 100
+			line.CumulativeShare = math.Round(cumulative/metricTotal*10000) / 100
+		}
+	}
+}
+
+func stockAnalysisMetric(line dtos.StockAnalysisLine, metric string) float64 {
+	if metric == "profit" {
+		return line.Profit
+	}
+	return line.Revenue
+}
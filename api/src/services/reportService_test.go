@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+
+	"kd-api/src/dtos"
+)
+
+func TestClassifyStockLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		metric  string
+		lines   []dtos.StockAnalysisLine
+		classes map[string]string
+	}{
+		{
+			name:   "cumulative share before each item decides its class",
+			metric: "revenue",
+			lines: []dtos.StockAnalysisLine{
+				{Name: "d", Revenue: 5},
+				{Name: "b", Revenue: 30},
+				{Name: "c", Revenue: 15},
+				{Name: "a", Revenue: 50},
+			},
+			classes: map[string]string{"a": "A", "b": "A", "c": "B", "d": "C"},
+		},
+		{
+			name:   "an item crossing the A limit is still A",
+			metric: "revenue",
+			lines: []dtos.StockAnalysisLine{
+				{Name: "a", Revenue: 79},
+				{Name: "b", Revenue: 15},
+				{Name: "c", Revenue: 6},
+			},
+			classes: map[string]string{"a": "A", "b": "A", "c": "B"},
+		},
+		{
+			name:   "unsold and loss-making items are C",
+			metric: "profit",
+			lines: []dtos.StockAnalysisLine{
+				{Name: "a", Profit: 100},
+				{Name: "b", Profit: 0},
+				{Name: "c", Profit: -20},
+			},
+			classes: map[string]string{"a": "A", "b": "C", "c": "C"},
+		},
+		{
+			name:   "nothing sold at all",
+			metric: "revenue",
+			lines: []dtos.StockAnalysisLine{
+				{Name: "a"},
+				{Name: "b"},
+			},
+			classes: map[string]string{"a": "C", "b": "C"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classifyStockLines(tt.lines, tt.metric)
+			for _, line := range tt.lines {
+				if line.Class != tt.classes[line.Name] {
+					t.Errorf("item %s: class %s, want %s", line.Name, line.Class, tt.classes[line.Name])
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyStockLinesOrderAndShares(t *testing.T) {
+	lines := []dtos.StockAnalysisLine{
+		{Name: "b", Revenue: 25},
+		{Name: "c", Revenue: 50},
+		{Name: "a", Revenue: 25},
+	}
+	classifyStockLines(lines, "revenue")
+
+	want := []struct {
+		name         string
+		contribution float64
+		cumulative   float64
+	}{
+		{"c", 50, 50},
+		{"a", 25, 75},
+		{"b", 25, 100},
+	}
+	for i, w := range want {
+		line := lines[i]
+		if line.Name != w.name || line.Contribution != w.contribution || line.CumulativeShare != w.cumulative {
+			t.Errorf("line %d: got %s %.2f%% / %.2f%%, want %s %.2f%% / %.2f%%",
+				i, line.Name, line.Contribution, line.CumulativeShare, w.name, w.contribution, w.cumulative)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReservationService interface {
+	ReserveForTransaction(tx *gorm.DB, transactionID uint, items []models.TransactionItem, reservationType string) error
+	ReleaseForTransaction(tx *gorm.DB, transactionID uint) error
+	FulfillForTransaction(tx *gorm.DB, transactionID uint) error
+	GetReservedQuantities(tx *gorm.DB, itemIDs []uint, excludeTransactionID uint) (map[uint]int, error)
+	GetReservations(filter dtos.ReservationFilter) (*dtos.ReservationListResponse, error)
+}
+
+type reservationService struct{}
+
+func NewReservationService() ReservationService {
+	return &reservationService{}
+}
+
+// activeReservations scopes a query to holds that still count against stock.
+// Expired draft holds are ignored here instead of being cleaned up by a job.
+func activeReservations(db *gorm.DB) *gorm.DB {
+	return db.Where("stock_reservations.status = ? AND (stock_reservations.expires_at IS NULL OR stock_reservations.expires_at > ?)", "active", time.Now())
+}
+
+func (s *reservationService) ReserveForTransaction(tx *gorm.DB, transactionID uint, items []models.TransactionItem, reservationType string) error {
+	var expiresAt *time.Time
+	if reservationType == "draft" {
+		expiry := time.Now().Add(config.DraftReservationTTL())
+		expiresAt = &expiry
+	}
+
+	var reservations []models.StockReservation
+	for _, tItem := range items {
+		if tItem.Quantity <= 0 {
+			continue
+		}
+
+		var item models.Item
+		if err := tx.Select("id", "is_stock_managed").First(&item, tItem.ItemID).Error; err != nil {
+			return err
+		}
+		if item.IsStockManaged == nil || !*item.IsStockManaged {
+			continue
+		}
+
+		reservations = append(reservations, models.StockReservation{
+			ItemID:        tItem.ItemID,
+			TransactionID: transactionID,
+			Quantity:      tItem.Quantity,
+			Type:          reservationType,
+			Status:        "active",
+			ExpiresAt:     expiresAt,
+		})
+	}
+
+	if len(reservations) == 0 {
+		return nil
+	}
+
+	return tx.Create(&reservations).Error
+}
+
+func (s *reservationService) ReleaseForTransaction(tx *gorm.DB, transactionID uint) error {
+	return tx.Model(&models.StockReservation{}).
+		Where("transaction_id = ? AND status = ?", transactionID, "active").
+		Update("status", "released").Error
+}
+
+func (s *reservationService) FulfillForTransaction(tx *gorm.DB, transactionID uint) error {
+	return tx.Model(&models.StockReservation{}).
+		Where("transaction_id = ? AND status = ?", transactionID, "active").
+		Update("status", "fulfilled").Error
+}
+
+// GetReservedQuantities sums active holds per item. Holds belonging to
+// excludeTransactionID are skipped so a transaction is not blocked by itself.
+func (s *reservationService) GetReservedQuantities(tx *gorm.DB, itemIDs []uint, excludeTransactionID uint) (map[uint]int, error) {
+	reserved := make(map[uint]int)
+	if len(itemIDs) == 0 {
+		return reserved, nil
+	}
+
+	var rows []struct {
+		ItemID   uint
+		Quantity int
+	}
+
+	query := activeReservations(tx.Model(&models.StockReservation{})).
+		Select("item_id, COALESCE(SUM(quantity), 0) AS quantity").
+		Where("item_id IN ?", itemIDs)
+	if excludeTransactionID != 0 {
+		query = query.Where("transaction_id != ?", excludeTransactionID)
+	}
+
+	if err := query.Group("item_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		reserved[row.ItemID] = row.Quantity
+	}
+	return reserved, nil
+}
+
+func (s *reservationService) GetReservations(filter dtos.ReservationFilter) (*dtos.ReservationListResponse, error) {
+	var reservations []models.StockReservation
+	var total int64
+
+	db := config.DB.Model(&models.StockReservation{})
+	if filter.Status == "" || filter.Status == "active" {
+		db = activeReservations(db)
+	} else {
+		db = db.Where("status = ?", filter.Status)
+	}
+
+	if filter.ItemID != 0 {
+		db = db.Where("item_id = ?", filter.ItemID)
+	}
+	if filter.TransactionID != 0 {
+		db = db.Where("transaction_id = ?", filter.TransactionID)
+	}
+	if filter.Type != "" {
+		db = db.Where("type = ?", filter.Type)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+	offset := (filter.Page - 1) * filter.Limit
+
+	if err := db.Preload("Item").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+
+	return &dtos.ReservationListResponse{
+		Data:       reservations,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+// applyAvailability fills the computed reserved/available fields on items.
+func applyAvailability(db *gorm.DB, items []models.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	itemIDs := make([]uint, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+	}
+
+	reserved, err := NewReservationService().GetReservedQuantities(db, itemIDs, 0)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i].ReservedStock = reserved[items[i].ID]
+		items[i].AvailableStock = items[i].Stock - items[i].ReservedStock
+	}
+	return nil
+}
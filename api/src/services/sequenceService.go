@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+	"kd-api/src/config"
+	"kd-api/src/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var sequencePattern = regexp.MustCompile(`\{SEQ(?::(\d+))?\}`)
+
+// nextDocumentNumber hands out the next number for a document type. It must run inside
+// the transaction that saves the document: the sequence row stays locked until it commits,
+// so concurrent sales queue up, and a rollback gives the number back, leaving no gaps.
+func nextDocumentNumber(tx *gorm.DB, docType string, at time.Time) (string, error) {
+	format := config.DocumentNumberFormat(docType)
+	if format == "" {
+		return "", fmt.Errorf("no number format for %s", docType)
+	}
+
+	period := ""
+	if strings.Contains(format, "{MM}") {
+		period = at.Format("2006/01")
+	} else if strings.Contains(format, "{YYYY}") || strings.Contains(format, "{YY}") {
+		period = at.Format("2006")
+	}
+
+	// The first document of a period creates the row, a concurrent one waits on the unique key
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.DocumentSequence{DocType: docType, Period: period}).Error; err != nil {
+		return "", err
+	}
+	var sequence models.DocumentSequence
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("doc_type = ? AND period = ?", docType, period).
+		First(&sequence).Error; err != nil {
+		return "", err
+	}
+
+	sequence.LastNumber++
+	if err := tx.Model(&sequence).Update("last_number", sequence.LastNumber).Error; err != nil {
+		return "", err
+	}
+
+	return formatDocumentNumber(format, at, sequence.LastNumber), nil
+}
+
+func formatDocumentNumber(format string, at time.Time, number uint) string {
+	result := strings.NewReplacer(
+		"{YYYY}", at.Format("2006"),
+		"{YY}", at.Format("06"),
+		"{MM}", at.Format("01"),
+	).Replace(format)
+
+	return sequencePattern.ReplaceAllStringFunc(result, func(token string) string {
+		width := 0
+		if match := sequencePattern.FindStringSubmatch(token); match[1] != "" {
+			width, _ = strconv.Atoi(match[1])
+		}
+		return fmt.Sprintf("%0*d", width, number)
+	})
+}
+
+// assignTransactionNumbers numbers a sale once it leaves draft.
+// Drafts get none so abandoned carts do not leave gaps.
+// A sale made offline is numbered in the period it was sold in.
+func assignTransactionNumbers(tx *gorm.DB, transaction *models.Transaction) error {
+	if transaction.Status == "draft" || transaction.Number != nil {
+		return nil
+	}
+
+	at := time.Now()
+	if transaction.ClientUUID != nil && !transaction.CreatedAt.IsZero() {
+		at = transaction.CreatedAt
+	}
+	number, err := nextDocumentNumber(tx, config.DocInvoice, at)
+	if err != nil {
+		return err
+	}
+	transaction.Number = &number
+	return nil
+}
+
+// transactionNumber is the number a sale is referred to by, drafts fall back to their id
+func transactionNumber(transaction *models.Transaction) string {
+	if transaction.Number != nil {
+		return *transaction.Number
+	}
+	return fmt.Sprintf("TX-%d", transaction.ID)
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDocumentNumber(t *testing.T) {
+	at := time.Date(2025, time.March, 7, 10, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name   string
+		format string
+		number uint
+		want   string
+	}{
+		{"year, month and padded sequence", "INV/{YYYY}/{MM}/{SEQ:5}", 42, "INV/2025/03/00042"},
+		{"short year", "KD-{YY}{MM}-{SEQ:4}", 7, "KD-2503-0007"},
+		{"unpadded sequence", "PO-{SEQ}", 123, "PO-123"},
+		{"sequence wider than the padding", "DO-{SEQ:3}", 12345, "DO-12345"},
+		{"sequence only", "{SEQ:6}", 1, "000001"},
+		{"sequence used twice", "{SEQ}/{SEQ:2}", 5, "5/05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDocumentNumber(tt.format, at, tt.number); got != tt.want {
+				t.Errorf("formatDocumentNumber(%q, %d) = %q, want %q", tt.format, tt.number, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+)
+
+type SupplierService interface {
+	GetSuppliers() ([]models.Supplier, error)
+	GetSupplierByID(id uint) (*models.Supplier, error)
+	CreateSupplier(input dtos.CreateSupplierInput) (*models.Supplier, error)
+	UpdateSupplier(id uint, input dtos.UpdateSupplierInput) (*models.Supplier, error)
+	DeleteSupplier(id uint) error
+}
+
+type supplierService struct{}
+
+func NewSupplierService() SupplierService {
+	return &supplierService{}
+}
+
+func (s *supplierService) GetSuppliers() ([]models.Supplier, error) {
+	var suppliers []models.Supplier
+	if err := config.DB.Order("name ASC").Find(&suppliers).Error; err != nil {
+		return nil, err
+	}
+	return suppliers, nil
+}
+
+func (s *supplierService) GetSupplierByID(id uint) (*models.Supplier, error) {
+	var supplier models.Supplier
+	if err := config.DB.First(&supplier, id).Error; err != nil {
+		return nil, errors.New("supplier not found")
+	}
+	return &supplier, nil
+}
+
+func (s *supplierService) CreateSupplier(input dtos.CreateSupplierInput) (*models.Supplier, error) {
+	var existing models.Supplier
+	if err := config.DB.Where("name = ?", input.Name).First(&existing).Error; err == nil {
+		return nil, errors.New("supplier with this name already exists")
+	}
+
+	supplier := models.Supplier{
+		Name:         input.Name,
+		Phone:        input.Phone,
+		Address:      input.Address,
+		LeadTimeDays: 7,
+		Note:         input.Note,
+	}
+	if input.LeadTimeDays != nil {
+		supplier.LeadTimeDays = *input.LeadTimeDays
+	}
+
+	if err := config.DB.Create(&supplier).Error; err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+func (s *supplierService) UpdateSupplier(id uint, input dtos.UpdateSupplierInput) (*models.Supplier, error) {
+	var supplier models.Supplier
+	if err := config.DB.First(&supplier, id).Error; err != nil {
+		return nil, errors.New("supplier not found")
+	}
+
+	if input.Name != nil && *input.Name != supplier.Name {
+		var existing models.Supplier
+		if err := config.DB.Where("name = ? AND id != ?", *input.Name, supplier.ID).First(&existing).Error; err == nil {
+			return nil, errors.New("supplier with this name already exists")
+		}
+		supplier.Name = *input.Name
+	}
+	if input.Phone != nil {
+		supplier.Phone = input.Phone
+	}
+	if input.Address != nil {
+		supplier.Address = input.Address
+	}
+	if input.LeadTimeDays != nil {
+		supplier.LeadTimeDays = *input.LeadTimeDays
+	}
+	if input.Note != nil {
+		supplier.Note = input.Note
+	}
+
+	if err := config.DB.Save(&supplier).Error; err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+func (s *supplierService) DeleteSupplier(id uint) error {
+	var supplier models.Supplier
+	if err := config.DB.First(&supplier, id).Error; err != nil {
+		return errors.New("supplier not found")
+	}
+	return config.DB.Delete(&supplier).Error
+}
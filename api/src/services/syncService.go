@@ -0,0 +1,253 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SyncService interface {
+	GetItemChanges(filter dtos.SyncItemsFilter) (*dtos.SyncItemsResponse, error)
+	UploadTransactions(input dtos.SyncTransactionsInput, userID *uint, role string, clientIP string) (*dtos.SyncTransactionsResponse, error)
+}
+
+type syncService struct{}
+
+// itemChangedAtSQL is when an item last changed, a soft delete counts as a change
+const itemChangedAtSQL = "GREATEST(items.updated_at, COALESCE(items.deleted_at, items.updated_at))"
+
+// syncLag keeps the newest changes out of the feed until writes still being committed with
+// an earlier timestamp have landed, so the cursor never moves past them
+const syncLag = 5 * time.Second
+
+func NewSyncService() SyncService {
+	return &syncService{}
+}
+
+// GetItemChanges returns the items changed since the cursor, oldest first, with their price,
+// stock and availability, and the IDs of items deleted since then
+func (s *syncService) GetItemChanges(filter dtos.SyncItemsFilter) (*dtos.SyncItemsResponse, error) {
+	if filter.Limit < 1 {
+		filter.Limit = 500
+	}
+	if filter.Limit > 1000 {
+		filter.Limit = 1000
+	}
+
+	now := time.Now()
+	db := config.DB.Unscoped().Model(&models.Item{}).
+		Select("items.*, "+itemChangedAtSQL+" AS changed_at").
+		Where(itemChangedAtSQL+" < ?", now.Add(-syncLag))
+	if filter.Cursor != "" {
+		changedAt, id, err := decodeSyncCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where("("+itemChangedAtSQL+" > ? OR ("+itemChangedAtSQL+" = ? AND items.id > ?))", changedAt, changedAt, id)
+	}
+
+	var rows []struct {
+		models.Item
+		ChangedAt time.Time
+	}
+	if err := db.Order("changed_at ASC, items.id ASC").Limit(filter.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	response := &dtos.SyncItemsResponse{
+		Items:      []models.Item{},
+		Deleted:    []uint{},
+		NextCursor: filter.Cursor,
+		ServerTime: now,
+	}
+	if len(rows) > filter.Limit {
+		response.HasMore = true
+		rows = rows[:filter.Limit]
+	}
+	for _, row := range rows {
+		if row.DeletedAt.Valid {
+			response.Deleted = append(response.Deleted, row.ID)
+		} else {
+			response.Items = append(response.Items, row.Item)
+		}
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		response.NextCursor = encodeSyncCursor(last.ChangedAt, last.ID)
+	}
+
+	if err := applyAvailability(config.DB, response.Items); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// UploadTransactions books sales made offline, each on its own so one bad sale does not hold
+// back the others. Conflicts are settled like this:
+//   - a ClientUUID already uploaded is a duplicate, the existing sale is returned
+//   - the price the app charged stands when it was the shelf price at the time, a lower one
+//     is a discount and must stay within the cashier's limit like any other
+//   - promotions running at upload time are not applied, the sale is numbered and dated when it was sold
+//   - a sale dated further back than SYNC_MAX_OFFLINE_AGE fails, it would land in a closed period
+//   - stock running short never turns a sale down, stock goes negative or is backordered
+//   - anything else (unknown item, bad payment, credit limit) fails that sale with the reason
+func (s *syncService) UploadTransactions(input dtos.SyncTransactionsInput, userID *uint, role string, clientIP string) (*dtos.SyncTransactionsResponse, error) {
+	response := &dtos.SyncTransactionsResponse{Results: []dtos.SyncTransactionResult{}}
+	transactionService := NewTransactionService()
+	seen := make(map[string]bool)
+
+	for _, offline := range input.Transactions {
+		clientUUID := strings.ToLower(offline.ClientUUID)
+		result := dtos.SyncTransactionResult{ClientUUID: clientUUID}
+
+		if seen[clientUUID] {
+			result.Status = "failed"
+			result.Error = "client_uuid appears more than once in this batch"
+			response.Failed++
+			response.Results = append(response.Results, result)
+			continue
+		}
+		seen[clientUUID] = true
+
+		if existing, ok := findSyncedTransaction(clientUUID); ok {
+			result.Status = "duplicate"
+			setSyncResultTransaction(&result, existing)
+			response.Duplicates++
+			response.Results = append(response.Results, result)
+			continue
+		}
+
+		sale, warnings, err := offlineSaleInput(offline, clientUUID)
+		if err == nil {
+			var transaction *models.Transaction
+			var saleWarnings []string
+			transaction, saleWarnings, err = transactionService.CreateTransaction(sale, userID, role, clientIP)
+			if err == nil {
+				result.Status = "created"
+				result.Warnings = append(warnings, saleWarnings...)
+				setSyncResultTransaction(&result, transaction)
+				response.Created++
+				response.Results = append(response.Results, result)
+				continue
+			}
+		}
+
+		// A parallel upload of the same sale may have won the race on the unique index
+		if existing, ok := findSyncedTransaction(clientUUID); ok {
+			result.Status = "duplicate"
+			setSyncResultTransaction(&result, existing)
+			response.Duplicates++
+		} else {
+			result.Status = "failed"
+			result.Error = err.Error()
+			response.Failed++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return response, nil
+}
+
+// offlineSaleInput turns an uploaded sale into a completed sale at the prices the app charged
+func offlineSaleInput(offline dtos.OfflineTransactionInput, clientUUID string) (dtos.CreateTransactionInput, []string, error) {
+	sale := dtos.CreateTransactionInput{
+		Status:            "completed",
+		Payments:          offline.Payments,
+		Note:              offline.Note,
+		TransactionType:   offline.TransactionType,
+		Discount:          offline.Discount,
+		CustomerID:        offline.CustomerID,
+		CustomerAddressID: offline.CustomerAddressID,
+		ClientUUID:        &clientUUID,
+	}
+
+	soldAt := time.Now()
+	if offline.SoldAt != nil && offline.SoldAt.Before(soldAt) {
+		soldAt = *offline.SoldAt
+	}
+	if maxAge := config.SyncMaxOfflineAge(); time.Since(soldAt) > maxAge {
+		return sale, nil, fmt.Errorf("sold_at is more than %s ago", maxAge)
+	}
+	sale.SoldAt = &soldAt
+
+	var warnings []string
+	for _, line := range offline.Items {
+		var item models.Item
+		if err := config.DB.Select("id", "name", "price").First(&item, line.ItemID).Error; err != nil {
+			return sale, nil, fmt.Errorf("item %d not found", line.ItemID)
+		}
+		shelfPrice, err := itemPriceAt(config.DB, item, soldAt)
+		if err != nil {
+			return sale, nil, err
+		}
+		if item.Price != shelfPrice {
+			warnings = append(warnings, fmt.Sprintf(
+				"Warning: Item '%s' was sold offline at the old price %.2f, the current price is %.2f",
+				item.Name, shelfPrice, item.Price,
+			))
+		}
+
+		// Anything below the shelf price of the time counts against the cashier's discount limit
+		price := line.Price
+		sale.Items = append(sale.Items, dtos.TransactionItemInput{
+			ItemID:         line.ItemID,
+			Quantity:       line.Quantity,
+			CustomPrice:    &price,
+			ListPrice:      &shelfPrice,
+			DiscountAmount: line.DiscountAmount,
+			DiscountReason: line.DiscountReason,
+		})
+	}
+
+	return sale, warnings, nil
+}
+
+func findSyncedTransaction(clientUUID string) (*models.Transaction, bool) {
+	var transaction models.Transaction
+	if err := config.DB.Where("client_uuid = ?", clientUUID).First(&transaction).Error; err != nil {
+		return nil, false
+	}
+	return &transaction, true
+}
+
+func setSyncResultTransaction(result *dtos.SyncTransactionResult, transaction *models.Transaction) {
+	total := transaction.Total
+	result.TransactionID = &transaction.ID
+	result.Number = transaction.Number
+	result.Total = &total
+}
+
+// Cursors are opaque to the app: the change time and ID of the last item it was sent
+func encodeSyncCursor(changedAt time.Time, id uint) string {
+	raw := changedAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.FormatUint(uint64(id), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSyncCursor(cursor string) (time.Time, uint, error) {
+	invalid := errors.New("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, invalid
+	}
+	changed, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return time.Time{}, 0, invalid
+	}
+	changedAt, err := time.Parse(time.RFC3339Nano, changed)
+	if err != nil {
+		return time.Time{}, 0, invalid
+	}
+	itemID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return time.Time{}, 0, invalid
+	}
+	return changedAt.Local(), uint(itemID), nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestSyncCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		changedAt time.Time
+		id        uint
+	}{
+		{"nanosecond precision", time.Date(2025, time.June, 1, 8, 30, 15, 123456789, time.UTC), 42},
+		{"other time zone", time.Date(2025, time.June, 1, 15, 30, 0, 0, time.FixedZone("WIB", 7*60*60)), 1},
+		{"largest id", time.Date(2024, time.December, 31, 23, 59, 59, 0, time.UTC), 4294967295},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changedAt, id, err := decodeSyncCursor(encodeSyncCursor(tt.changedAt, tt.id))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !changedAt.Equal(tt.changedAt) || id != tt.id {
+				t.Errorf("got %s / %d, want %s / %d", changedAt, id, tt.changedAt, tt.id)
+			}
+		})
+	}
+}
+
+func TestDecodeSyncCursorInvalid(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("2025-06-01T08:30:15Z|42"))},
+		{"no separator", encode("2025-06-01T08:30:15Z")},
+		{"bad time", encode("yesterday|42")},
+		{"bad id", encode("2025-06-01T08:30:15Z|abc")},
+		{"negative id", encode("2025-06-01T08:30:15Z|-1")},
+		{"id out of range", encode("2025-06-01T08:30:15Z|4294967296")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeSyncCursor(tt.cursor); err == nil || err.Error() != "invalid cursor" {
+				t.Errorf("error %v, want invalid cursor", err)
+			}
+		})
+	}
+}
@@ -3,11 +3,15 @@ package services
 import (
 	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"kd-api/src/config"
 	"kd-api/src/dtos"
 	"kd-api/src/models"
+	"kd-api/src/utils/common"
 	"kd-api/src/utils/log"
 
 	"gorm.io/gorm"
@@ -15,37 +19,58 @@ import (
 )
 
 type TransactionService interface {
-	CreateTransaction(input dtos.CreateTransactionInput, userID *uint, clientIP string) (*models.Transaction, []string, error)
-	UpdateTransactionStatus(id string, input dtos.UpdateTransactionInput, userID *uint, clientIP string) (*models.Transaction, error)
+	CreateTransaction(input dtos.CreateTransactionInput, userID *uint, role string, clientIP string) (*models.Transaction, []string, error)
+	UpdateTransactionStatus(id string, input dtos.UpdateTransactionInput, userID *uint, role string, clientIP string) (*models.Transaction, error)
 	GetTransactions(filter dtos.TransactionFilter) (*dtos.TransactionListResponse, error)
 	GetTransactionHistory(filter dtos.TransactionFilter) (*dtos.TransactionListResponse, error)
 	GetTransactionByID(id string) (*models.Transaction, error)
 	DeleteDraft(id string, userID *uint, clientIP string) error
-	RefundTransaction(id string, userID *uint, clientIP string) (*models.Transaction, error)
+	RefundTransaction(id string, input dtos.RefundInput, userID *uint, clientIP string) (*models.Transaction, error)
+	MarkDelivered(id string, userID *uint, role string, clientIP string) (*models.Transaction, []string, error)
+	AddPayment(id string, input dtos.AddPaymentInput, userID *uint, role string, clientIP string) (*models.Transaction, []string, error)
+	VoidTransaction(id string, input dtos.VoidTransactionInput, userID *uint, clientIP string) (*models.Transaction, error)
 }
 
 type transactionService struct{}
 
+// soldStatuses are the transaction statuses whose lines count as sales in reports.
+// Refunded quantities on partially refunded sales are subtracted via refunded_quantity.
+var soldStatuses = []string{"completed", "partially_refunded"}
+
+// deliverableStatuses are deliver sales whose goods may go out, a partially paid one is
+// settled on delivery
+var deliverableStatuses = []string{"partially_paid", "completed", "partially_refunded"}
+
+// soldLineRevenueSQL is what the unrefunded part of a sale line brought in, after line promotions.
+// soldLineCostSQL is what those units cost at the item's buy price.
+const (
+	soldLineRevenueSQL = "transaction_items.subtotal * (transaction_items.quantity - transaction_items.refunded_quantity) / transaction_items.quantity"
+	soldLineCostSQL    = "(transaction_items.quantity - transaction_items.refunded_quantity) * items.buy_price"
+)
+
 func NewTransactionService() TransactionService {
 	return &transactionService{}
 }
 
-func (s *transactionService) CreateTransaction(input dtos.CreateTransactionInput, userID *uint, clientIP string) (*models.Transaction, []string, error) {
+func (s *transactionService) CreateTransaction(input dtos.CreateTransactionInput, userID *uint, role string, clientIP string) (*models.Transaction, []string, error) {
 	if len(input.Items) == 0 {
 		return nil, nil, errors.New("no items provided")
 	}
 
-	if input.Status != "draft" && input.Status != "completed" {
+	if input.Status != "draft" && input.Status != "completed" && input.Status != "partially_paid" {
 		return nil, nil, errors.New("invalid transaction status")
 	}
 
 	var transaction models.Transaction
 	var warnings []string
+	var shortages []models.StockShortage
+	var oldDraft *models.Transaction
 	isUpdate := input.ID != nil && *input.ID > 0
+	reservationService := NewReservationService()
 
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
-		// If updating an existing draft
-		if isUpdate && input.Status == "draft" {
+		// If updating (or completing) an existing draft
+		if isUpdate {
 			if err := tx.Preload("Items").First(&transaction, *input.ID).Error; err != nil {
 				return errors.New("transaction not found")
 			}
@@ -53,19 +78,55 @@ func (s *transactionService) CreateTransaction(input dtos.CreateTransactionInput
 			if transaction.Status != "draft" {
 				return errors.New("only draft transactions can be updated")
 			}
+			draftCopy := transaction
+			oldDraft = &draftCopy
 
 			// Delete old transaction items
 			if err := tx.Where("transaction_id = ?", transaction.ID).Delete(&models.TransactionItem{}).Error; err != nil {
 				return err
 			}
+
+			if err := tx.Where("transaction_id = ?", transaction.ID).Delete(&models.TransactionPayment{}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("transaction_id = ?", transaction.ID).Delete(&models.TransactionPromotion{}).Error; err != nil {
+				return err
+			}
+
+			// Drop the draft's old holds, they are recreated below from the new lines
+			if err := reservationService.ReleaseForTransaction(tx, transaction.ID); err != nil {
+				return err
+			}
+		}
+
+		// A sale from a quotation keeps the quoted prices, even when Item.Price moved since
+		quotationID := input.QuotationID
+		if quotationID == nil {
+			quotationID = transaction.QuotationID
+		}
+		var quotation *models.Quotation
+		quoted := make(map[uint]models.QuotationLine)
+		if quotationID != nil {
+			var err error
+			quotation, err = quotationForSale(tx, *quotationID, transaction.ID)
+			if err != nil {
+				return err
+			}
+			for _, line := range quotation.Lines {
+				quoted[line.ItemID] = line
+			}
 		}
 
 		var total float64
 		var transactionItems []models.TransactionItem
 		var localWarnings []string
 		loadedItems := make(map[uint]models.Item) // Cache map to prevent redundant database reads
+		requested := make(map[uint]int)
+		negotiated := make(map[int]bool)
+		quotedSold := make(map[uint]int)
 
-		for _, i := range input.Items {
+		for index, i := range input.Items {
 			var item models.Item
 			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, i.ItemID).Error; err != nil {
 				return fmt.Errorf("item %d not found", i.ItemID)
@@ -75,22 +136,65 @@ func (s *transactionService) CreateTransaction(input dtos.CreateTransactionInput
 				return fmt.Errorf("invalid quantity for item %d", i.ItemID)
 			}
 
-			price := item.Price
-			if i.CustomPrice != nil {
-				price = *i.CustomPrice
+			var line models.TransactionItem
+			if quotedLine, ok := quoted[item.ID]; ok {
+				// The negotiated price was agreed for the quoted quantity, not for any amount
+				quotedSold[item.ID] += i.Quantity
+				if quotedSold[item.ID] > quotedLine.Quantity {
+					return fmt.Errorf("quotation covers only %d of '%s'", quotedLine.Quantity, item.Name)
+				}
+				line = quotedSaleLine(item, quotedLine, i.Quantity)
+				negotiated[index] = true
+			} else {
+				var err error
+				line, err = priceSaleLine(item, i, role)
+				if err != nil {
+					return err
+				}
+				negotiated[index] = line.Price != line.ListPrice || line.LineDiscount > 0
 			}
+			transactionItems = append(transactionItems, line)
 
-			subtotal := float64(i.Quantity) * price
-			total += subtotal
-
-			transactionItems = append(transactionItems, models.TransactionItem{
-				ItemID:   i.ItemID,
-				Quantity: i.Quantity,
-				Price:    price,
-				Subtotal: subtotal,
-			})
-			
 			loadedItems[item.ID] = item // Save to locked items map cache
+			requested[item.ID] += i.Quantity
+		}
+
+		// Warn when the sale eats into stock already promised to other drafts/deliveries
+		itemIDs := make([]uint, 0, len(loadedItems))
+		for itemID := range loadedItems {
+			itemIDs = append(itemIDs, itemID)
+		}
+		reserved, err := reservationService.GetReservedQuantities(tx, itemIDs, transaction.ID)
+		if err != nil {
+			return err
+		}
+		for itemID, quantity := range requested {
+			item := loadedItems[itemID]
+			if item.IsStockManaged == nil || !*item.IsStockManaged {
+				continue
+			}
+			available := item.Stock - reserved[itemID]
+			if quantity > available {
+				localWarnings = append(localWarnings,
+					fmt.Sprintf(
+						"Warning: Item '%s' exceeds available stock (available: %d, reserved: %d, requested: %d)",
+						item.Name, available, reserved[itemID], quantity,
+					),
+				)
+			}
+		}
+
+		// An offline sale stands at what the app charged, promotions running at upload time do not apply
+		var applied []appliedPromotion
+		var promotionDiscount float64
+		if input.SoldAt == nil {
+			applied, promotionDiscount, err = applyPromotions(tx, transactionItems, loadedItems, negotiated, input.CouponCodes)
+			if err != nil {
+				return err
+			}
+		}
+		for _, tItem := range transactionItems {
+			total += tItem.Subtotal
 		}
 
 		discount := 0.0
@@ -98,49 +202,117 @@ func (s *transactionService) CreateTransaction(input dtos.CreateTransactionInput
 			discount = *input.Discount
 		}
 
-		finalTotal := total - discount
+		finalTotal := total - discount - promotionDiscount
 		if finalTotal < 0 {
 			finalTotal = 0
 		}
 
 		if !isUpdate {
 			transaction = models.Transaction{
-				Status:          input.Status,
-				Total:           finalTotal,
-				Discount:        discount,
-				Items:           transactionItems,
-				Note:            input.Note,
-				TransactionType: "onsite",
+				Status:            input.Status,
+				Total:             finalTotal,
+				Discount:          discount,
+				PromotionDiscount: promotionDiscount,
+				Items:             transactionItems,
+				Note:              input.Note,
+				TransactionType:   "onsite",
 			}
 		} else {
 			transaction.Status = input.Status
 			transaction.Total = finalTotal
 			transaction.Discount = discount
+			transaction.PromotionDiscount = promotionDiscount
 			transaction.Items = transactionItems
 			transaction.Note = input.Note
 		}
+		transaction.TaxExclusive = !config.PricesIncludeTax()
+		applyTransactionTax(&transaction)
+		finalTotal = transaction.Total
 
 		if input.TransactionType != nil && *input.TransactionType != "" {
 			transaction.TransactionType = *input.TransactionType
 		}
 
+		if err := validateTransactionCustomer(tx, input.CustomerID, input.CustomerAddressID); err != nil {
+			return err
+		}
+		transaction.CustomerID = input.CustomerID
+		transaction.CustomerAddressID = input.CustomerAddressID
+		transaction.QuotationID = quotationID
+		if !isUpdate {
+			transaction.ClientUUID = input.ClientUUID
+			if input.SoldAt != nil {
+				transaction.CreatedAt = *input.SoldAt
+			}
+		}
+		if quotation != nil && common.GetUintValue(transaction.CustomerID) != quotation.CustomerID {
+			return errors.New("quotation is for another customer")
+		}
+		if !isUpdate {
+			transaction.CreatedByID = userID
+		}
+
 		if input.Status == "completed" {
-			if input.PaymentAmount == nil || *input.PaymentAmount < finalTotal {
-				return errors.New("payment not enough")
+			payments, err := buildTransactionPayments(input, finalTotal)
+			if err != nil {
+				return err
 			}
 
-			change := *input.PaymentAmount - finalTotal
-			transaction.Payment = input.PaymentAmount
-			transaction.Change = &change
+			var paid, cash, credit float64
+			paymentType := payments[0].Method
+			for _, p := range payments {
+				paid += p.Amount
+				switch p.Method {
+				case "cash":
+					cash += p.Amount
+				case "credit":
+					credit += p.Amount
+				}
+				if p.Method != paymentType {
+					paymentType = "split"
+				}
+			}
 
-			if input.PaymentType != nil && *input.PaymentType != "" {
-				transaction.PaymentType = input.PaymentType
-			} else {
-				defaultType := "cash"
-				transaction.PaymentType = &defaultType
+			change := paid - finalTotal
+			if change > cash {
+				return errors.New("change can only be given from cash, non-cash payments exceed total")
+			}
+
+			// The credit tender is what the customer takes on account
+			if credit > 0 {
+				if change > 0 {
+					return errors.New("credit can only cover the unpaid part of the total")
+				}
+				dueDate, err := reserveCustomerCredit(tx, transaction.CustomerID, credit)
+				if err != nil {
+					return err
+				}
+				transaction.AmountDue = credit
+				transaction.DueDate = dueDate
+			}
+
+			transaction.Payment = &paid
+			transaction.Change = &change
+			transaction.PaymentType = &paymentType
+			transaction.Payments = payments
+		} else if input.Status == "partially_paid" {
+			if err := takeDownPayment(&transaction, input, finalTotal); err != nil {
+				return err
+			}
+		}
+		if input.Status == "completed" {
+			transaction.CompletedByID = userID
+		}
+		if input.Status == "completed" || input.Status == "partially_paid" {
+			if err := bookOnCashSession(tx, &transaction, transaction.Payments, userID); err != nil {
+				return err
 			}
 		}
 
+		if err := assignTransactionNumbers(tx, &transaction); err != nil {
+			return err
+		}
+
 		if isUpdate {
 			if err := tx.Save(&transaction).Error; err != nil {
 				return err
@@ -151,13 +323,42 @@ func (s *transactionService) CreateTransaction(input dtos.CreateTransactionInput
 			}
 		}
 
-		// Inventory Ledger: Log Sales & Deduct Stock
-		if input.Status == "completed" {
-			var err error
-			localWarnings, err = deductStockForTransaction(tx, transaction.Items, transaction.ID, userID, "Sold in transaction")
+		if err := saveTransactionPromotions(tx, &transaction, applied); err != nil {
+			return err
+		}
+		if quotation != nil && quotation.Status == "open" {
+			if err := markQuotationConverted(tx, quotation, transaction.ID); err != nil {
+				return err
+			}
+		}
+		if input.Status == "completed" || input.Status == "partially_paid" {
+			if err := consumeCoupons(tx, transaction.ID); err != nil {
+				return err
+			}
+		}
+
+		// Inventory Ledger: Log Sales & Deduct Stock.
+		// Deliver orders keep the goods in the shop until delivery, so they only hold stock.
+		// A down payment holds the goods too, they are picked up once the balance is paid.
+		if (input.Status == "completed" || input.Status == "partially_paid") && transaction.TransactionType == "deliver" {
+			if err := reservationService.ReserveForTransaction(tx, transaction.ID, transaction.Items, "delivery"); err != nil {
+				return err
+			}
+		} else if input.Status == "partially_paid" {
+			if err := reservationService.ReserveForTransaction(tx, transaction.ID, transaction.Items, "layaway"); err != nil {
+				return err
+			}
+		} else if input.Status == "completed" {
+			var stockWarnings []string
+			stockWarnings, shortages, err = deductStockForTransaction(tx, transaction.Items, &transaction, userID, role, "Sold in transaction")
 			if err != nil {
 				return err
 			}
+			localWarnings = append(localWarnings, stockWarnings...)
+		} else if config.ReserveDraftStock() {
+			if err := reservationService.ReserveForTransaction(tx, transaction.ID, transaction.Items, "draft"); err != nil {
+				return err
+			}
 		}
 
 		actionType := "create"
@@ -169,7 +370,7 @@ func (s *transactionService) CreateTransaction(input dtos.CreateTransactionInput
 			tx,
 			actionType,
 			transaction.ID,
-			nil,
+			oldDraft,
 			&transaction,
 			userID,
 			clientIP,
@@ -182,43 +383,85 @@ func (s *transactionService) CreateTransaction(input dtos.CreateTransactionInput
 		return nil
 	})
 
+	// A rejected new sale was rolled back, so there is no transaction ID to point at
+	shortageTxID := transaction.ID
+	if err != nil && !isUpdate {
+		shortageTxID = 0
+	}
+	recordStockShortages(shortages, shortageTxID, err == nil)
+
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if err := config.DB.Preload("Items.Item").First(&transaction, transaction.ID).Error; err != nil {
+	if err := config.DB.Preload("Items.Item").Preload("Payments").Preload("Promotions").Preload("PaymentSchedule").First(&transaction, transaction.ID).Error; err != nil {
 		return nil, nil, err
 	}
 
 	return &transaction, warnings, nil
 }
 
-func (s *transactionService) UpdateTransactionStatus(id string, input dtos.UpdateTransactionInput, userID *uint, clientIP string) (*models.Transaction, error) {
+func (s *transactionService) UpdateTransactionStatus(id string, input dtos.UpdateTransactionInput, userID *uint, role string, clientIP string) (*models.Transaction, error) {
 	var transaction models.Transaction
+	var shortages []models.StockShortage
 
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Preload("Items.Item").First(&transaction, id).Error; err != nil {
+		if err := tx.Preload("Items.Item").Preload("Payments").First(&transaction, id).Error; err != nil {
 			return errors.New("transaction not found")
 		}
 
 		oldCopy := transaction
 		oldStatus := transaction.Status
 
+		if oldStatus == "voided" {
+			return errors.New("a voided transaction cannot be changed")
+		}
 		if input.Status != "" {
 			if input.Status != "draft" && input.Status != "completed" {
 				return errors.New("invalid status")
 			}
+			if oldStatus == "partially_paid" && input.Status != oldStatus {
+				return errors.New("a partially paid transaction completes when its balance is paid")
+			}
 			transaction.Status = input.Status
 		}
+		if oldStatus == "partially_paid" && input.Discount != nil {
+			return errors.New("cannot change the discount of a partially paid transaction")
+		}
 
 		if input.Note != nil {
 			transaction.Note = input.Note
 		}
 
-		if input.TransactionType != nil {
+		if input.TransactionType != nil && *input.TransactionType != transaction.TransactionType {
+			// Past the draft the stock is already held for delivery or taken from the shelf
+			if oldStatus != "draft" {
+				return errors.New("transaction type can only be changed on a draft")
+			}
 			transaction.TransactionType = *input.TransactionType
 		}
 
+		if input.CustomerID != nil {
+			if *input.CustomerID == 0 {
+				transaction.CustomerID = nil
+				transaction.CustomerAddressID = nil
+			} else {
+				transaction.CustomerID = input.CustomerID
+				transaction.CustomerAddressID = input.CustomerAddressID
+			}
+		} else if input.CustomerAddressID != nil {
+			transaction.CustomerAddressID = input.CustomerAddressID
+		}
+		if transaction.AmountDue > 0 && common.GetUintValue(transaction.CustomerID) != common.GetUintValue(oldCopy.CustomerID) {
+			return errors.New("cannot change the customer of a transaction with an outstanding balance")
+		}
+		if err := validateTransactionCustomer(tx, transaction.CustomerID, transaction.CustomerAddressID); err != nil {
+			return err
+		}
+		// Drop stale preloaded relations so Save writes the new IDs
+		transaction.Customer = nil
+		transaction.CustomerAddress = nil
+
 		if input.Discount != nil {
 			if *input.Discount < 0 {
 				transaction.Discount = 0
@@ -231,19 +474,44 @@ func (s *transactionService) UpdateTransactionStatus(id string, input dtos.Updat
 				total += item.Subtotal
 			}
 
-			finalTotal := total - transaction.Discount
+			finalTotal := total - transaction.Discount - transaction.PromotionDiscount
 			if finalTotal < 0 {
 				finalTotal = 0
 			}
 			transaction.Total = finalTotal
+			applyTransactionTax(&transaction)
+		}
+
+		// Numbered before any stock moves so the ledger rows carry the invoice number
+		if err := assignTransactionNumbers(tx, &transaction); err != nil {
+			return err
 		}
 
 		if oldStatus == "draft" && transaction.Status == "completed" {
-			var err error
-			_, err = deductStockForTransaction(tx, transaction.Items, transaction.ID, userID, "Sold in transaction (Draft to Completed)")
-			if err != nil {
+			transaction.CompletedByID = userID
+			if err := bookOnCashSession(tx, &transaction, nil, userID); err != nil {
+				return err
+			}
+			if err := consumeCoupons(tx, transaction.ID); err != nil {
+				return err
+			}
+
+			reservationService := NewReservationService()
+			if err := reservationService.ReleaseForTransaction(tx, transaction.ID); err != nil {
 				return err
 			}
+
+			if transaction.TransactionType == "deliver" {
+				if err := reservationService.ReserveForTransaction(tx, transaction.ID, transaction.Items, "delivery"); err != nil {
+					return err
+				}
+			} else {
+				var err error
+				_, shortages, err = deductStockForTransaction(tx, transaction.Items, &transaction, userID, role, "Sold in transaction (Draft to Completed)")
+				if err != nil {
+					return err
+				}
+			}
 		}
 
 		if err := tx.Save(&transaction).Error; err != nil {
@@ -267,6 +535,8 @@ func (s *transactionService) UpdateTransactionStatus(id string, input dtos.Updat
 		return nil
 	})
 
+	recordStockShortages(shortages, transaction.ID, err == nil)
+
 	if err != nil {
 		return nil, err
 	}
@@ -276,6 +546,133 @@ func (s *transactionService) UpdateTransactionStatus(id string, input dtos.Updat
 	return &transaction, nil
 }
 
+// AddPayment takes a further payment on a partially paid sale. Once the balance is paid the
+// sale completes: goods held for pickup leave the stock now, deliver sales keep their hold
+// (or were delivered already).
+func (s *transactionService) AddPayment(id string, input dtos.AddPaymentInput, userID *uint, role string, clientIP string) (*models.Transaction, []string, error) {
+	var transaction models.Transaction
+	var warnings []string
+	var shortages []models.StockShortage
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			Preload("PaymentSchedule", func(db *gorm.DB) *gorm.DB { return db.Order("due_date ASC, id ASC") }).
+			First(&transaction, id).Error; err != nil {
+			return errors.New("transaction not found")
+		}
+		if transaction.Status != "partially_paid" {
+			return errors.New("only partially paid transactions take further payments")
+		}
+		oldCopy := transaction
+
+		var paid, cash, credit float64
+		var payments []models.TransactionPayment
+		paymentType := common.GetStringValue(transaction.PaymentType)
+		for _, p := range input.Payments {
+			payments = append(payments, models.TransactionPayment{
+				TransactionID: transaction.ID,
+				Method:        p.Method,
+				Amount:        p.Amount,
+				Reference:     p.Reference,
+			})
+			paid += p.Amount
+			switch p.Method {
+			case "cash":
+				cash += p.Amount
+			case "credit":
+				credit += p.Amount
+			}
+			if p.Method != paymentType {
+				paymentType = "split"
+			}
+		}
+
+		change := roundMoney(paid - transaction.BalanceDue)
+		if change > 0 {
+			if change > cash {
+				return errors.New("change can only be given from cash, non-cash payments exceed the balance due")
+			}
+			if credit > 0 {
+				return errors.New("credit can only cover the unpaid part of the balance")
+			}
+		}
+		// Whatever is left may go on the customer's account when they take the goods
+		if credit > 0 {
+			dueDate, err := reserveCustomerCredit(tx, transaction.CustomerID, credit)
+			if err != nil {
+				return err
+			}
+			transaction.AmountDue = roundMoney(transaction.AmountDue + credit)
+			transaction.DueDate = dueDate
+		}
+
+		if err := bookOnCashSession(tx, &transaction, payments, userID); err != nil {
+			return err
+		}
+		if err := tx.Create(&payments).Error; err != nil {
+			return err
+		}
+		applied := min(paid, transaction.BalanceDue)
+		if err := allocateSchedulePayment(tx, transaction.PaymentSchedule, applied); err != nil {
+			return err
+		}
+
+		totalPaid := roundMoney(common.GetFloatValue(transaction.Payment) + paid)
+		transaction.Payment = &totalPaid
+		transaction.PaymentType = &paymentType
+		transaction.BalanceDue = roundMoney(transaction.BalanceDue - applied)
+
+		if transaction.BalanceDue <= 0 {
+			transaction.BalanceDue = 0
+			transaction.Status = "completed"
+			transaction.Change = &change
+			transaction.CompletedByID = userID
+
+			if transaction.TransactionType != "deliver" {
+				reservationService := NewReservationService()
+				if err := reservationService.ReleaseForTransaction(tx, transaction.ID); err != nil {
+					return err
+				}
+				var err error
+				warnings, shortages, err = deductStockForTransaction(tx, transaction.Items, &transaction, userID, role, "Sold in transaction (balance paid)")
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		transaction.PaymentSchedule = nil
+		if err := tx.Omit("Items", "PaymentSchedule").Save(&transaction).Error; err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("Transaction #%d payment of %.2f, balance due %.2f", transaction.ID, paid, transaction.BalanceDue)
+		return log.CreateTransactionAuditLog(
+			tx,
+			"update",
+			transaction.ID,
+			&oldCopy,
+			&transaction,
+			userID,
+			clientIP,
+			description,
+		)
+	})
+
+	recordStockShortages(shortages, transaction.ID, err == nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := config.DB.Preload("Items.Item").Preload("Payments").Preload("PaymentSchedule").First(&transaction, transaction.ID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &transaction, warnings, nil
+}
+
 func (s *transactionService) GetTransactions(filter dtos.TransactionFilter) (*dtos.TransactionListResponse, error) {
 	var transactions []models.Transaction
 	var total int64
@@ -293,6 +690,12 @@ func (s *transactionService) GetTransactions(filter dtos.TransactionFilter) (*dt
 		db = db.Where("status = ?", filter.Status)
 	}
 
+	if filter.Number != "" {
+		db = db.Where("number = ?", filter.Number)
+	}
+
+	db = filterTransactionsByCashier(db, filter)
+
 	if err := db.Count(&total).Error; err != nil {
 		return nil, err
 	}
@@ -305,7 +708,7 @@ func (s *transactionService) GetTransactions(filter dtos.TransactionFilter) (*dt
 	}
 	offset := (filter.Page - 1) * filter.Limit
 
-	if err := db.Preload("Items.Item").
+	if err := db.Preload("Items.Item").Preload("Payments").
 		Order("created_at DESC").
 		Limit(filter.Limit).
 		Offset(offset).
@@ -327,7 +730,7 @@ func (s *transactionService) GetTransactionHistory(filter dtos.TransactionFilter
 	var total int64
 
 	db := config.DB.Model(&models.Transaction{}).
-		Where("status IN ?", []string{"completed", "refunded"})
+		Where("status IN ?", []string{"partially_paid", "completed", "partially_refunded", "refunded"})
 
 	if filter.StartDate != "" {
 		start, _ := time.ParseInLocation("2006-01-02", filter.StartDate, time.Local)
@@ -343,6 +746,8 @@ func (s *transactionService) GetTransactionHistory(filter dtos.TransactionFilter
 		db = db.Where("created_at >= ? AND created_at < ?", startWindow, endWindow)
 	}
 
+	db = filterTransactionsByCashier(db, filter)
+
 	if err := db.Count(&total).Error; err != nil {
 		return nil, err
 	}
@@ -355,7 +760,7 @@ func (s *transactionService) GetTransactionHistory(filter dtos.TransactionFilter
 	}
 	offset := (filter.Page - 1) * filter.Limit
 
-	if err := db.Preload("Items.Item").
+	if err := db.Preload("Items.Item").Preload("Payments").
 		Order("created_at DESC").
 		Limit(filter.Limit).
 		Offset(offset).
@@ -372,9 +777,23 @@ func (s *transactionService) GetTransactionHistory(filter dtos.TransactionFilter
 	}, nil
 }
 
+// filterTransactionsByCashier narrows a transaction list to one cashier's sales or one drawer.
+// A sale belongs to whoever completed it, a draft to whoever started it.
+func filterTransactionsByCashier(db *gorm.DB, filter dtos.TransactionFilter) *gorm.DB {
+	if filter.CashierID > 0 {
+		db = db.Where("COALESCE(completed_by_id, created_by_id) = ?", filter.CashierID)
+	}
+	if filter.SessionID > 0 {
+		db = db.Where("cash_session_id = ?", filter.SessionID)
+	}
+	return db
+}
+
 func (s *transactionService) GetTransactionByID(id string) (*models.Transaction, error) {
 	var transaction models.Transaction
-	if err := config.DB.Preload("Items.Item").First(&transaction, id).Error; err != nil {
+	if err := config.DB.Preload("Items.Item").Preload("Payments").Preload("Refunds.Lines").Preload("Promotions").
+		Preload("PaymentSchedule").Preload("Customer").Preload("CustomerAddress").Preload("CreatedBy").Preload("CompletedBy").
+		First(&transaction, id).Error; err != nil {
 		return nil, errors.New("transaction not found")
 	}
 	return &transaction, nil
@@ -392,7 +811,17 @@ func (s *transactionService) DeleteDraft(id string, userID *uint, clientIP strin
 
 	txCopy := transaction
 
-	if err := config.DB.Delete(&transaction).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := NewReservationService().ReleaseForTransaction(tx, transaction.ID); err != nil {
+			return err
+		}
+		// A quotation converted into this draft can be converted again
+		if err := reopenQuotation(tx, transaction.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&transaction).Error
+	})
+	if err != nil {
 		return errors.New("failed to delete")
 	}
 
@@ -411,101 +840,884 @@ func (s *transactionService) DeleteDraft(id string, userID *uint, clientIP strin
 	return nil
 }
 
-func (s *transactionService) RefundTransaction(id string, userID *uint, clientIP string) (*models.Transaction, error) {
+// RefundTransaction refunds some lines of a sale (or all that is left) and writes a refund document.
+func (s *transactionService) RefundTransaction(id string, input dtos.RefundInput, userID *uint, clientIP string) (*models.Transaction, error) {
 	var transaction models.Transaction
-	
+
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Preload("Items.Item").First(&transaction, id).Error; err != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&transaction, id).Error; err != nil {
 			return errors.New("transaction not found")
 		}
 
-		if transaction.Status != "completed" {
-			return errors.New("only completed transactions can be refunded")
+		oldCopy := transaction
+
+		refund, err := createRefund(tx, &transaction, input, userID)
+		if err != nil {
+			return err
 		}
 
+		description := fmt.Sprintf("Transaction #%d refunded (%s)", transaction.ID, refund.Number)
+		return log.CreateTransactionAuditLog(
+			tx,
+			"update",
+			transaction.ID,
+			&oldCopy,
+			&transaction,
+			userID,
+			clientIP,
+			description,
+		)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTransactionByID(id)
+}
+
+// createRefund refunds lines of a locked transaction (loaded with Items) inside tx.
+// Only refunded quantities actually taken from stock go back on the shelf, backordered ones
+// are taken off the backorder instead. The amount follows the line prices
+// scaled by the sale's discount, cash refunds are booked on the refunding user's open cash session.
+func createRefund(tx *gorm.DB, transaction *models.Transaction, input dtos.RefundInput, userID *uint) (*models.Refund, error) {
+	if transaction.Status != "completed" && transaction.Status != "partially_refunded" {
+		return nil, errors.New("only completed transactions can be refunded")
+	}
+
+	// Default to refunding every remaining quantity
+	requested := make(map[uint]int)
+	if len(input.Lines) == 0 {
 		for _, tItem := range transaction.Items {
-			var item models.Item
-			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, tItem.ItemID).Error; err != nil {
-				// If we can't find the item during refund, we might still want to proceed or fail.
-				// Fail safe approach: return error
-				return err
+			if remaining := tItem.Quantity - tItem.RefundedQuantity; remaining > 0 {
+				requested[tItem.ID] = remaining
 			}
+		}
+	}
+	for _, line := range input.Lines {
+		requested[line.TransactionItemID] += line.Quantity
+	}
+	if len(requested) == 0 {
+		return nil, errors.New("nothing left to refund")
+	}
 
-			if item.IsStockManaged == nil || !*item.IsStockManaged {
-				continue
-			}
+	var gross float64
+	for _, tItem := range transaction.Items {
+		gross += tItem.Subtotal
+	}
+	discountFactor := 1.0
+	if gross > 0 {
+		discountFactor = transaction.Total / gross
+	}
 
-			item.Stock += tItem.Quantity
-			if err := tx.Save(&item).Error; err != nil {
-				return err
+	var refundLines []models.RefundLine
+	var returnedItems []models.TransactionItem
+	var amount float64
+	fullyRefunded := true
+
+	for i := range transaction.Items {
+		tItem := &transaction.Items[i]
+		quantity, ok := requested[tItem.ID]
+		if ok {
+			delete(requested, tItem.ID)
+			if quantity > tItem.Quantity-tItem.RefundedQuantity {
+				return nil, fmt.Errorf("refund quantity for line %d exceeds remaining quantity (%d)", tItem.ID, tItem.Quantity-tItem.RefundedQuantity)
 			}
 
-			// Inventory Log (Refund)
-			invService := NewInventoryService()
-			change := tItem.Quantity // Refund is positive (stock returns)
-			ref := fmt.Sprintf("TX-%d (REFUND)", transaction.ID)
-			note := "Refunded transaction"
+			// Line subtotals are net of line promotions, so refund the same share of them
+			subtotal := tItem.Subtotal * float64(quantity) / float64(tItem.Quantity)
+			amount += subtotal * discountFactor
+			refundLines = append(refundLines, models.RefundLine{
+				TransactionItemID: tItem.ID,
+				ItemID:            tItem.ItemID,
+				Quantity:          quantity,
+				Price:             tItem.Price,
+				Subtotal:          subtotal,
+			})
+			// On a delivery still under way the refund cancels what has not left the shop first
+			returned := quantity
+			if transaction.TransactionType == "deliver" && transaction.DeliveredAt == nil {
+				returned -= max(tItem.Quantity-tItem.RefundedQuantity-tItem.DeliveredQuantity, 0)
+			}
+			// Then what is still backordered, it was never taken from stock either
+			if returned > 0 {
+				reduced, err := reduceOpenBackorders(tx, transaction.ID, tItem.ItemID, returned)
+				if err != nil {
+					return nil, err
+				}
+				returned -= reduced
+			}
+			if returned > 0 {
+				returnedItems = append(returnedItems, models.TransactionItem{ItemID: tItem.ItemID, Quantity: returned})
+			}
 
-			if err := invService.LogStockChange(tx, tItem.ItemID, change, "refund", ref, userID, note); err != nil {
-				return err
+			tItem.RefundedQuantity += quantity
+			if err := tx.Model(tItem).Update("refunded_quantity", tItem.RefundedQuantity).Error; err != nil {
+				return nil, err
 			}
 		}
 
-		transaction.Status = "refunded"
-		transaction.Payment = nil
-		transaction.Change = nil
-
-		if err := tx.Save(&transaction).Error; err != nil {
-			return err
+		if tItem.RefundedQuantity < tItem.Quantity {
+			fullyRefunded = false
 		}
+	}
+	for itemID := range requested {
+		return nil, fmt.Errorf("transaction item %d not found", itemID)
+	}
 
-		return nil
-	})
-
-	if err != nil {
+	// The last refund takes whatever is left so rounding never leaves cents behind
+	var alreadyRefunded float64
+	if err := tx.Model(&models.Refund{}).Where("transaction_id = ?", transaction.ID).
+		Select("COALESCE(SUM(amount), 0)").Scan(&alreadyRefunded).Error; err != nil {
 		return nil, err
 	}
+	amount = math.Round(amount*100) / 100
+	if fullyRefunded || amount > transaction.Total-alreadyRefunded {
+		amount = transaction.Total - alreadyRefunded
+	}
 
-	return &transaction, nil
-}
+	// On a credit sale the refund first comes off what the customer still owes,
+	// only the part they already paid is handed back
+	credited := math.Min(amount, transaction.AmountDue)
+	if credited < 0 {
+		credited = 0
+	}
 
-// Helper to deduct stock, log stock changes, and calculate stock warnings
-func deductStockForTransaction(tx *gorm.DB, items []models.TransactionItem, transactionID uint, userID *uint, note string) ([]string, error) {
-	var warnings []string
-	invService := NewInventoryService()
+	method := "cash"
+	if input.Method != nil {
+		method = *input.Method
+	} else if credited > 0 && credited == amount {
+		method = "credit"
+	} else if transaction.PaymentType != nil && *transaction.PaymentType != "split" && *transaction.PaymentType != "credit" {
+		method = *transaction.PaymentType
+	}
+	if method == "credit" && amount > credited {
+		return nil, errors.New("credit refund exceeds the outstanding balance of this transaction")
+	}
 
-	for _, tItem := range items {
-		var item models.Item
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, tItem.ItemID).Error; err != nil {
+	number, err := nextDocumentNumber(tx, config.DocRefund, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	refund := models.Refund{
+		Number:         number,
+		TransactionID:  transaction.ID,
+		Reason:         input.Reason,
+		Amount:         amount,
+		CreditedAmount: credited,
+		Method:         method,
+		UserID:         userID,
+		Lines:          refundLines,
+	}
+	if method == "cash" && amount > credited {
+		sessionID, err := openCashSessionID(tx, userID)
+		if err != nil {
 			return nil, err
 		}
+		refund.CashSessionID = sessionID
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		return nil, err
+	}
 
-		if item.IsStockManaged == nil || !*item.IsStockManaged {
-			continue
+	if credited > 0 {
+		transaction.AmountDue = math.Round((transaction.AmountDue-credited)*100) / 100
+		if err := tx.Model(transaction).Update("amount_due", transaction.AmountDue).Error; err != nil {
+			return nil, err
 		}
+	}
 
-		if item.Stock < tItem.Quantity {
-			warnings = append(warnings,
-				fmt.Sprintf(
-					"Warning: Item '%s' stock insufficient (current: %d, required: %d)",
-					item.Name, item.Stock, tItem.Quantity,
-				),
-			)
-			item.Stock = 0
-		} else {
-			item.Stock -= tItem.Quantity
+	// Goods not delivered yet never left the shop, so only the hold shrinks to what is still owed
+	if transaction.TransactionType == "deliver" && transaction.DeliveredAt == nil {
+		reservationService := NewReservationService()
+		if err := reservationService.ReleaseForTransaction(tx, transaction.ID); err != nil {
+			return nil, err
+		}
+		undelivered := undeliveredItems(transaction.Items)
+		if err := reservationService.ReserveForTransaction(tx, transaction.ID, undelivered, "delivery"); err != nil {
+			return nil, err
+		}
+		// Cancelling the rest of a partly delivered order completes the delivery
+		if len(undelivered) == 0 && !fullyRefunded {
+			now := time.Now()
+			transaction.DeliveredAt = &now
+			if err := tx.Model(transaction).Update("delivered_at", now).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ref := transactionNumber(transaction) + " (REFUND)"
+	for _, tItem := range returnedItems {
+		var item models.Item
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, tItem.ItemID).Error; err != nil {
+			return nil, err
+		}
+
+		if item.IsStockManaged == nil || !*item.IsStockManaged {
+			continue
 		}
 
+		item.Stock += tItem.Quantity
 		if err := tx.Save(&item).Error; err != nil {
 			return nil, err
 		}
 
-		change := -tItem.Quantity
-		ref := fmt.Sprintf("TX-%d", transactionID)
-		if err := invService.LogStockChange(tx, tItem.ItemID, change, "sale", ref, userID, note); err != nil {
+		if err := logTransactionStockChange(tx, transaction, tItem.ItemID, tItem.Quantity, "refund", ref, userID, "Refunded "+refund.Number); err != nil {
 			return nil, err
 		}
 	}
 
-	return warnings, nil
+	transaction.Status = "partially_refunded"
+	if fullyRefunded {
+		transaction.Status = "refunded"
+		if err := releaseCoupons(tx, transaction.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Model(transaction).Update("status", transaction.Status).Error; err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+// VoidTransaction cancels a completed sale rung up by mistake. Unlike a refund it leaves no
+// refund document: the sale drops out of sales and refund figures alike, its tenders out of
+// the drawer count, and whatever stock it took goes back on the ledger as "void". It needs
+// a supervisor's PIN and is only possible while the drawer the sale was paid into is still open.
+func (s *transactionService) VoidTransaction(id string, input dtos.VoidTransactionInput, userID *uint, clientIP string) (*models.Transaction, error) {
+	supervisor, err := verifySupervisorPIN(input.SupervisorUsername, input.SupervisorPIN)
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var transaction models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Payments").First(&transaction, id).Error; err != nil {
+			return errors.New("transaction not found")
+		}
+
+		if transaction.Status != "completed" {
+			return errors.New("only completed transactions can be voided")
+		}
+		for _, payment := range transaction.Payments {
+			if payment.Method == "exchange" {
+				return errors.New("an exchange sale cannot be voided")
+			}
+		}
+
+		// The drawer the sale was paid into must still be open, the money goes back out of it
+		var session models.CashSession
+		if transaction.CashSessionID != nil {
+			if err := tx.First(&session, *transaction.CashSessionID).Error; err != nil {
+				return err
+			}
+		}
+		if session.Status != "open" {
+			return errors.New("transactions can only be voided while the cash session is open")
+		}
+
+		var allocated int64
+		if err := tx.Model(&models.CustomerPaymentAllocation{}).Where("transaction_id = ?", transaction.ID).Count(&allocated).Error; err != nil {
+			return err
+		}
+		if allocated > 0 {
+			return errors.New("transaction has customer payments, refund it instead")
+		}
+		var openOrders int64
+		if err := tx.Model(&models.DeliveryOrder{}).
+			Where("transaction_id = ? AND status IN ?", transaction.ID, openDeliveryStatuses).
+			Count(&openOrders).Error; err != nil {
+			return err
+		}
+		if openOrders > 0 {
+			return errors.New("transaction has open delivery orders")
+		}
+
+		oldCopy := transaction
+
+		if err := restockVoidedTransaction(tx, &transaction, userID); err != nil {
+			return err
+		}
+		if err := NewReservationService().ReleaseForTransaction(tx, transaction.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Backorder{}).
+			Where("transaction_id = ? AND status = ?", transaction.ID, "open").
+			Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+		// The quotation can be converted again into the corrected sale
+		if err := reopenQuotation(tx, transaction.ID); err != nil {
+			return err
+		}
+		if err := releaseCoupons(tx, transaction.ID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		reason := input.Reason
+		transaction.Status = "voided"
+		transaction.AmountDue = 0
+		transaction.VoidedAt = &now
+		transaction.VoidReason = &reason
+		transaction.VoidedByID = userID
+		transaction.VoidApprovedByID = &supervisor.ID
+		if err := tx.Model(&transaction).Updates(map[string]any{
+			"status":              transaction.Status,
+			"amount_due":          transaction.AmountDue,
+			"voided_at":           transaction.VoidedAt,
+			"void_reason":         transaction.VoidReason,
+			"voided_by_id":        transaction.VoidedByID,
+			"void_approved_by_id": transaction.VoidApprovedByID,
+		}).Error; err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("Transaction #%d voided, approved by %s: %s", transaction.ID, supervisor.Username, reason)
+		return log.CreateTransactionAuditLog(
+			tx,
+			"update",
+			transaction.ID,
+			&oldCopy,
+			&transaction,
+			userID,
+			clientIP,
+			description,
+		)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTransactionByID(id)
+}
+
+// restockVoidedTransaction puts back exactly what the ledger shows the sale took, which
+// covers partial deliveries and fulfilled backorders without working them out again.
+// Ledger rows are matched by transaction ID, the number on them may predate the invoice number.
+func restockVoidedTransaction(tx *gorm.DB, transaction *models.Transaction, userID *uint) error {
+	ref := transactionNumber(transaction)
+
+	var taken []struct {
+		ItemID   uint
+		Quantity int
+	}
+	if err := tx.Model(&models.InventoryLog{}).
+		Select("item_id, -SUM(`change`) AS quantity").
+		Where("type = ? AND transaction_id = ?", "sale", transaction.ID).
+		Group("item_id").
+		Scan(&taken).Error; err != nil {
+		return err
+	}
+
+	for _, line := range taken {
+		if line.Quantity <= 0 {
+			continue
+		}
+
+		var item models.Item
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, line.ItemID).Error; err != nil {
+			return err
+		}
+
+		item.Stock += line.Quantity
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+
+		if err := logTransactionStockChange(tx, transaction, item.ID, line.Quantity, "void", ref+" (VOID)", userID, "Sale voided"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// undeliveredItems returns the lines of a deliver order reduced to what still has to go out
+func undeliveredItems(items []models.TransactionItem) []models.TransactionItem {
+	var undelivered []models.TransactionItem
+	for _, tItem := range items {
+		if quantity := tItem.Quantity - tItem.RefundedQuantity - tItem.DeliveredQuantity; quantity > 0 {
+			tItem.Quantity = quantity
+			undelivered = append(undelivered, tItem)
+		}
+	}
+	return undelivered
+}
+
+// MarkDelivered hands everything left on a completed deliver order over to the customer
+// at once, without a delivery order: the stock hold becomes a real deduction on the ledger.
+func (s *transactionService) MarkDelivered(id string, userID *uint, role string, clientIP string) (*models.Transaction, []string, error) {
+	var transaction models.Transaction
+	var warnings []string
+	var shortages []models.StockShortage
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&transaction, id).Error; err != nil {
+			return errors.New("transaction not found")
+		}
+
+		if !slices.Contains(deliverableStatuses, transaction.Status) || transaction.TransactionType != "deliver" {
+			return errors.New("only completed deliver transactions can be delivered")
+		}
+		if transaction.DeliveredAt != nil {
+			return errors.New("transaction already delivered")
+		}
+		var openOrders int64
+		if err := tx.Model(&models.DeliveryOrder{}).
+			Where("transaction_id = ? AND status IN ?", transaction.ID, openDeliveryStatuses).
+			Count(&openOrders).Error; err != nil {
+			return err
+		}
+		if openOrders > 0 {
+			return errors.New("transaction has open delivery orders")
+		}
+
+		oldCopy := transaction
+
+		undelivered := undeliveredItems(transaction.Items)
+		var err error
+		warnings, shortages, err = deductStockForTransaction(tx, undelivered, &transaction, userID, role, "Delivered to customer")
+		if err != nil {
+			return err
+		}
+		for i := range transaction.Items {
+			tItem := &transaction.Items[i]
+			tItem.DeliveredQuantity = max(tItem.Quantity-tItem.RefundedQuantity, 0)
+			if err := tx.Model(tItem).Update("delivered_quantity", tItem.DeliveredQuantity).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := NewReservationService().FulfillForTransaction(tx, transaction.ID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		transaction.DeliveredAt = &now
+		if err := tx.Save(&transaction).Error; err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("Transaction #%d delivered", transaction.ID)
+		return log.CreateTransactionAuditLog(
+			tx,
+			"update",
+			transaction.ID,
+			&oldCopy,
+			&transaction,
+			userID,
+			clientIP,
+			description,
+		)
+	})
+
+	recordStockShortages(shortages, transaction.ID, err == nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := config.DB.Preload("Items.Item").Preload("Payments").First(&transaction, transaction.ID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &transaction, warnings, nil
+}
+
+// priceSaleLine prices one sale line at the item's list price, or the custom price when given,
+// less the cashier's line discount. The discount, counting a custom price below list as part
+// of it, may not go beyond the role's limit.
+func priceSaleLine(item models.Item, input dtos.TransactionItemInput, role string) (models.TransactionItem, error) {
+	listPrice := item.Price
+	if input.ListPrice != nil {
+		listPrice = *input.ListPrice
+	}
+	price := listPrice
+	if input.CustomPrice != nil {
+		price = *input.CustomPrice
+	}
+	gross := float64(input.Quantity) * price
+
+	line := models.TransactionItem{
+		ItemID:    item.ID,
+		Quantity:  input.Quantity,
+		ListPrice: listPrice,
+		Price:     price,
+		Subtotal:  gross,
+		TaxRate:   itemTaxRate(item),
+	}
+
+	if input.DiscountAmount != nil && input.DiscountPercent != nil {
+		return line, fmt.Errorf("discount for '%s' must be either discount_amount or discount_percent, not both", item.Name)
+	}
+	discount := 0.0
+	if input.DiscountAmount != nil {
+		discount = *input.DiscountAmount
+	} else if input.DiscountPercent != nil {
+		discount = gross * *input.DiscountPercent / 100
+	}
+	discount = roundMoney(discount)
+
+	if discount > 0 {
+		if input.Quantity < 0 || discount > gross {
+			return line, fmt.Errorf("discount on '%s' is larger than the line", item.Name)
+		}
+		if input.DiscountReason == nil || strings.TrimSpace(*input.DiscountReason) == "" {
+			return line, fmt.Errorf("discount_reason is required for the discount on '%s'", item.Name)
+		}
+		reason := strings.TrimSpace(*input.DiscountReason)
+		line.LineDiscount = discount
+		line.DiscountReason = &reason
+		line.Subtotal = roundMoney(gross - discount)
+	}
+
+	listTotal := float64(input.Quantity) * listPrice
+	if listTotal > 0 && line.Subtotal < listTotal {
+		percent := (listTotal - line.Subtotal) / listTotal * 100
+		if limit := config.MaxLineDiscountPercent(role); percent > limit+0.005 {
+			return line, fmt.Errorf("discount on '%s' is %.1f%%, above the %.0f%% allowed for %s", item.Name, percent, limit, role)
+		}
+	}
+
+	return line, nil
+}
+
+// itemTaxRate is the PPN percent charged on an item, 0 when it is exempt
+func itemTaxRate(item models.Item) float64 {
+	if item.IsTaxable != nil && !*item.IsTaxable {
+		return 0
+	}
+	if item.TaxRate != nil {
+		return *item.TaxRate
+	}
+	return config.TaxRate()
+}
+
+// applyTransactionTax splits the sale's Total, which must be net of all discounts and
+// before any added PPN, over the lines pro rata and works out each line's DPP and PPN.
+// With tax-exclusive pricing the PPN is added to Total, otherwise it is already inside it.
+func applyTransactionTax(transaction *models.Transaction) {
+	var gross float64
+	for _, line := range transaction.Items {
+		gross += line.Subtotal
+	}
+	share := 0.0
+	if gross > 0 {
+		share = transaction.Total / gross
+	}
+
+	var base, tax float64
+	for i := range transaction.Items {
+		line := &transaction.Items[i]
+		lineNet := line.Subtotal * share
+		if transaction.TaxExclusive {
+			line.TaxBase = roundMoney(lineNet)
+			line.TaxAmount = roundMoney(lineNet * line.TaxRate / 100)
+		} else {
+			line.TaxBase = roundMoney(lineNet / (1 + line.TaxRate/100))
+			line.TaxAmount = roundMoney(lineNet - line.TaxBase)
+		}
+		if line.TaxRate > 0 {
+			base += line.TaxBase
+			tax += line.TaxAmount
+		}
+	}
+
+	transaction.TaxBase = roundMoney(base)
+	transaction.TaxAmount = roundMoney(tax)
+	if transaction.TaxExclusive {
+		transaction.Total = roundMoney(transaction.Total + transaction.TaxAmount)
+	}
+}
+
+// validateTransactionCustomer checks the customer exists and the delivery address is one of theirs
+func validateTransactionCustomer(tx *gorm.DB, customerID, addressID *uint) error {
+	if customerID == nil {
+		if addressID != nil {
+			return errors.New("customer_address_id requires customer_id")
+		}
+		return nil
+	}
+
+	var customer models.Customer
+	if err := tx.Select("id").First(&customer, *customerID).Error; err != nil {
+		return errors.New("customer not found")
+	}
+
+	if addressID != nil {
+		var count int64
+		if err := tx.Model(&models.CustomerAddress{}).
+			Where("id = ? AND customer_id = ?", *addressID, *customerID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("address not found for this customer")
+		}
+	}
+	return nil
+}
+
+// buildTransactionPayments turns the tenders of a sale into payment rows.
+// Clients that still send a single paymentAmount/paymentType get one tender (cash by default).
+func buildTransactionPayments(input dtos.CreateTransactionInput, total float64) ([]models.TransactionPayment, error) {
+	payments, err := collectPayments(input, total)
+	if err != nil {
+		return nil, err
+	}
+
+	var paid float64
+	for _, p := range payments {
+		paid += p.Amount
+	}
+	if len(payments) == 0 || paid < total {
+		return nil, errors.New("payment not enough")
+	}
+
+	return payments, nil
+}
+
+// collectPayments reads the tenders of a sale from either the payments list or the old
+// paymentAmount/paymentType pair
+func collectPayments(input dtos.CreateTransactionInput, total float64) ([]models.TransactionPayment, error) {
+	var payments []models.TransactionPayment
+	if len(input.Payments) > 0 {
+		for _, p := range input.Payments {
+			payments = append(payments, models.TransactionPayment{
+				Method:    p.Method,
+				Amount:    p.Amount,
+				Reference: p.Reference,
+			})
+		}
+	} else if input.PaymentAmount == nil && input.PaymentType != nil && *input.PaymentType == "credit" {
+		// Old clients put a whole sale on account by sending only paymentType "credit"
+		payments = append(payments, models.TransactionPayment{
+			Method: "credit",
+			Amount: total,
+		})
+	} else if input.PaymentAmount != nil {
+		method := "cash"
+		if input.PaymentType != nil && *input.PaymentType != "" {
+			method = *input.PaymentType
+		}
+		payments = append(payments, models.TransactionPayment{
+			Method: method,
+			Amount: *input.PaymentAmount,
+		})
+	}
+
+	for _, p := range payments {
+		if p.Method != "cash" && p.Method != "qris" && p.Method != "debit" && p.Method != "credit" {
+			return nil, fmt.Errorf("invalid payment method '%s'", p.Method)
+		}
+	}
+
+	return payments, nil
+}
+
+// takeDownPayment records the payments of a partially paid sale as its down payment and
+// plans the balance. The customer is required, they come back to pay the rest.
+func takeDownPayment(transaction *models.Transaction, input dtos.CreateTransactionInput, total float64) error {
+	if transaction.CustomerID == nil {
+		return errors.New("down payments require a customer")
+	}
+
+	payments, err := collectPayments(input, total)
+	if err != nil {
+		return err
+	}
+	var paid float64
+	paymentType := ""
+	for _, p := range payments {
+		if p.Method == "credit" {
+			return errors.New("a down payment cannot be put on credit")
+		}
+		paid += p.Amount
+		if paymentType == "" {
+			paymentType = p.Method
+		} else if p.Method != paymentType {
+			paymentType = "split"
+		}
+	}
+	if paid <= 0 {
+		return errors.New("down payment is required")
+	}
+	if paid >= total {
+		return errors.New("down payment covers the total, complete the transaction instead")
+	}
+
+	balance := roundMoney(total - paid)
+	today := time.Now().Format("2006-01-02")
+	var schedule []models.PaymentScheduleEntry
+	var planned float64
+	for _, entry := range input.PaymentSchedule {
+		dueDate, err := time.ParseInLocation("2006-01-02", entry.DueDate, time.Local)
+		if err != nil {
+			return errors.New("invalid payment schedule due_date, use YYYY-MM-DD")
+		}
+		if entry.DueDate < today {
+			return errors.New("payment schedule due_date cannot be in the past")
+		}
+		schedule = append(schedule, models.PaymentScheduleEntry{DueDate: dueDate, Amount: entry.Amount, Note: entry.Note})
+		planned += entry.Amount
+	}
+	if len(schedule) > 0 && math.Abs(planned-balance) > 0.005 {
+		return fmt.Errorf("payment schedule must add up to the balance due (%.2f)", balance)
+	}
+	slices.SortStableFunc(schedule, func(a, b models.PaymentScheduleEntry) int {
+		return a.DueDate.Compare(b.DueDate)
+	})
+
+	change := 0.0
+	transaction.Payment = &paid
+	transaction.Change = &change
+	transaction.PaymentType = &paymentType
+	transaction.Payments = payments
+	transaction.PaymentSchedule = schedule
+	transaction.BalanceDue = balance
+	return nil
+}
+
+// allocateSchedulePayment fills the open installments of a payment schedule in due date order
+func allocateSchedulePayment(tx *gorm.DB, schedule []models.PaymentScheduleEntry, amount float64) error {
+	for _, entry := range schedule {
+		if amount <= 0 {
+			break
+		}
+		open := roundMoney(entry.Amount - entry.PaidAmount)
+		if open <= 0 {
+			continue
+		}
+		take := min(open, amount)
+		if err := tx.Model(&entry).Update("paid_amount", roundMoney(entry.PaidAmount+take)).Error; err != nil {
+			return err
+		}
+		amount = roundMoney(amount - take)
+	}
+	return nil
+}
+
+// Helper to deduct stock, log stock changes, and calculate stock warnings.
+// When a line asks for more than the stock on hand the negative-stock policy decides:
+// "reject" fails the whole sale, "allow" lets stock go negative and "backorder"
+// takes what is on hand and opens a backorder for the rest. The ledger Change always
+// equals the real stock movement. Shortages are returned for recordStockShortages.
+func deductStockForTransaction(tx *gorm.DB, items []models.TransactionItem, transaction *models.Transaction, userID *uint, role string, note string) ([]string, []models.StockShortage, error) {
+	var warnings []string
+	var shortages []models.StockShortage
+	ref := transactionNumber(transaction)
+
+	for _, tItem := range items {
+		var item models.Item
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, tItem.ItemID).Error; err != nil {
+			return nil, shortages, err
+		}
+
+		if item.IsStockManaged == nil || !*item.IsStockManaged {
+			continue
+		}
+
+		deducted := tItem.Quantity
+		if item.Stock < tItem.Quantity {
+			policy := resolveStockPolicy(item, role)
+			// An offline sale already handed the goods over, it cannot be turned down any more
+			if policy == "reject" && transaction.ClientUUID != nil {
+				policy = "allow"
+			}
+			shortages = append(shortages, models.StockShortage{
+				ItemID:    item.ID,
+				Required:  tItem.Quantity,
+				Available: item.Stock,
+				Policy:    policy,
+				Role:      role,
+				UserID:    userID,
+			})
+
+			switch policy {
+			case "reject":
+				return nil, shortages, fmt.Errorf(
+					"insufficient stock for item '%s' (current: %d, required: %d)",
+					item.Name, item.Stock, tItem.Quantity,
+				)
+			case "backorder":
+				deducted = 0
+				if item.Stock > 0 {
+					deducted = item.Stock
+				}
+				backorder := models.Backorder{
+					TransactionID: transaction.ID,
+					ItemID:        item.ID,
+					Quantity:      tItem.Quantity - deducted,
+					Status:        "open",
+				}
+				if err := tx.Create(&backorder).Error; err != nil {
+					return nil, shortages, err
+				}
+				warnings = append(warnings,
+					fmt.Sprintf(
+						"Warning: Item '%s' stock insufficient (current: %d, required: %d), %d backordered",
+						item.Name, item.Stock, tItem.Quantity, backorder.Quantity,
+					),
+				)
+			default:
+				warnings = append(warnings,
+					fmt.Sprintf(
+						"Warning: Item '%s' stock insufficient (current: %d, required: %d), stock is now negative",
+						item.Name, item.Stock, tItem.Quantity,
+					),
+				)
+			}
+		}
+
+		if deducted == 0 {
+			continue
+		}
+
+		item.Stock -= deducted
+		if err := tx.Save(&item).Error; err != nil {
+			return nil, shortages, err
+		}
+
+		if err := logTransactionStockChange(tx, transaction, tItem.ItemID, -deducted, "sale", ref, userID, note); err != nil {
+			return nil, shortages, err
+		}
+	}
+
+	return warnings, shortages, nil
+}
+
+// resolveStockPolicy picks the negative-stock policy for a sale line.
+// A role override wins (e.g. owners may always oversell), then the item's own policy, then the global default.
+func resolveStockPolicy(item models.Item, role string) string {
+	policy, fromRole := config.NegativeStockPolicy(role)
+	if !fromRole && item.NegativeStockPolicy != nil && config.IsValidStockPolicy(*item.NegativeStockPolicy) {
+		return *item.NegativeStockPolicy
+	}
+	return policy
+}
+
+// recordStockShortages stores shortage events after the sale's DB transaction has finished,
+// so rejections survive the rollback. A sale that did not commit keeps only its rejections.
+func recordStockShortages(shortages []models.StockShortage, transactionID uint, committed bool) {
+	var events []models.StockShortage
+	for _, shortage := range shortages {
+		if !committed && shortage.Policy != "reject" {
+			continue
+		}
+		if transactionID != 0 {
+			id := transactionID
+			shortage.TransactionID = &id
+		}
+		events = append(events, shortage)
+	}
+
+	if len(events) == 0 {
+		return
+	}
+	_ = config.DB.Create(&events).Error
 }
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+)
+
+func TestApplyTransactionTax(t *testing.T) {
+	tests := []struct {
+		name         string
+		taxExclusive bool
+		total        float64
+		lines        []models.TransactionItem
+		wantBase     float64
+		wantTax      float64
+		wantTotal    float64
+		wantLineTax  []float64
+	}{
+		{
+			name:        "inclusive, PPN is taken out of the price",
+			total:       111,
+			lines:       []models.TransactionItem{{Subtotal: 111, TaxRate: 11}},
+			wantBase:    100,
+			wantTax:     11,
+			wantTotal:   111,
+			wantLineTax: []float64{11},
+		},
+		{
+			name:         "exclusive, PPN is added on top",
+			taxExclusive: true,
+			total:        100,
+			lines:        []models.TransactionItem{{Subtotal: 100, TaxRate: 11}},
+			wantBase:     100,
+			wantTax:      11,
+			wantTotal:    111,
+			wantLineTax:  []float64{11},
+		},
+		{
+			name:        "inclusive, exempt lines stay out of the tax base",
+			total:       161,
+			lines:       []models.TransactionItem{{Subtotal: 111, TaxRate: 11}, {Subtotal: 50, TaxRate: 0}},
+			wantBase:    100,
+			wantTax:     11,
+			wantTotal:   161,
+			wantLineTax: []float64{11, 0},
+		},
+		{
+			name:         "exclusive, the sale discount is spread over the lines",
+			taxExclusive: true,
+			total:        150,
+			lines:        []models.TransactionItem{{Subtotal: 100, TaxRate: 11}, {Subtotal: 100, TaxRate: 0}},
+			wantBase:     75,
+			wantTax:      8.25,
+			wantTotal:    158.25,
+			wantLineTax:  []float64{8.25, 0},
+		},
+		{
+			name:         "inclusive, fully discounted sale",
+			taxExclusive: false,
+			total:        0,
+			lines:        []models.TransactionItem{{Subtotal: 100, TaxRate: 11}},
+			wantLineTax:  []float64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := models.Transaction{TaxExclusive: tt.taxExclusive, Total: tt.total, Items: tt.lines}
+			applyTransactionTax(&transaction)
+
+			if transaction.TaxBase != tt.wantBase || transaction.TaxAmount != tt.wantTax || transaction.Total != tt.wantTotal {
+				t.Errorf("base %.2f, tax %.2f, total %.2f, want %.2f, %.2f, %.2f",
+					transaction.TaxBase, transaction.TaxAmount, transaction.Total, tt.wantBase, tt.wantTax, tt.wantTotal)
+			}
+			for i, want := range tt.wantLineTax {
+				if got := transaction.Items[i].TaxAmount; got != want {
+					t.Errorf("line %d: tax %.2f, want %.2f", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPriceSaleLineDiscountLimits(t *testing.T) {
+	item := models.Item{ID: 1, Name: "Kabel", Price: 100}
+	float := func(v float64) *float64 { return &v }
+	reason := "Loyal customer"
+
+	tests := []struct {
+		name         string
+		role         string
+		env          map[string]string
+		input        dtos.TransactionItemInput
+		wantSubtotal float64
+		wantErr      string
+	}{
+		{
+			name:         "cashier at the 10% default",
+			role:         "cashier",
+			input:        dtos.TransactionItemInput{Quantity: 2, DiscountPercent: float(10), DiscountReason: &reason},
+			wantSubtotal: 180,
+		},
+		{
+			name:    "cashier above the 10% default",
+			role:    "cashier",
+			input:   dtos.TransactionItemInput{Quantity: 2, DiscountPercent: float(15), DiscountReason: &reason},
+			wantErr: "above the 10% allowed for cashier",
+		},
+		{
+			name:         "admin within the 25% default",
+			role:         "admin",
+			input:        dtos.TransactionItemInput{Quantity: 1, DiscountPercent: float(15), DiscountReason: &reason},
+			wantSubtotal: 85,
+		},
+		{
+			name:    "admin custom price below the limit",
+			role:    "admin",
+			input:   dtos.TransactionItemInput{Quantity: 1, CustomPrice: float(70)},
+			wantErr: "above the 25% allowed for admin",
+		},
+		{
+			name:         "owner has no limit",
+			role:         "owner",
+			input:        dtos.TransactionItemInput{Quantity: 1, CustomPrice: float(10)},
+			wantSubtotal: 10,
+		},
+		{
+			name:         "limit raised for the role",
+			role:         "cashier",
+			env:          map[string]string{"MAX_LINE_DISCOUNT_CASHIER": "20"},
+			input:        dtos.TransactionItemInput{Quantity: 1, DiscountPercent: float(15), DiscountReason: &reason},
+			wantSubtotal: 85,
+		},
+		{
+			name:    "custom price and line discount add up",
+			role:    "cashier",
+			input:   dtos.TransactionItemInput{Quantity: 1, CustomPrice: float(95), DiscountAmount: float(10), DiscountReason: &reason},
+			wantErr: "is 15.0%, above the 10% allowed for cashier",
+		},
+		{
+			name:    "offline price measured against the shelf price of the time",
+			role:    "cashier",
+			input:   dtos.TransactionItemInput{Quantity: 1, CustomPrice: float(100), ListPrice: float(120)},
+			wantErr: "above the 10% allowed for cashier",
+		},
+		{
+			name:         "price above list is no discount",
+			role:         "cashier",
+			input:        dtos.TransactionItemInput{Quantity: 1, CustomPrice: float(150)},
+			wantSubtotal: 150,
+		},
+		{
+			name:    "discount without a reason",
+			role:    "owner",
+			input:   dtos.TransactionItemInput{Quantity: 1, DiscountAmount: float(5)},
+			wantErr: "discount_reason is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, role := range []string{"CASHIER", "ADMIN", "OWNER"} {
+				t.Setenv("MAX_LINE_DISCOUNT_"+role, tt.env["MAX_LINE_DISCOUNT_"+role])
+			}
+
+			tt.input.ItemID = item.ID
+			line, err := priceSaleLine(item, tt.input, tt.role)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if line.Subtotal != tt.wantSubtotal {
+				t.Errorf("subtotal %.2f, want %.2f", line.Subtotal, tt.wantSubtotal)
+			}
+		})
+	}
+}
@@ -41,6 +41,13 @@ func (s *userService) CreateUser(input dtos.CreateUserInput) (models.User, error
 		Password: string(hashedPassword),
 		Role:     input.Role,
 	}
+	if input.PIN != "" {
+		hashedPIN, err := bcrypt.GenerateFromPassword([]byte(input.PIN), bcrypt.DefaultCost)
+		if err != nil {
+			return models.User{}, err
+		}
+		user.PIN = string(hashedPIN)
+	}
 
 	if err := config.DB.Create(&user).Error; err != nil {
 		return models.User{}, errors.New("failed to create user, username might exist")
@@ -69,6 +76,13 @@ func (s *userService) UpdateUser(id string, input dtos.UpdateUserInput) (models.
 		}
 		user.Password = string(hashedPassword)
 	}
+	if input.PIN != "" {
+		hashedPIN, err := bcrypt.GenerateFromPassword([]byte(input.PIN), bcrypt.DefaultCost)
+		if err != nil {
+			return models.User{}, err
+		}
+		user.PIN = string(hashedPIN)
+	}
 
 	if err := config.DB.Save(&user).Error; err != nil {
 		return models.User{}, err
@@ -84,3 +98,15 @@ func (s *userService) DeleteUser(id string) error {
 	}
 	return nil
 }
+
+// verifySupervisorPIN checks the PIN of an owner or admin approving a cashier's action.
+// Unknown users, other roles and users without a PIN all fail the same way.
+func verifySupervisorPIN(username string, pin string) (*models.User, error) {
+	var supervisor models.User
+	err := config.DB.Where("username = ? AND role IN ?", username, []string{"owner", "admin"}).First(&supervisor).Error
+	if err != nil || supervisor.PIN == "" ||
+		bcrypt.CompareHashAndPassword([]byte(supervisor.PIN), []byte(pin)) != nil {
+		return nil, errors.New("invalid supervisor PIN")
+	}
+	return &supervisor, nil
+}
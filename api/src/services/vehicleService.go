@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+)
+
+type VehicleService interface {
+	GetVehicles() ([]models.Vehicle, error)
+	GetVehicleByID(id uint) (*models.Vehicle, error)
+	CreateVehicle(input dtos.CreateVehicleInput) (*models.Vehicle, error)
+	UpdateVehicle(id uint, input dtos.UpdateVehicleInput) (*models.Vehicle, error)
+	DeleteVehicle(id uint) error
+}
+
+type vehicleService struct{}
+
+func NewVehicleService() VehicleService {
+	return &vehicleService{}
+}
+
+func (s *vehicleService) GetVehicles() ([]models.Vehicle, error) {
+	var vehicles []models.Vehicle
+	if err := config.DB.Order("name ASC").Find(&vehicles).Error; err != nil {
+		return nil, err
+	}
+	return vehicles, nil
+}
+
+func (s *vehicleService) GetVehicleByID(id uint) (*models.Vehicle, error) {
+	var vehicle models.Vehicle
+	if err := config.DB.First(&vehicle, id).Error; err != nil {
+		return nil, errors.New("vehicle not found")
+	}
+	return &vehicle, nil
+}
+
+func (s *vehicleService) CreateVehicle(input dtos.CreateVehicleInput) (*models.Vehicle, error) {
+	var existing models.Vehicle
+	if err := config.DB.Where("plate_number = ?", input.PlateNumber).First(&existing).Error; err == nil {
+		return nil, errors.New("vehicle with this plate number already exists")
+	}
+
+	vehicle := models.Vehicle{
+		PlateNumber: input.PlateNumber,
+		Name:        input.Name,
+		MaxWeightKg: input.MaxWeightKg,
+		MaxVolumeM3: input.MaxVolumeM3,
+		IsActive:    true,
+		Note:        input.Note,
+	}
+
+	if err := config.DB.Create(&vehicle).Error; err != nil {
+		return nil, err
+	}
+	return &vehicle, nil
+}
+
+func (s *vehicleService) UpdateVehicle(id uint, input dtos.UpdateVehicleInput) (*models.Vehicle, error) {
+	var vehicle models.Vehicle
+	if err := config.DB.First(&vehicle, id).Error; err != nil {
+		return nil, errors.New("vehicle not found")
+	}
+
+	if input.PlateNumber != nil && *input.PlateNumber != vehicle.PlateNumber {
+		var existing models.Vehicle
+		if err := config.DB.Where("plate_number = ? AND id != ?", *input.PlateNumber, vehicle.ID).First(&existing).Error; err == nil {
+			return nil, errors.New("vehicle with this plate number already exists")
+		}
+		vehicle.PlateNumber = *input.PlateNumber
+	}
+	if input.Name != nil {
+		vehicle.Name = *input.Name
+	}
+	if input.MaxWeightKg != nil {
+		vehicle.MaxWeightKg = *input.MaxWeightKg
+	}
+	if input.MaxVolumeM3 != nil {
+		vehicle.MaxVolumeM3 = *input.MaxVolumeM3
+	}
+	if input.IsActive != nil {
+		vehicle.IsActive = *input.IsActive
+	}
+	if input.Note != nil {
+		vehicle.Note = input.Note
+	}
+
+	if err := config.DB.Save(&vehicle).Error; err != nil {
+		return nil, err
+	}
+	return &vehicle, nil
+}
+
+func (s *vehicleService) DeleteVehicle(id uint) error {
+	var vehicle models.Vehicle
+	if err := config.DB.First(&vehicle, id).Error; err != nil {
+		return errors.New("vehicle not found")
+	}
+	return config.DB.Delete(&vehicle).Error
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kd-api/src/config"
+	"kd-api/src/dtos"
+	"kd-api/src/models"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WriteOffService interface {
+	CreateWriteOff(input dtos.CreateWriteOffInput, userID *uint) (*models.StockWriteOff, error)
+	GetWriteOffs(filter dtos.WriteOffFilter) (*dtos.WriteOffListResponse, error)
+	GetShrinkageReport(filter dtos.ShrinkageFilter) (*dtos.ShrinkageReport, error)
+}
+
+type writeOffService struct{}
+
+func NewWriteOffService() WriteOffService {
+	return &writeOffService{}
+}
+
+func (s *writeOffService) CreateWriteOff(input dtos.CreateWriteOffInput, userID *uint) (*models.StockWriteOff, error) {
+	if input.ImageURL != nil && *input.ImageURL == "" {
+		input.ImageURL = nil
+	}
+	if input.ImageURL != nil {
+		if err := ensureImageExists(*input.ImageURL); err != nil {
+			return nil, err
+		}
+	}
+
+	var writeOff models.StockWriteOff
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var item models.Item
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, input.ItemID).Error; err != nil {
+			return errors.New("item not found")
+		}
+
+		if item.IsStockManaged == nil || !*item.IsStockManaged {
+			return errors.New("item stock is not managed")
+		}
+		if input.Quantity > item.Stock {
+			return fmt.Errorf("write-off quantity exceeds stock (current: %d)", item.Stock)
+		}
+
+		writeOff = models.StockWriteOff{
+			ItemID:    item.ID,
+			Quantity:  input.Quantity,
+			Reason:    input.Reason,
+			UnitCost:  item.BuyPrice,
+			TotalCost: float64(input.Quantity) * item.BuyPrice,
+			ImageURL:  input.ImageURL,
+			Note:      input.Note,
+			UserID:    userID,
+		}
+		if err := tx.Create(&writeOff).Error; err != nil {
+			return err
+		}
+
+		item.Stock -= input.Quantity
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+
+		note := "Write-off: " + input.Reason
+		if input.Note != nil && *input.Note != "" {
+			note += " - " + *input.Note
+		}
+		ref := fmt.Sprintf("WO-%d", writeOff.ID)
+		return NewInventoryService().LogStockChange(tx, item.ID, -input.Quantity, "write_off", ref, userID, note)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Preload("Item").Preload("User").First(&writeOff, writeOff.ID).Error; err != nil {
+		return nil, err
+	}
+
+	return &writeOff, nil
+}
+
+func (s *writeOffService) GetWriteOffs(filter dtos.WriteOffFilter) (*dtos.WriteOffListResponse, error) {
+	var writeOffs []models.StockWriteOff
+	var total int64
+
+	db := config.DB.Model(&models.StockWriteOff{})
+	if filter.ItemID != 0 {
+		db = db.Where("item_id = ?", filter.ItemID)
+	}
+	if filter.Reason != "" {
+		db = db.Where("reason = ?", filter.Reason)
+	}
+	if filter.StartDate != "" {
+		db = db.Where("created_at >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		db = db.Where("created_at <= ?", filter.EndDate+" 23:59:59")
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+	offset := (filter.Page - 1) * filter.Limit
+
+	if err := db.Preload("User").Preload("Item").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&writeOffs).Error; err != nil {
+		return nil, err
+	}
+
+	return &dtos.WriteOffListResponse{
+		Data:       writeOffs,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}, nil
+}
+
+// GetShrinkageReport totals write-offs at cost, per period and per reason
+func (s *writeOffService) GetShrinkageReport(filter dtos.ShrinkageFilter) (*dtos.ShrinkageReport, error) {
+	period := filter.Period
+	if period != "day" {
+		period = "month"
+	}
+
+	periodFormat := "%Y-%m"
+	if period == "day" {
+		periodFormat = "%Y-%m-%d"
+	}
+
+	db := config.DB.Model(&models.StockWriteOff{})
+	if filter.StartDate != "" {
+		db = db.Where("created_at >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		db = db.Where("created_at <= ?", filter.EndDate+" 23:59:59")
+	}
+
+	report := &dtos.ShrinkageReport{
+		Period:   period,
+		Rows:     []dtos.ShrinkageRow{},
+		ByReason: []dtos.ShrinkageReasonTotal{},
+	}
+
+	if err := db.Session(&gorm.Session{}).
+		Select("DATE_FORMAT(created_at, ?) AS period, reason, COUNT(*) AS count, "+
+			"COALESCE(SUM(quantity), 0) AS quantity, COALESCE(SUM(total_cost), 0) AS value", periodFormat).
+		Group("period, reason").
+		Order("period ASC, reason ASC").
+		Scan(&report.Rows).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Session(&gorm.Session{}).
+		Select("reason, COUNT(*) AS count, COALESCE(SUM(quantity), 0) AS quantity, COALESCE(SUM(total_cost), 0) AS value").
+		Group("reason").
+		Order("value DESC").
+		Scan(&report.ByReason).Error; err != nil {
+		return nil, err
+	}
+
+	for _, reason := range report.ByReason {
+		report.TotalValue += reason.Value
+	}
+
+	return report, nil
+}
+
+// ensureImageExists checks that a /images/<file> URL points to an uploaded image
+func ensureImageExists(imageURL string) error {
+	fileName := strings.TrimPrefix(imageURL, "/images/")
+	if fileName == imageURL || fileName == "" {
+		return errors.New("image_url must be an uploaded /images/ URL")
+	}
+
+	var count int64
+	if err := config.DB.Model(&models.Image{}).Where("file_name = ?", fileName).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("image not found")
+	}
+	return nil
+}
@@ -58,4 +58,22 @@ func GetStringValue(ptr *string) string {
 		return *ptr
 	}
 	return ""
-}
\ No newline at end of file
+}
+func GetUintValue(ptr *uint) uint {
+	if ptr != nil {
+		return *ptr
+	}
+	return 0
+}
+func GetBoolValue(ptr *bool) bool {
+	if ptr != nil {
+		return *ptr
+	}
+	return false
+}
+func GetFloatValue(ptr *float64) float64 {
+	if ptr != nil {
+		return *ptr
+	}
+	return 0
+}
@@ -57,6 +57,56 @@ func CalculateItemChanges(action string, oldItem, newItem *models.Item) *string
 		}
 	}
 
+	if common.GetStringValue(oldItem.NegativeStockPolicy) != common.GetStringValue(newItem.NegativeStockPolicy) {
+		changes["negative_stock_policy"] = map[string]string{
+			"old": common.GetStringValue(oldItem.NegativeStockPolicy),
+			"new": common.GetStringValue(newItem.NegativeStockPolicy),
+		}
+	}
+
+	if common.GetUintValue(oldItem.SupplierID) != common.GetUintValue(newItem.SupplierID) {
+		changes["supplier_id"] = map[string]uint{
+			"old": common.GetUintValue(oldItem.SupplierID),
+			"new": common.GetUintValue(newItem.SupplierID),
+		}
+	}
+
+	if common.GetBoolValue(oldItem.IsTaxable) != common.GetBoolValue(newItem.IsTaxable) {
+		changes["is_taxable"] = map[string]bool{
+			"old": common.GetBoolValue(oldItem.IsTaxable),
+			"new": common.GetBoolValue(newItem.IsTaxable),
+		}
+	}
+
+	// nil means the item follows PPN_RATE, which is not the same as an explicit 0
+	if floatChanged(oldItem.TaxRate, newItem.TaxRate) {
+		changes["tax_rate"] = map[string]*float64{
+			"old": oldItem.TaxRate,
+			"new": newItem.TaxRate,
+		}
+	}
+
+	if floatChanged(oldItem.WeightKg, newItem.WeightKg) {
+		changes["weight_kg"] = map[string]*float64{
+			"old": oldItem.WeightKg,
+			"new": newItem.WeightKg,
+		}
+	}
+
+	if floatChanged(oldItem.VolumeM3, newItem.VolumeM3) {
+		changes["volume_m3"] = map[string]*float64{
+			"old": oldItem.VolumeM3,
+			"new": newItem.VolumeM3,
+		}
+	}
+
+	if common.GetStringValue(oldItem.Category) != common.GetStringValue(newItem.Category) {
+		changes["category"] = map[string]string{
+			"old": common.GetStringValue(oldItem.Category),
+			"new": common.GetStringValue(newItem.Category),
+		}
+	}
+
 	if len(changes) == 0 {
 		return nil
 	}
@@ -64,6 +114,14 @@ func CalculateItemChanges(action string, oldItem, newItem *models.Item) *string
 	return common.ToJSONString(changes)
 }
 
+// floatChanged compares optional numbers, where nil and 0 are different values
+func floatChanged(before, after *float64) bool {
+	if before == nil || after == nil {
+		return (before == nil) != (after == nil)
+	}
+	return *before != *after
+}
+
 func CreateItemAuditLog(
 	db *gorm.DB,
 	action string,
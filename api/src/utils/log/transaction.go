@@ -46,6 +46,35 @@ func CalculateTransactionChanges(
 		}
 	}
 
+	if oldTx.AmountDue != newTx.AmountDue {
+		changes["amount_due"] = map[string]float64{
+			"old": oldTx.AmountDue,
+			"new": newTx.AmountDue,
+		}
+	}
+
+	if common.GetUintValue(oldTx.CustomerID) != common.GetUintValue(newTx.CustomerID) {
+		changes["customer_id"] = map[string]uint{
+			"old": common.GetUintValue(oldTx.CustomerID),
+			"new": common.GetUintValue(newTx.CustomerID),
+		}
+	}
+
+	if oldTx.PromotionDiscount != newTx.PromotionDiscount {
+		changes["promotion_discount"] = map[string]float64{
+			"old": oldTx.PromotionDiscount,
+			"new": newTx.PromotionDiscount,
+		}
+	}
+
+	oldDiscounts, newDiscounts := lineDiscounts(oldTx.Items), lineDiscounts(newTx.Items)
+	if !sameLineDiscounts(oldDiscounts, newDiscounts) {
+		changes["line_discounts"] = map[string][]lineDiscount{
+			"old": oldDiscounts,
+			"new": newDiscounts,
+		}
+	}
+
 	if len(changes) == 0 {
 		return nil
 	}
@@ -53,6 +82,44 @@ func CalculateTransactionChanges(
 	return common.ToJSONString(changes)
 }
 
+type lineDiscount struct {
+	ItemID    uint    `json:"item_id"`
+	ListPrice float64 `json:"list_price"`
+	Price     float64 `json:"price"`
+	Discount  float64 `json:"discount"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+// lineDiscounts lists the lines sold below list price or with a cashier discount
+func lineDiscounts(items []models.TransactionItem) []lineDiscount {
+	discounts := []lineDiscount{}
+	for _, item := range items {
+		if item.LineDiscount == 0 && item.Price == item.ListPrice {
+			continue
+		}
+		discounts = append(discounts, lineDiscount{
+			ItemID:    item.ItemID,
+			ListPrice: item.ListPrice,
+			Price:     item.Price,
+			Discount:  item.LineDiscount,
+			Reason:    common.GetStringValue(item.DiscountReason),
+		})
+	}
+	return discounts
+}
+
+func sameLineDiscounts(a, b []lineDiscount) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func CreateTransactionAuditLog(
 	db *gorm.DB,
 	action string,
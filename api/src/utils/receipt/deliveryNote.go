@@ -0,0 +1,190 @@
+package receipt
+
+import (
+	"fmt"
+	"time"
+
+	"kd-api/src/config"
+	"kd-api/src/models"
+)
+
+// Delivery note layout on A5 portrait, in points
+const (
+	noteMargin    = 36.0
+	noteRowHeight = 16.0
+	noteFooter    = 110.0 // Space kept free for the signature boxes on the last page
+)
+
+type noteLine struct {
+	name     string
+	quantity int
+}
+
+// deliveryNote is what gets printed, for a whole sale or for one delivery order
+type deliveryNote struct {
+	number    string
+	invoice   string // Set when the note is for a delivery order
+	date      time.Time
+	recipient string
+	address   string
+	details   []string // Driver, vehicle
+	note      string
+	lines     []noteLine
+}
+
+// BuildDeliveryNote renders an A5 delivery note for a transaction as a PDF.
+// It lists what still goes to the customer, refunded quantities are left out.
+func BuildDeliveryNote(transaction *models.Transaction, store config.StoreProfile) []byte {
+	note := deliveryNote{
+		number: documentNumber(transaction),
+		date:   transaction.CreatedAt,
+	}
+	if transaction.Customer != nil {
+		note.recipient = transaction.Customer.Name
+		if transaction.Customer.Phone != nil {
+			note.recipient += " (" + *transaction.Customer.Phone + ")"
+		}
+		if transaction.CustomerAddress != nil {
+			note.address = transaction.CustomerAddress.Address
+		}
+	}
+	if transaction.Note != nil {
+		note.note = *transaction.Note
+	}
+	for _, tItem := range transaction.Items {
+		if quantity := tItem.Quantity - tItem.RefundedQuantity; quantity > 0 {
+			note.lines = append(note.lines, noteLine{tItem.Item.Name, quantity})
+		}
+	}
+
+	return renderDeliveryNote(note, store)
+}
+
+// BuildDeliveryOrderNote renders the A5 delivery note the driver takes on one trip
+func BuildDeliveryOrderNote(order *models.DeliveryOrder, store config.StoreProfile) []byte {
+	note := deliveryNote{
+		number:    order.Number,
+		date:      order.ScheduledDate,
+		recipient: order.RecipientName,
+		address:   order.Address,
+	}
+	if order.Transaction != nil {
+		note.invoice = documentNumber(order.Transaction)
+	}
+	if order.Phone != nil {
+		note.recipient += " (" + *order.Phone + ")"
+	}
+	if order.Driver != nil {
+		note.details = append(note.details, "Driver: "+order.Driver.Username)
+	}
+	if order.Vehicle != nil {
+		note.details = append(note.details, "Vehicle: "+order.Vehicle.Name+" ("+order.Vehicle.PlateNumber+")")
+	}
+	if order.TotalWeightKg > 0 {
+		note.details = append(note.details, fmt.Sprintf("Load: %.1f kg", order.TotalWeightKg))
+	}
+	if order.Note != nil {
+		note.note = *order.Note
+	}
+	for _, line := range order.Lines {
+		note.lines = append(note.lines, noteLine{line.Item.Name, line.Quantity})
+	}
+
+	return renderDeliveryNote(note, store)
+}
+
+func renderDeliveryNote(note deliveryNote, store config.StoreProfile) []byte {
+	doc := newPDF(a5Width, a5Height)
+	right := a5Width - noteMargin
+
+	var page *pdfPage
+	var y float64
+	pageNumber := 0
+
+	startPage := func() {
+		page = doc.addPage()
+		pageNumber++
+		y = a5Height - noteMargin
+
+		page.text(noteMargin, y-14, fontBold, 14, store.Name)
+		page.textRight(right, y-14, fontBold, 14, "DELIVERY NOTE")
+		y -= 30
+		if store.Address != "" {
+			page.text(noteMargin, y, fontRegular, 9, fitText(store.Address, 9, right-noteMargin-150))
+		}
+		page.textRight(right, y, fontRegular, 9, "No: "+note.number)
+		y -= 12
+		if store.Phone != "" {
+			page.text(noteMargin, y, fontRegular, 9, store.Phone)
+		}
+		page.textRight(right, y, fontRegular, 9, "Date: "+note.date.Format("02/01/2006"))
+		if note.invoice != "" {
+			y -= 12
+			page.textRight(right, y, fontRegular, 9, "Invoice: "+note.invoice)
+		}
+		y -= 10
+		page.line(noteMargin, y, right, y)
+		y -= 18
+
+		if pageNumber == 1 && note.recipient != "" {
+			page.text(noteMargin, y, fontBold, 9, fitText("Deliver to: "+note.recipient, 9, right-noteMargin))
+			y -= 12
+			if note.address != "" {
+				page.text(noteMargin, y, fontRegular, 9, fitText(note.address, 9, right-noteMargin))
+				y -= 12
+			}
+			y -= 6
+		}
+
+		if pageNumber == 1 && len(note.details) > 0 {
+			for _, detail := range note.details {
+				page.text(noteMargin, y, fontRegular, 9, fitText(detail, 9, right-noteMargin))
+				y -= 12
+			}
+			y -= 6
+		}
+
+		if pageNumber == 1 && note.note != "" {
+			page.text(noteMargin, y, fontRegular, 9, fitText("Note: "+note.note, 9, right-noteMargin))
+			y -= 18
+		}
+
+		// Table header
+		page.rect(noteMargin, y-5, right-noteMargin, noteRowHeight)
+		page.text(noteMargin+4, y, fontBold, 10, "No")
+		page.text(noteMargin+30, y, fontBold, 10, "Item")
+		page.textRight(right-4, y, fontBold, 10, "Qty")
+		y -= noteRowHeight
+	}
+
+	startPage()
+	for i, line := range note.lines {
+		if y < noteMargin+noteRowHeight {
+			startPage()
+		}
+		page.text(noteMargin+4, y, fontRegular, 10, fmt.Sprintf("%d", i+1))
+		page.text(noteMargin+30, y, fontRegular, 10, fitText(line.name, 10, right-noteMargin-90))
+		page.textRight(right-4, y, fontRegular, 10, fmt.Sprintf("%d", line.quantity))
+		page.line(noteMargin, y-5, right, y-5)
+		y -= noteRowHeight
+	}
+
+	if y < noteMargin+noteFooter {
+		startPage()
+	}
+
+	// Signature boxes
+	boxWidth := (right - noteMargin - 20) / 2
+	boxTop := noteMargin + 80
+	for i, label := range []string{"Received by", "Delivered by"} {
+		x := noteMargin + float64(i)*(boxWidth+20)
+		page.text(x, boxTop, fontRegular, 9, label)
+		page.line(x, noteMargin+10, x+boxWidth, noteMargin+10)
+	}
+
+	for i, p := range doc.pages {
+		p.textRight(right, noteMargin-16, fontRegular, 8, fmt.Sprintf("Page %d of %d", i+1, len(doc.pages)))
+	}
+
+	return doc.bytes()
+}
@@ -0,0 +1,139 @@
+package receipt
+
+import (
+	"bytes"
+	"strings"
+)
+
+// ESC/POS command bytes, see the Epson ESC/POS reference
+var (
+	cmdInit        = []byte{0x1B, 0x40}
+	cmdAlignLeft   = []byte{0x1B, 0x61, 0x00}
+	cmdAlignCenter = []byte{0x1B, 0x61, 0x01}
+	cmdBoldOn      = []byte{0x1B, 0x45, 0x01}
+	cmdBoldOff     = []byte{0x1B, 0x45, 0x00}
+	cmdSizeNormal  = []byte{0x1D, 0x21, 0x00}
+	cmdSizeDouble  = []byte{0x1D, 0x21, 0x11}
+	cmdCut         = []byte{0x1D, 0x56, 0x42, 0x00}       // Feed to the cutter, then partial cut
+	cmdDrawerKick  = []byte{0x1B, 0x70, 0x00, 0x19, 0xFA} // Pulse pin 2 for 50ms on, 500ms off
+)
+
+// escPos accumulates an ESC/POS byte stream for a printer with a fixed line width
+type escPos struct {
+	buf   bytes.Buffer
+	width int // Characters per line in font A
+}
+
+func newEscPos(width int) *escPos {
+	p := &escPos{width: width}
+	p.buf.Write(cmdInit)
+	return p
+}
+
+func (p *escPos) left()   { p.buf.Write(cmdAlignLeft) }
+func (p *escPos) center() { p.buf.Write(cmdAlignCenter) }
+func (p *escPos) bold(on bool) {
+	if on {
+		p.buf.Write(cmdBoldOn)
+	} else {
+		p.buf.Write(cmdBoldOff)
+	}
+}
+
+// large prints double width and height, so a line holds half the characters
+func (p *escPos) large(on bool) {
+	if on {
+		p.buf.Write(cmdSizeDouble)
+	} else {
+		p.buf.Write(cmdSizeNormal)
+	}
+}
+
+func (p *escPos) line(text string) {
+	p.buf.WriteString(printable(text))
+	p.buf.WriteByte('\n')
+}
+
+// wrap prints text over as many lines as it needs
+func (p *escPos) wrap(text string) {
+	for _, l := range wrapText(printable(text), p.width) {
+		p.line(l)
+	}
+}
+
+// columns prints left and right aligned text on one line. When they do not fit together
+// the left side is wrapped and the right side goes on its own line below it.
+func (p *escPos) columns(left, right string) {
+	left, right = printable(left), printable(right)
+	if len(left)+1+len(right) <= p.width {
+		p.line(left + strings.Repeat(" ", p.width-len(left)-len(right)) + right)
+		return
+	}
+
+	if strings.TrimSpace(left) != "" {
+		p.wrap(left)
+	}
+	for _, l := range wrapText(right, p.width) {
+		p.line(strings.Repeat(" ", max(p.width-len(l), 0)) + l)
+	}
+}
+
+func (p *escPos) separator() {
+	p.line(strings.Repeat("-", p.width))
+}
+
+func (p *escPos) feed() {
+	p.buf.WriteByte('\n')
+}
+
+// qrCode prints data as a QR code rendered by the printer itself (GS ( k, model 2)
+func (p *escPos) qrCode(data string, moduleSize byte) {
+	p.buf.Write([]byte{0x1D, 0x28, 0x6B, 0x04, 0x00, 0x31, 0x41, 0x32, 0x00}) // Model 2
+	p.buf.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x43, moduleSize}) // Module size in dots
+	p.buf.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x45, 0x31})       // Error correction M
+
+	length := len(data) + 3
+	p.buf.Write([]byte{0x1D, 0x28, 0x6B, byte(length % 256), byte(length / 256), 0x31, 0x50, 0x30})
+	p.buf.WriteString(data)
+
+	p.buf.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x51, 0x30}) // Print stored symbol
+	p.feed()
+}
+
+func (p *escPos) kickDrawer() { p.buf.Write(cmdDrawerKick) }
+func (p *escPos) cut()        { p.buf.Write(cmdCut) }
+
+func (p *escPos) bytes() []byte { return p.buf.Bytes() }
+
+// printable replaces characters outside printable ASCII, thermal printers default to PC437
+func printable(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20 || r > 0x7E:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// wrapText splits text into lines of at most width characters, breaking on spaces where possible
+func wrapText(text string, width int) []string {
+	var lines []string
+	for len(text) > width {
+		cut := strings.LastIndex(text[:width+1], " ")
+		if cut <= 0 {
+			cut = width
+		}
+		lines = append(lines, strings.TrimRight(text[:cut], " "))
+		text = strings.TrimLeft(text[cut:], " ")
+	}
+	if text != "" || len(lines) == 0 {
+		lines = append(lines, text)
+	}
+	return lines
+}
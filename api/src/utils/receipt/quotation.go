@@ -0,0 +1,141 @@
+package receipt
+
+import (
+	"fmt"
+
+	"kd-api/src/config"
+	"kd-api/src/models"
+)
+
+// Quotation layout on A4 portrait, in points
+const (
+	quoteMargin    = 40.0
+	quoteRowHeight = 16.0
+	quoteFooter    = 150.0 // Totals and signature on the last page
+)
+
+// BuildQuotationPDF renders a quotation (penawaran harga) on A4 for the customer
+func BuildQuotationPDF(quotation *models.Quotation, store config.StoreProfile) []byte {
+	doc := newPDF(a4Width, a4Height)
+	right := a4Width - quoteMargin
+
+	// Column positions: No, Item, Qty, Price, Discount, Subtotal
+	colItem := quoteMargin + 28
+	colQty := right - 250
+	colPrice := right - 170
+	colDiscount := right - 90
+
+	var page *pdfPage
+	var y float64
+	pageNumber := 0
+
+	startPage := func() {
+		page = doc.addPage()
+		pageNumber++
+		y = a4Height - quoteMargin
+
+		page.text(quoteMargin, y-16, fontBold, 16, store.Name)
+		page.textRight(right, y-16, fontBold, 16, "QUOTATION")
+		y -= 34
+		if store.Address != "" {
+			page.text(quoteMargin, y, fontRegular, 9, fitText(store.Address, 9, right-quoteMargin-200))
+		}
+		page.textRight(right, y, fontRegular, 9, "No: "+quotation.Number)
+		y -= 12
+		if store.Phone != "" {
+			page.text(quoteMargin, y, fontRegular, 9, store.Phone)
+		}
+		page.textRight(right, y, fontRegular, 9, "Date: "+quotation.CreatedAt.Format("02/01/2006"))
+		y -= 12
+		if store.NPWP != "" {
+			page.text(quoteMargin, y, fontRegular, 9, "NPWP "+store.NPWP)
+		}
+		page.textRight(right, y, fontBold, 9, "Valid until: "+quotation.ValidUntil.Format("02/01/2006"))
+		y -= 10
+		page.line(quoteMargin, y, right, y)
+		y -= 18
+
+		if pageNumber == 1 && quotation.Customer != nil {
+			customer := quotation.Customer.Name
+			if quotation.Customer.Phone != nil {
+				customer += " (" + *quotation.Customer.Phone + ")"
+			}
+			page.text(quoteMargin, y, fontBold, 10, fitText("To: "+customer, 10, right-quoteMargin))
+			y -= 13
+			if quotation.CustomerAddress != nil {
+				page.text(quoteMargin, y, fontRegular, 9, fitText(quotation.CustomerAddress.Address, 9, right-quoteMargin))
+				y -= 12
+			}
+			y -= 8
+		}
+
+		// Table header
+		page.rect(quoteMargin, y-5, right-quoteMargin, quoteRowHeight)
+		page.text(quoteMargin+4, y, fontBold, 9, "No")
+		page.text(colItem, y, fontBold, 9, "Item")
+		page.textRight(colQty+30, y, fontBold, 9, "Qty")
+		page.textRight(colPrice+70, y, fontBold, 9, "Price")
+		page.textRight(colDiscount+70, y, fontBold, 9, "Discount")
+		page.textRight(right-4, y, fontBold, 9, "Subtotal")
+		y -= quoteRowHeight
+	}
+
+	startPage()
+	var subtotal float64
+	for i, line := range quotation.Lines {
+		if y < quoteMargin+quoteRowHeight {
+			startPage()
+		}
+		page.text(quoteMargin+4, y, fontRegular, 9, fmt.Sprintf("%d", i+1))
+		page.text(colItem, y, fontRegular, 9, fitText(line.Item.Name, 9, colQty-colItem-10))
+		page.textRight(colQty+30, y, fontRegular, 9, fmt.Sprintf("%d", line.Quantity))
+		page.textRight(colPrice+70, y, fontRegular, 9, FormatMoney(line.Price))
+		if line.LineDiscount > 0 {
+			page.textRight(colDiscount+70, y, fontRegular, 9, "-"+FormatMoney(line.LineDiscount))
+		}
+		page.textRight(right-4, y, fontRegular, 9, FormatMoney(line.Subtotal))
+		page.line(quoteMargin, y-5, right, y-5)
+		y -= quoteRowHeight
+		subtotal += line.Subtotal
+	}
+
+	if y < quoteMargin+quoteFooter {
+		startPage()
+	}
+
+	// Totals
+	y -= 6
+	total := func(label, amount string, font string) {
+		page.textRight(colDiscount+70, y, font, 10, label)
+		page.textRight(right-4, y, font, 10, amount)
+		y -= 14
+	}
+	total("Subtotal", FormatMoney(subtotal), fontRegular)
+	if quotation.Discount > 0 {
+		total("Discount", "-"+FormatMoney(quotation.Discount), fontRegular)
+	}
+	if quotation.TaxExclusive && quotation.TaxAmount > 0 {
+		total("PPN", FormatMoney(quotation.TaxAmount), fontRegular)
+	}
+	total("TOTAL", FormatMoney(quotation.Total), fontBold)
+	if !quotation.TaxExclusive && quotation.TaxAmount > 0 {
+		total("Incl. PPN", FormatMoney(quotation.TaxAmount), fontRegular)
+	}
+
+	if quotation.Note != nil && *quotation.Note != "" {
+		y -= 6
+		page.text(quoteMargin, y, fontRegular, 9, fitText("Note: "+*quotation.Note, 9, right-quoteMargin))
+		y -= 12
+	}
+	page.text(quoteMargin, y, fontRegular, 9, "Prices are valid until "+quotation.ValidUntil.Format("02/01/2006")+" and subject to stock availability.")
+
+	// Signature
+	page.text(right-160, quoteMargin+70, fontRegular, 9, "Regards,")
+	page.line(right-160, quoteMargin+10, right, quoteMargin+10)
+
+	for i, p := range doc.pages {
+		p.textRight(right, quoteMargin-18, fontRegular, 8, fmt.Sprintf("Page %d of %d", i+1, len(doc.pages)))
+	}
+
+	return doc.bytes()
+}
@@ -0,0 +1,230 @@
+package receipt
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"kd-api/src/config"
+	"kd-api/src/models"
+)
+
+// Characters per line in font A for the supported paper widths
+const (
+	width58mm = 32
+	width80mm = 48
+)
+
+// ReceiptOptions selects the paper width (58 or 80 mm) and whether to open the cash drawer
+type ReceiptOptions struct {
+	PaperWidth int
+	KickDrawer bool
+}
+
+// BuildReceipt renders a transaction as a raw ESC/POS byte stream
+func BuildReceipt(transaction *models.Transaction, store config.StoreProfile, options ReceiptOptions) []byte {
+	width := width58mm
+	qrSize := byte(5)
+	if options.PaperWidth == 80 {
+		width = width80mm
+		qrSize = 6
+	}
+
+	p := newEscPos(width)
+
+	// Open the drawer first so the cashier can count change while the receipt prints
+	if options.KickDrawer {
+		p.kickDrawer()
+	}
+
+	p.center()
+	p.bold(true)
+	p.large(true)
+	for _, l := range wrapText(printable(store.Name), width/2) {
+		p.line(l)
+	}
+	p.large(false)
+	p.bold(false)
+	if store.Address != "" {
+		p.wrap(store.Address)
+	}
+	if store.Phone != "" {
+		p.line(store.Phone)
+	}
+	if store.NPWP != "" {
+		p.line("NPWP " + store.NPWP)
+	}
+
+	p.left()
+	p.separator()
+	p.columns("No", documentNumber(transaction))
+	p.columns("Date", transaction.CreatedAt.Format("02/01/2006 15:04"))
+	if transaction.TransactionType == "deliver" {
+		p.columns("Type", "Delivery")
+	}
+	p.separator()
+
+	promotionNames := make(map[uint]string)
+	var salePromotion string
+	for _, promotion := range transaction.Promotions {
+		if promotion.TransactionItemID != nil {
+			promotionNames[*promotion.TransactionItemID] = promotion.Name
+		} else {
+			salePromotion = promotion.Name
+		}
+	}
+
+	var subtotal float64
+	for _, tItem := range transaction.Items {
+		p.wrap(tItem.Item.Name)
+		p.columns(
+			fmt.Sprintf("  %d x %s", tItem.Quantity, FormatMoney(tItem.Price)),
+			FormatMoney(float64(tItem.Quantity)*tItem.Price),
+		)
+		if tItem.PromotionDiscount > 0 {
+			p.columns("  "+promotionNames[tItem.ID], "-"+FormatMoney(tItem.PromotionDiscount))
+		}
+		if tItem.LineDiscount > 0 {
+			label := "  Discount"
+			if tItem.DiscountReason != nil {
+				label += " " + *tItem.DiscountReason
+			}
+			p.columns(label, "-"+FormatMoney(tItem.LineDiscount))
+		}
+		subtotal += tItem.Subtotal
+	}
+	p.separator()
+
+	p.columns("Subtotal", FormatMoney(subtotal))
+	if transaction.PromotionDiscount > 0 {
+		p.columns(salePromotion, "-"+FormatMoney(transaction.PromotionDiscount))
+	}
+	if transaction.Discount > 0 {
+		p.columns("Discount", "-"+FormatMoney(transaction.Discount))
+	}
+	if transaction.TaxExclusive && transaction.TaxAmount > 0 {
+		p.columns("PPN", FormatMoney(transaction.TaxAmount))
+	}
+	p.bold(true)
+	p.columns("TOTAL", FormatMoney(transaction.Total))
+	p.bold(false)
+	if !transaction.TaxExclusive && transaction.TaxAmount > 0 {
+		p.columns("Incl. PPN", FormatMoney(transaction.TaxAmount))
+	}
+
+	if len(transaction.Payments) > 0 {
+		for _, payment := range transaction.Payments {
+			p.columns(PaymentLabel(payment.Method), FormatMoney(payment.Amount))
+		}
+	} else if transaction.Payment != nil {
+		method := "cash"
+		if transaction.PaymentType != nil {
+			method = *transaction.PaymentType
+		}
+		p.columns(PaymentLabel(method), FormatMoney(*transaction.Payment))
+	}
+	if transaction.Change != nil && *transaction.Change > 0 {
+		p.columns("Change", FormatMoney(*transaction.Change))
+	}
+
+	for _, refund := range transaction.Refunds {
+		p.columns("Refund "+refund.Number, "-"+FormatMoney(refund.Amount))
+	}
+
+	if transaction.BalanceDue > 0 {
+		p.bold(true)
+		p.columns("BALANCE DUE", FormatMoney(transaction.BalanceDue))
+		p.bold(false)
+		for _, entry := range transaction.PaymentSchedule {
+			if open := entry.Amount - entry.PaidAmount; open > 0 {
+				p.columns("  Due "+entry.DueDate.Format("02/01/2006"), FormatMoney(open))
+			}
+		}
+	}
+
+	if transaction.AmountDue > 0 {
+		p.bold(true)
+		p.columns("AMOUNT DUE", FormatMoney(transaction.AmountDue))
+		p.bold(false)
+		if transaction.DueDate != nil {
+			p.columns("Due date", transaction.DueDate.Format("02/01/2006"))
+		}
+	}
+
+	if transaction.VoidedAt != nil {
+		p.bold(true)
+		p.columns("VOIDED", transaction.VoidedAt.Format("02/01/2006 15:04"))
+		p.bold(false)
+	}
+
+	if transaction.Note != nil && *transaction.Note != "" {
+		p.separator()
+		p.wrap(*transaction.Note)
+	}
+
+	p.separator()
+	p.center()
+	p.qrCode(strconv.FormatUint(uint64(transaction.ID), 10), qrSize)
+	if store.Footer != "" {
+		p.wrap(store.Footer)
+	}
+	p.feed()
+	p.feed()
+	p.cut()
+
+	return p.bytes()
+}
+
+// FormatMoney formats an amount the Indonesian way: 1.250.000 or 1.250,50
+func FormatMoney(amount float64) string {
+	negative := amount < 0
+	amount = math.Abs(math.Round(amount*100) / 100)
+
+	whole := int64(amount)
+	cents := int64(math.Round((amount - float64(whole)) * 100))
+
+	digits := strconv.FormatInt(whole, 10)
+	var b strings.Builder
+	if negative {
+		b.WriteByte('-')
+	}
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	if cents > 0 {
+		fmt.Fprintf(&b, ",%02d", cents)
+	}
+	return b.String()
+}
+
+// PaymentLabel is the printed name of a tender method
+func PaymentLabel(method string) string {
+	switch method {
+	case "cash":
+		return "Cash"
+	case "qris":
+		return "QRIS"
+	case "debit":
+		return "Debit"
+	case "credit":
+		return "On account"
+	case "exchange":
+		return "Exchange credit"
+	case "split":
+		return "Split payment"
+	default:
+		return method
+	}
+}
+
+// documentNumber is the invoice number printed for a sale, drafts only have their id
+func documentNumber(transaction *models.Transaction) string {
+	if transaction.Number != nil {
+		return *transaction.Number
+	}
+	return fmt.Sprintf("TX-%d", transaction.ID)
+}
@@ -0,0 +1,108 @@
+package receipt
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1.000"},
+		{1250000, "1.250.000"},
+		{1250.5, "1.250,50"},
+		{123456789.12, "123.456.789,12"},
+		{1000.999, "1.001"},
+		{0.005, "0,01"},
+		{-1500, "-1.500"},
+		{-20000.25, "-20.000,25"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatMoney(tt.amount); got != tt.want {
+			t.Errorf("FormatMoney(%v) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{"fits", "short", 10, []string{"short"}},
+		{"empty", "", 10, []string{""}},
+		{"breaks on spaces", "one two three", 7, []string{"one two", "three"}},
+		{"break exactly at the width", "hello world", 5, []string{"hello", "world"}},
+		{"long word is cut", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"extra spaces are dropped at the break", "one   two", 4, []string{"one", "two"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapText(tt.text, tt.width); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrapText(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		left  string
+		right string
+		want  []string
+	}{
+		{
+			name:  "both fit",
+			left:  "No",
+			right: "INV-1",
+			want:  []string{"No         INV-1"},
+		},
+		{
+			name:  "exact fit",
+			left:  "Subtotal12",
+			right: "1.000",
+			want:  []string{"Subtotal12 1.000"},
+		},
+		{
+			name:  "long left side wraps above the right side",
+			left:  "  Promo Lebaran Besar",
+			right: "-10.000",
+			want:  []string{"  Promo Lebaran", "Besar", "         -10.000"},
+		},
+		{
+			name:  "right side wider than the paper",
+			left:  "No",
+			right: "INV/2025/03/000042",
+			want:  []string{"No", "INV/2025/03/0000", "              42"},
+		},
+		{
+			name:  "no blank line for an empty left side",
+			left:  "",
+			right: "INV/2025/03/000042",
+			want:  []string{"INV/2025/03/0000", "              42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newEscPos(16)
+			p.columns(tt.left, tt.right)
+
+			out := strings.TrimSuffix(string(bytes.TrimPrefix(p.bytes(), cmdInit)), "\n")
+			if got := strings.Split(out, "\n"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columns(%q, %q) printed %q, want %q", tt.left, tt.right, got, tt.want)
+			}
+		})
+	}
+}
@@ -8,8 +8,11 @@ type ItemResponseCashier struct {
 	Name        string  `json:"name"`
 	Description *string `json:"description,omitempty"`
 	Stock       int     `json:"stock"`
+	Reserved    int     `json:"reserved_stock"`
+	Available   int     `json:"available_stock"`
 	Price       float64 `json:"price"`
 	ImageURL    *string `json:"image_url,omitempty"`
+	Category    *string `json:"category,omitempty"`
 }
 
 // Mapping slice item berdasarkan role user
@@ -41,7 +44,10 @@ func mapItemForCashier(item models.Item) ItemResponseCashier {
 		Name:        item.Name,
 		Description: item.Description,
 		Stock:       item.Stock,
+		Reserved:    item.ReservedStock,
+		Available:   item.AvailableStock,
 		Price:       item.Price,
 		ImageURL:    item.ImageURL,
+		Category:    item.Category,
 	}
 }